@@ -20,13 +20,21 @@ const (
 	EUNKNOWN        = "unknown"         // unknown error
 	EFORBIDDEN      = "forbidden"       // access forbidden
 	EEXISTS         = "exists"          // resource already exists
+	ERATELIMIT      = "rate_limit"      // too many requests
+	ETOOLARGE       = "too_large"       // request too large
 
 	EMGVM                     = "mgvm"                // error code prefix for music gang virtual machine, it is assimilated to EINTERNAL
 	EMGVM_LOWFUEL             = "low_fuel"            // subcode for EMGVM, low fuel
+	EMGVM_USER_LOWFUEL        = "user_low_fuel"       // subcode for EMGVM, low fuel in the caller wallet
 	EMGVM_CORE_POOL_NOT_FOUND = "core_pool_not_found" // subcode for EMGVM, core pool not found
 	EMGVM_CORE_POOL_TIMEOUT   = "core_pool_timeout"   // subcode for EMGVM, core pool timeout
+	EMGVM_MEMORY_LIMIT        = "memory_limit"        // subcode for EMGVM, the contract exceeded the memory ceiling of its revision
+	EMGVM_OUTPUT_TOO_LARGE    = "output_too_large"    // subcode for EMGVM, the result of the contract is too large
+	EMGVM_STATE_TOO_LARGE     = "state_too_large"     // subcode for EMGVM, the state of the contract is too large
 
 	EANCHORAGE = "anchorage" // error code prefix for anchorage contract executor, it is assimilated to EINTERNAL
+	EBOSTON    = "boston"    // error code prefix for boston contract executor, it is assimilated to EINTERNAL
+	ECHICAGO   = "chicago"   // error code prefix for chicago contract executor, it is assimilated to EINTERNAL
 )
 
 // Error represents an application-specific error. Application errors can be
@@ -42,6 +50,10 @@ type Error struct {
 
 	// Human-readable error message.
 	Message string
+
+	// Machine-readable error details, like the position of a syntax error.
+	// Can be nil if the error has no details.
+	Details interface{}
 }
 
 // Error implements the error interface.
@@ -73,6 +85,18 @@ func ErrorMessage(err error) string {
 	return err.Error()
 }
 
+// ErrorDetails unwraps an application error and returns its details.
+// Non-application errors always return nil.
+func ErrorDetails(err error) interface{} {
+	var e *Error
+	if err == nil {
+		return nil
+	} else if errors.As(err, &e) {
+		return e.Details
+	}
+	return nil
+}
+
 // ErrorLog returns the params for a log entry for an application error.
 func ErrorLog(err error) (string, string, string) {
 	var e *Error
@@ -84,6 +108,12 @@ func ErrorLog(err error) (string, string, string) {
 	return err.Error(), "code", EINTERNAL
 }
 
+// WithDetails attaches the details to the error and returns it.
+func (e *Error) WithDetails(details interface{}) *Error {
+	e.Details = details
+	return e
+}
+
 // Errorf is a helper function to return an Error with a given code and formatted message.
 func Errorf(code string, format string, args ...interface{}) *Error {
 	return &Error{
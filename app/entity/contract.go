@@ -27,6 +27,41 @@ func (v Visibility) Validate() error {
 	}
 }
 
+// ContractOrderBy consts for the sorting of the contracts.
+const (
+	ContractOrderByID        = "id"
+	ContractOrderByCreatedAt = "created_at"
+	ContractOrderByUpdatedAt = "updated_at"
+)
+
+// ContractOrderBy defines the field used to sort the contracts.
+type ContractOrderBy string
+
+// Validate validates the contract order by.
+func (o ContractOrderBy) Validate() error {
+	switch o {
+	case
+		ContractOrderByID,
+		ContractOrderByCreatedAt,
+		ContractOrderByUpdatedAt:
+		return nil
+	default:
+		return apperr.Errorf(apperr.EINVALID, "invalid contract order by")
+	}
+}
+
+// ContractRetention is how long a deleted contract can be restored before it is purged.
+// This is the default value that may be overwritten by the init function.
+var ContractRetention = 30 * 24 * time.Hour
+
+// ContractPurgeRate is the rate of the purge of the deleted contracts.
+// This is the default value that may be overwritten by the init function.
+var ContractPurgeRate = time.Hour
+
+// MaxContractResultSize is the maximum size in bytes of the JSON encoded result of a contract call.
+// This is the default value that may be overwritten by the init function.
+var MaxContractResultSize = 1 << 20
+
 // Contracts represents a list of contracts.
 type Contracts []*Contract
 
@@ -44,9 +79,20 @@ type Contract struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
+	ActiveRev         RevisionNumber `json:"active_rev"`          // The revision run by the calls without a revision number, 0 runs the last revision.
+	PreviousActiveRev RevisionNumber `json:"previous_active_rev"` // The revision active before the last change of ActiveRev, it is restored by the rollback.
+
 	// avoid to access this field directly because it can be nil, use the Revision method UnwrapRevision instead.
 	LastRevision *Revision `json:"last_revision"`
-	User         *User     `json:"user"`
+	// avoid to access this field directly because it is nil if no revision is pinned, use the method UnwrapActiveRevision instead.
+	ActiveRevision *Revision `json:"active_revision"`
+	User           *User     `json:"user"`
+}
+
+// IsAccessibleBy returns if the user can read and call the contract.
+// Private contracts are accessible only by their owner, userID is 0 for anonymous users.
+func (c *Contract) IsAccessibleBy(userID int64) bool {
+	return c.Visibility != VisibilityPrivate || (userID != 0 && c.UserID == userID)
 }
 
 // MaxExecutionTime returns the maximum execution time of the contract.
@@ -77,6 +123,27 @@ func (c *Contract) Validate() error {
 	return nil
 }
 
+// EffectiveActiveRev returns the revision number run by the calls without a revision number.
+// It is 0 if the contract has no revision.
+func (c *Contract) EffectiveActiveRev() RevisionNumber {
+	if c.ActiveRev != 0 {
+		return c.ActiveRev
+	}
+	if c.LastRevision != nil {
+		return c.LastRevision.Rev
+	}
+	return 0
+}
+
+// UnwrapActiveRevision returns the revision run by the calls without a revision number.
+// It is the pinned active revision if any, otherwise the last revision of the contract.
+func (c *Contract) UnwrapActiveRevision() (*Revision, error) {
+	if c.ActiveRevision != nil {
+		return c.ActiveRevision, nil
+	}
+	return c.UnwrapRevision()
+}
+
 // UnwrapRevision returns the last revision of the contract if it exists, otherwise error is returned.
 func (c *Contract) UnwrapRevision() (*Revision, error) {
 	if c.LastRevision == nil {
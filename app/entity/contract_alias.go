@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+)
+
+// ContractAliasPrefix is the prefix that marks an alias in place of a revision number, like @canary.
+const ContractAliasPrefix = "@"
+
+// ContractAliasMaxSplitWeight is the split weight that routes every call to the split revision.
+const ContractAliasMaxSplitWeight = 100
+
+// contractAliasNameRegexp matches the valid alias names, like stable, canary or beta-2.
+var contractAliasNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+
+// ContractAliases represents a list of contract aliases.
+type ContractAliases []*ContractAlias
+
+// ContractAlias represents a named pointer to the revisions of a contract, like stable or canary.
+// The alias can split the calls between two revisions, SplitWeight percent of them run SplitRev.
+type ContractAlias struct {
+	ID          int64          `json:"id"`
+	ContractID  int64          `json:"contract_id"`
+	Name        string         `json:"name"`
+	Rev         RevisionNumber `json:"rev"`
+	SplitRev    RevisionNumber `json:"split_rev"`    // The revision receiving SplitWeight percent of the calls, 0 disables the split.
+	SplitWeight int            `json:"split_weight"` // The percent of the calls routed to SplitRev, from 0 to 100.
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Resolve returns the revision that runs the call, roll is a random number in [0, 100).
+func (a *ContractAlias) Resolve(roll int) RevisionNumber {
+	if a.SplitRev != 0 && roll < a.SplitWeight {
+		return a.SplitRev
+	}
+	return a.Rev
+}
+
+// Validate validates the contract alias.
+func (a *ContractAlias) Validate() error {
+
+	if a.ContractID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "contract id is required")
+	}
+
+	if err := ValidateContractAliasName(a.Name); err != nil {
+		return err
+	}
+
+	if a.Rev == 0 {
+		return apperr.Errorf(apperr.EINVALID, "alias revision is required")
+	}
+
+	if a.SplitWeight < 0 || a.SplitWeight > ContractAliasMaxSplitWeight {
+		return apperr.Errorf(apperr.EINVALID, "split weight must be between 0 and %d", ContractAliasMaxSplitWeight)
+	}
+
+	if a.SplitRev == 0 && a.SplitWeight != 0 {
+		return apperr.Errorf(apperr.EINVALID, "split weight requires a split revision")
+	}
+
+	if a.SplitRev != 0 && a.SplitRev == a.Rev {
+		return apperr.Errorf(apperr.EINVALID, "split revision must differ from the alias revision")
+	}
+
+	return nil
+}
+
+// ValidateContractAliasName validates the name of an alias.
+func ValidateContractAliasName(name string) error {
+	if !contractAliasNameRegexp.MatchString(name) {
+		return apperr.Errorf(apperr.EINVALID, "invalid alias name, it must start with a lowercase letter followed by lowercase letters, digits, - or _")
+	}
+	return nil
+}
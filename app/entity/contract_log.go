@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"sync"
+	"time"
+
+	"github.com/music-gang/music-gang-api/common"
+)
+
+// Log levels of the contract console.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+const (
+	// MaxContractLogEntries is the maximum number of log entries captured during a single contract call.
+	MaxContractLogEntries = 100
+	// MaxContractLogMessageSize is the maximum size in bytes of a single log message, longer messages are truncated.
+	MaxContractLogMessageSize = 1024
+)
+
+// ContractLogs represents a list of contract logs.
+type ContractLogs []*ContractLog
+
+// ContractLog represents a single line written by a contract through the console object.
+type ContractLog struct {
+	ContractID int64     `json:"contract_id"`
+	RevisionID int64     `json:"revision_id"`
+	UserID     int64     `json:"user_id"`
+	Level      string    `json:"level"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ContractLogBuffer is a bounded buffer of logs captured during a single contract call.
+// Once the buffer is full, new entries are discarded and the buffer is marked as truncated.
+// It is thread-safe.
+type ContractLogBuffer struct {
+	mux       sync.Mutex
+	logs      ContractLogs
+	truncated bool
+}
+
+// NewContractLogBuffer creates a new empty ContractLogBuffer.
+func NewContractLogBuffer() *ContractLogBuffer {
+	return &ContractLogBuffer{
+		logs: make(ContractLogs, 0),
+	}
+}
+
+// Append adds a new log entry to the buffer.
+func (b *ContractLogBuffer) Append(level string, message string) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if len(b.logs) >= MaxContractLogEntries {
+		b.truncated = true
+		return
+	}
+
+	if len(message) > MaxContractLogMessageSize {
+		message = message[:MaxContractLogMessageSize]
+		b.truncated = true
+	}
+
+	b.logs = append(b.logs, &ContractLog{
+		Level:     level,
+		Message:   message,
+		CreatedAt: common.AppNowUTC(),
+	})
+}
+
+// Logs returns a copy of the logs stored in the buffer.
+func (b *ContractLogBuffer) Logs() ContractLogs {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	logs := make(ContractLogs, len(b.logs))
+	for i, l := range b.logs {
+		entry := *l
+		logs[i] = &entry
+	}
+	return logs
+}
+
+// Truncated returns true if some entries or messages were discarded.
+func (b *ContractLogBuffer) Truncated() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.truncated
+}
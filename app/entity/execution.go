@@ -0,0 +1,160 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"gopkg.in/guregu/null.v4"
+)
+
+// ExecutionOutcome consts for the outcome of a contract execution.
+const (
+	ExecutionOutcomeOK        = "ok"
+	ExecutionOutcomeError     = "error"
+	ExecutionOutcomeTimeout   = "timeout"
+	ExecutionOutcomePanic     = "panic"
+	ExecutionOutcomeOutOfFuel = "out_of_fuel"
+
+	ExecutionOutcomeMemoryLimit    = "memory_limit"
+	ExecutionOutcomeOutputTooLarge = "output_too_large"
+	ExecutionOutcomeStateTooLarge  = "state_too_large"
+)
+
+// ExecutionSnapshotRetention is how long the recorded call of an execution is kept before it is purged.
+// This is the default value that may be overwritten by the init function.
+var ExecutionSnapshotRetention = 7 * 24 * time.Hour
+
+// ExecutionSnapshotPurgeRate is the rate of the purge of the recorded calls.
+// This is the default value that may be overwritten by the init function.
+var ExecutionSnapshotPurgeRate = time.Hour
+
+// ExecutionOutcome defines how a contract execution ended.
+type ExecutionOutcome string
+
+// Validate validates the execution outcome.
+func (o ExecutionOutcome) Validate() error {
+	switch o {
+	case
+		ExecutionOutcomeOK,
+		ExecutionOutcomeError,
+		ExecutionOutcomeTimeout,
+		ExecutionOutcomePanic,
+		ExecutionOutcomeOutOfFuel,
+		ExecutionOutcomeMemoryLimit,
+		ExecutionOutcomeOutputTooLarge,
+		ExecutionOutcomeStateTooLarge:
+		return nil
+	default:
+		return apperr.Errorf(apperr.EINVALID, "invalid execution outcome")
+	}
+}
+
+// Executions represents a list of executions.
+type Executions []*Execution
+
+// Execution represents the record of a single contract call.
+// It is written by the VM after every execution and it is never updated.
+type Execution struct {
+	ID           int64            `json:"id"`
+	ContractID   int64            `json:"contract_id"`
+	RevisionID   int64            `json:"revision_id"`
+	Rev          RevisionNumber   `json:"rev"`     // The number of the revision called.
+	Alias        string           `json:"alias"`   // The alias resolved to the revision, empty if the revision is called by number.
+	UserID       null.Int         `json:"user_id"` // The caller, null if the call is not bound to a user.
+	StartedAt    time.Time        `json:"started_at"`
+	EndedAt      time.Time        `json:"ended_at"`
+	FuelReserved Fuel             `json:"fuel_reserved"` // The fuel burned before the execution.
+	FuelCharged  Fuel             `json:"fuel_charged"`  // The fuel effectively consumed after the refuel.
+	Outcome      ExecutionOutcome `json:"outcome"`
+	ErrorCode    string           `json:"error_code"`  // Empty if the outcome is ok.
+	ResultSize   int              `json:"result_size"` // Size in bytes of the JSON encoded result.
+	Seed         int64            `json:"seed"`        // The seed of Math.random in the contract.
+	FrozenAt     null.Time        `json:"frozen_at"`   // The time seen by the contract, null if the execution did not run in a sandbox.
+
+	// Snapshot is the recorded call, it is loaded only with the single execution.
+	Snapshot *ExecutionSnapshot `json:"-"`
+}
+
+// ExecutionSnapshot represents the recorded call of an execution, so the execution can be replayed.
+type ExecutionSnapshot struct {
+	Input       any        `json:"input"`
+	StateBefore StateValue `json:"state_before"` // The state before the call, nil if the contract is stateless.
+	Result      any        `json:"result"`       // Nil if the execution failed.
+	StateAfter  StateValue `json:"state_after"`  // The state left by the call, nil if the contract is stateless or the execution failed.
+}
+
+// ExecutionReplay represents the outcome of a recorded execution run again, compared with the original one.
+type ExecutionReplay struct {
+	ExecutionID    int64          `json:"execution_id"`
+	Rev            RevisionNumber `json:"rev"`          // The revision that ran the replay.
+	OriginalRev    RevisionNumber `json:"original_rev"` // The revision that ran the original execution.
+	Result         any            `json:"result"`
+	OriginalResult any            `json:"original_result"`
+	ResultChanged  bool           `json:"result_changed"`
+	State          StateValue     `json:"state"`      // The state left by the replay, nil if the contract is stateless.
+	StateDiff      StateDiff      `json:"state_diff"` // The keys of the state left differently from the original execution.
+}
+
+// DryRun represents the outcome of a contract call whose state changes are not stored.
+type DryRun struct {
+	StateDiff StateDiff `json:"state_diff"` // The keys of the state the call would change, empty if the contract is stateless.
+	Fuel      Fuel      `json:"fuel"`       // The fuel the call would be charged.
+}
+
+// NewExecutionReplay compares the result and the state of a replay with the ones recorded by the execution.
+func NewExecutionReplay(execution *Execution, revision *Revision, result any, state StateValue) *ExecutionReplay {
+
+	replay := &ExecutionReplay{
+		ExecutionID: execution.ID,
+		Rev:         revision.Rev,
+		OriginalRev: execution.Rev,
+		Result:      result,
+		State:       state,
+		StateDiff:   make(StateDiff),
+	}
+
+	if execution.Snapshot != nil {
+		replay.OriginalResult = execution.Snapshot.Result
+		replay.StateDiff = execution.Snapshot.StateAfter.Diff(state)
+	}
+
+	replay.ResultChanged = !jsonEqual(replay.OriginalResult, result)
+
+	return replay
+}
+
+// Sandbox returns the sandbox the execution ran in.
+// Return nil if the execution did not run in a sandbox.
+func (e *Execution) Sandbox() *Sandbox {
+	if !e.FrozenAt.Valid {
+		return nil
+	}
+	return &Sandbox{Seed: e.Seed, Now: e.FrozenAt.Time}
+}
+
+// Duration returns the wall time spent by the execution.
+func (e *Execution) Duration() time.Duration {
+	return e.EndedAt.Sub(e.StartedAt)
+}
+
+// Validate validates the execution.
+func (e *Execution) Validate() error {
+
+	if e.ContractID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "contract id is required")
+	}
+
+	if e.RevisionID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "revision id is required")
+	}
+
+	if e.StartedAt.IsZero() || e.EndedAt.IsZero() {
+		return apperr.Errorf(apperr.EINVALID, "start and end time are required")
+	}
+
+	if e.EndedAt.Before(e.StartedAt) {
+		return apperr.Errorf(apperr.EINVALID, "end time cannot be before start time")
+	}
+
+	return e.Outcome.Validate()
+}
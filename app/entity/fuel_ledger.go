@@ -0,0 +1,122 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"gopkg.in/guregu/null.v4"
+)
+
+// FuelLedgerKind consts for the kind of movement recorded in the fuel ledger.
+const (
+	FuelLedgerKindBurn   = "burn"   // fuel burned before an operation
+	FuelLedgerKindRefund = "refund" // fuel given back after an operation, because it was not consumed
+	FuelLedgerKindRefill = "refill" // fuel refilled by the fuel station
+)
+
+// FuelLedgerKind defines the kind of a fuel ledger entry.
+type FuelLedgerKind string
+
+// Validate validates the fuel ledger kind.
+func (k FuelLedgerKind) Validate() error {
+	switch k {
+	case
+		FuelLedgerKindBurn,
+		FuelLedgerKindRefund,
+		FuelLedgerKindRefill:
+		return nil
+	default:
+		return apperr.Errorf(apperr.EINVALID, "invalid fuel ledger kind")
+	}
+}
+
+// FuelLedgerEntries represents a list of fuel ledger entries.
+type FuelLedgerEntries []*FuelLedgerEntry
+
+// FuelLedgerEntry represents a single fuel movement of the fuel tank.
+// The ledger is append-only, entries are never updated or deleted.
+type FuelLedgerEntry struct {
+	ID         int64          `json:"id"`
+	Kind       FuelLedgerKind `json:"kind"`
+	Operation  VmOperation    `json:"operation"` // Empty for the station refills.
+	UserID     null.Int       `json:"user_id"`
+	ContractID null.Int       `json:"contract_id"`
+	RevisionID null.Int       `json:"revision_id"`
+	Amount     Fuel           `json:"amount"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// Validate validates the fuel ledger entry.
+func (e *FuelLedgerEntry) Validate() error {
+
+	if e.Amount == 0 {
+		return apperr.Errorf(apperr.EINVALID, "amount is required")
+	}
+
+	return e.Kind.Validate()
+}
+
+// FuelUsageGroupBy consts for the aggregations of the fuel ledger.
+const (
+	FuelUsageGroupByUser     = "user"
+	FuelUsageGroupByContract = "contract"
+	FuelUsageGroupByBucket   = "bucket"
+)
+
+// FuelUsageGroupBy defines how the fuel ledger entries are aggregated.
+type FuelUsageGroupBy string
+
+// Validate validates the fuel usage group by.
+func (g FuelUsageGroupBy) Validate() error {
+	switch g {
+	case
+		FuelUsageGroupByUser,
+		FuelUsageGroupByContract,
+		FuelUsageGroupByBucket:
+		return nil
+	default:
+		return apperr.Errorf(apperr.EINVALID, "invalid fuel usage group by")
+	}
+}
+
+// FuelUsageBucket consts for the size of the time buckets.
+const (
+	FuelUsageBucketMinute = "minute"
+	FuelUsageBucketHour   = "hour"
+	FuelUsageBucketDay    = "day"
+	FuelUsageBucketWeek   = "week"
+	FuelUsageBucketMonth  = "month"
+)
+
+// FuelUsageBucket defines the size of the time buckets used to aggregate the fuel ledger.
+type FuelUsageBucket string
+
+// Validate validates the fuel usage bucket.
+func (b FuelUsageBucket) Validate() error {
+	switch b {
+	case
+		FuelUsageBucketMinute,
+		FuelUsageBucketHour,
+		FuelUsageBucketDay,
+		FuelUsageBucketWeek,
+		FuelUsageBucketMonth:
+		return nil
+	default:
+		return apperr.Errorf(apperr.EINVALID, "invalid fuel usage bucket")
+	}
+}
+
+// FuelUsages represents a list of fuel usages.
+type FuelUsages []*FuelUsage
+
+// FuelUsage represents the fuel ledger aggregated by user, contract or time bucket.
+// Only the field used to group the entries is valued between UserID, ContractID and Bucket.
+type FuelUsage struct {
+	UserID     null.Int  `json:"user_id"`
+	ContractID null.Int  `json:"contract_id"`
+	Bucket     null.Time `json:"bucket"`
+	Burned     Fuel      `json:"burned"`
+	Refunded   Fuel      `json:"refunded"`
+	Refilled   Fuel      `json:"refilled"`
+	Consumed   Fuel      `json:"consumed"` // Burned minus refunded, it is the fuel effectively used.
+}
@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+)
+
+// FuelPrice is the number of work units that costs one vFuel.
+// A work unit is a deterministic step of the execution, like a statement executed by the JS engines or an instruction executed by the WASM engine.
+type FuelPrice uint64
+
+// FuelPriceTable is the price of the work units reported by the executor of every revision version.
+// The work units are not the same for every executor, so every version has its own price.
+type FuelPriceTable map[RevisionVersion]FuelPrice
+
+// FuelPrices is the price table used to charge the contract executions.
+// The revision versions without a price are charged on the execution time.
+// This is the default value that may be overwritten by the init function.
+var FuelPrices = FuelPriceTable{
+	AnchorageVersion: 500,
+	BostonVersion:    2500,
+	ChicagoVersion:   50000,
+}
+
+// ParseFuelPriceTable parses a price table in the format "Version:price,Version:price", like "Anchorage:500,Boston:2500,Chicago:50000".
+func ParseFuelPriceTable(s string) (FuelPriceTable, error) {
+
+	table := make(FuelPriceTable)
+
+	for _, entry := range strings.Split(s, ",") {
+
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		version, rawPrice, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, apperr.Errorf(apperr.EINVALID, "invalid config fuel price format: %s", entry)
+		}
+
+		price, err := strconv.ParseUint(strings.TrimSpace(rawPrice), 10, 64)
+		if err != nil || price == 0 {
+			return nil, apperr.Errorf(apperr.EINVALID, "Cannot parse fuel price: %s", entry)
+		}
+
+		table[RevisionVersion(strings.TrimSpace(version))] = FuelPrice(price)
+	}
+
+	return table, nil
+}
+
+// FuelMeter measures the work done by a single contract execution and converts it into fuel.
+// The fuel used is deterministic, it does not depend on the load of the machine.
+// A FuelMeter is not safe for concurrent use, it is owned by the executor running the contract.
+type FuelMeter struct {
+	price FuelPrice
+	limit Fuel
+	units uint64
+}
+
+// NewFuelMeter creates a new FuelMeter that allows to use at most limit fuel, charged at the given price.
+func NewFuelMeter(limit Fuel, price FuelPrice) *FuelMeter {
+	if price == 0 {
+		price = 1
+	}
+	return &FuelMeter{
+		price: price,
+		limit: limit,
+	}
+}
+
+// Consume adds the work units done by the execution.
+// Returns false if the fuel used exceeds the limit, in this case the execution must stop.
+func (m *FuelMeter) Consume(units uint64) bool {
+	m.units += units
+	return !m.Exhausted()
+}
+
+// Exhausted returns true if the fuel used exceeds the limit.
+func (m *FuelMeter) Exhausted() bool {
+	return m.units > uint64(m.limit)*uint64(m.price)
+}
+
+// Limit returns the maximum fuel that the execution can use.
+func (m *FuelMeter) Limit() Fuel {
+	return m.limit
+}
+
+// Remaining returns the work units the execution can still do before the fuel is exhausted.
+func (m *FuelMeter) Remaining() uint64 {
+	if m.Exhausted() {
+		return 0
+	}
+	return uint64(m.limit)*uint64(m.price) - m.units
+}
+
+// Units returns the work units done by the execution.
+func (m *FuelMeter) Units() uint64 {
+	return m.units
+}
+
+// Charged returns the fuel charged for the execution.
+// Like the executions charged on the time, every execution costs at least FuelInstantActionAmount, it is never greater than the limit.
+func (m *FuelMeter) Charged() Fuel {
+	charged := m.Used()
+	if charged < FuelInstantActionAmount {
+		charged = FuelInstantActionAmount
+	}
+	if charged > m.limit {
+		charged = m.limit
+	}
+	return charged
+}
+
+// Used returns the fuel used by the execution, every started vFuel is charged.
+// It is never greater than the limit.
+func (m *FuelMeter) Used() Fuel {
+	if m.Exhausted() {
+		return m.limit
+	}
+	return Fuel((m.units + uint64(m.price) - 1) / uint64(m.price))
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+)
+
+// UserFuelCapacity is the maximum capacity of the fuel wallet of every user.
+// This is the default value that may be overwritten by the init function.
+var UserFuelCapacity = 10 * vKFuel
+
+// UserFuelRefillAmount is how much fuel is given back to a user wallet at every refill.
+// It is equivalent to 10% of the capacity of the wallet.
+// This is the default value that may be overwritten by the init function.
+var UserFuelRefillAmount = Fuel(UserFuelCapacity * 10 / 100)
+
+// UserFuelRefillRate is the rate of the user wallet refills.
+// This is the default value that may be overwritten by the init function.
+var UserFuelRefillRate = time.Minute
+
+// AnonymousFuelWalletID is the id of the fuel wallet shared by the anonymous callers of public contracts.
+// It never matches a user, so anonymous calls cannot drain the wallets of the users.
+const AnonymousFuelWalletID int64 = -1
+
+// FuelWallet represents the fuel budget of a single user.
+// It works like the global fuel tank, but it only limits the operations performed by its owner.
+// Refills are not scheduled, they are applied lazily based on the time elapsed since the last refill.
+type FuelWallet struct {
+	UserID       int64     `json:"user_id"`
+	FuelCapacity Fuel      `json:"fuel_capacity"`
+	FuelUsed     Fuel      `json:"fuel_used"`
+	LastRefuelAt time.Time `json:"last_refuel_at"`
+}
+
+// NewFuelWallet creates an empty fuel wallet for the given user.
+func NewFuelWallet(userID int64, now time.Time) *FuelWallet {
+	return &FuelWallet{
+		UserID:       userID,
+		FuelCapacity: UserFuelCapacity,
+		LastRefuelAt: now,
+	}
+}
+
+// FuelAvailable returns the fuel that can still be burned from the wallet.
+func (w *FuelWallet) FuelAvailable() Fuel {
+	if w.FuelUsed >= w.FuelCapacity {
+		return 0
+	}
+	return w.FuelCapacity - w.FuelUsed
+}
+
+// Burn consumes the specified amount of fuel.
+// Return EMGVM_USER_LOWFUEL if the fuel available is not enough.
+func (w *FuelWallet) Burn(fuel Fuel) error {
+	if available := w.FuelAvailable(); fuel > available {
+		return apperr.Errorf(apperr.EMGVM_USER_LOWFUEL, "your fuel wallet is not enough: %d vFuel available of %d, operation requires %d", available, w.FuelCapacity, fuel)
+	}
+	w.FuelUsed += fuel
+	return nil
+}
+
+// Refuel gives back the specified amount of fuel, fuel used cannot go below 0.
+func (w *FuelWallet) Refuel(fuel Fuel) {
+	if fuel > w.FuelUsed {
+		fuel = w.FuelUsed
+	}
+	w.FuelUsed -= fuel
+}
+
+// Refill applies all the refills elapsed between the last refill and now.
+func (w *FuelWallet) Refill(now time.Time) {
+
+	if UserFuelRefillRate <= 0 || !now.After(w.LastRefuelAt) {
+		return
+	}
+
+	refills := now.Sub(w.LastRefuelAt) / UserFuelRefillRate
+	if refills <= 0 {
+		return
+	}
+
+	w.Refuel(Fuel(refills) * UserFuelRefillAmount)
+	w.LastRefuelAt = w.LastRefuelAt.Add(refills * UserFuelRefillRate)
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/music-gang/music-gang-api/app/apperr"
@@ -12,10 +14,28 @@ type RevisionVersion string
 // This is a list of all revisions versions.
 const (
 	AnchorageVersion RevisionVersion = "Anchorage"
+	BostonVersion    RevisionVersion = "Boston"  // ES2015+ code, like let, const, arrow functions, classes and promises.
+	ChicagoVersion   RevisionVersion = "Chicago" // WebAssembly modules, like the ones built from Rust, TinyGo and AssemblyScript.
 
 	CurrentRevisionVersion RevisionVersion = AnchorageVersion
 )
 
+// CodeExtension returns the file extension of the code written for the version.
+func (v RevisionVersion) CodeExtension() string {
+	if v == ChicagoVersion {
+		return ".wasm"
+	}
+	return ".js"
+}
+
+// DefaultContractMemory is the memory ceiling, in bytes, of the revisions that do not set their own.
+// This is the default value that may be overwritten by the init function.
+var DefaultContractMemory int64 = 16 << 20
+
+// MaxContractMemory is the highest memory ceiling, in bytes, that a revision can set.
+// This is the default value that may be overwritten by the init function.
+var MaxContractMemory int64 = 64 << 20
+
 // RevisionNumber is the revision number of the entity.
 type RevisionNumber uint
 
@@ -32,11 +52,39 @@ type Revision struct {
 	ContractID   int64           `json:"contract_id"`
 	Notes        string          `json:"notes"`
 	CompiledCode []byte          `json:"-"`
+	CodeHash     string          `json:"code_hash"` // The hex SHA-256 of CompiledCode.
 	MaxFuel      Fuel            `json:"max_fuel"`
+	MaxMemory    int64           `json:"max_memory"` // The memory ceiling in bytes, 0 uses DefaultContractMemory.
 
 	Contract *Contract `json:"contract"`
 }
 
+// RevisionCodeError reports a problem found in the code of a revision before it is stored.
+// Line and Column start at 1, they are 0 if the problem is not bound to a position.
+type RevisionCodeError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// UnwrapCodeHash returns the hash of the compiled code.
+// The revisions stored before the hashes were introduced may have no hash, so it is computed from the code.
+func (r *Revision) UnwrapCodeHash() string {
+	if r.CodeHash == "" {
+		return HashRevisionCode(r.CompiledCode)
+	}
+	return r.CodeHash
+}
+
+// UnwrapMaxMemory returns the memory ceiling of the revision in bytes.
+// If the revision does not set its own ceiling, DefaultContractMemory is returned.
+func (r *Revision) UnwrapMaxMemory() int64 {
+	if r.MaxMemory == 0 {
+		return DefaultContractMemory
+	}
+	return r.MaxMemory
+}
+
 // Validate validates the revision.
 func (r *Revision) Validate() error {
 
@@ -48,9 +96,10 @@ func (r *Revision) Validate() error {
 
 		return apperr.Errorf(apperr.EINVALID, "contract id is required")
 
-	} else if r.Version != CurrentRevisionVersion {
+	} else if r.Version == "" {
 
-		return apperr.Errorf(apperr.EINVALID, "invalid revision version")
+		// the version is checked against the registered executors when the code is compiled.
+		return apperr.Errorf(apperr.EINVALID, "revision version is required")
 
 	} else if r.CreatedAt.IsZero() {
 
@@ -60,6 +109,10 @@ func (r *Revision) Validate() error {
 
 		return apperr.Errorf(apperr.EINVALID, "max fuel is required")
 
+	} else if r.MaxMemory < 0 || r.MaxMemory > MaxContractMemory {
+
+		return apperr.Errorf(apperr.EINVALID, "max memory must be between 0 and %d bytes", MaxContractMemory)
+
 	} else if len(r.CompiledCode) == 0 {
 
 		return apperr.Errorf(apperr.EINVALID, "compiled code is required")
@@ -67,3 +120,9 @@ func (r *Revision) Validate() error {
 
 	return nil
 }
+
+// HashRevisionCode returns the hex SHA-256 of the compiled code of a revision.
+func HashRevisionCode(code []byte) string {
+	sum := sha256.Sum256(code)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// sandboxRand picks the seeds of the sandboxes.
+var sandboxRand = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Sandbox represents the deterministic environment of a contract execution.
+// Math.random and Date of the contract derive from the seed and the frozen time,
+// so the same call in the same sandbox always returns the same result.
+type Sandbox struct {
+	Seed int64     `json:"seed"`
+	Now  time.Time `json:"now"` // The time seen by the contract, it does not move during the execution.
+}
+
+// NewSandbox creates a sandbox with a random seed, frozen at the current time.
+// The time is truncated to the millisecond, the resolution of the JS Date.
+func NewSandbox() *Sandbox {
+
+	sandboxRand.Lock()
+	seed := sandboxRand.Int63()
+	sandboxRand.Unlock()
+
+	return &Sandbox{
+		Seed: seed,
+		Now:  time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Random returns the source of Math.random, the same seed always returns the same sequence.
+func (s *Sandbox) Random() func() float64 {
+	return rand.New(rand.NewSource(s.Seed)).Float64
+}
+
+// UnixMilli returns the frozen time in milliseconds since the epoch, the value of Date.now().
+func (s *Sandbox) UnixMilli() int64 {
+	return s.Now.UnixMilli()
+}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
@@ -14,6 +15,10 @@ const (
 	EmptyState = `{}`
 )
 
+// MaxContractStateSize is the maximum size in bytes of the JSON encoded state of a stateful contract.
+// This is the default value that may be overwritten by the init function.
+var MaxContractStateSize = 1 << 20
+
 var _ driver.Valuer = StateValue{}
 var _ sql.Scanner = (*StateValue)(nil)
 
@@ -29,6 +34,15 @@ func (s StateValue) Value() (driver.Value, error) {
 	return v, nil
 }
 
+// Size returns the size in bytes of the JSON encoded state.
+func (s StateValue) Size() (int, error) {
+	v, err := json.Marshal(s)
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINVALID, "Error while encoding state: %s", err.Error())
+	}
+	return len(v), nil
+}
+
 // Scan implements sql.Scanner
 func (s *StateValue) Scan(src any) error {
 	b, ok := src.([]byte)
@@ -38,6 +52,60 @@ func (s *StateValue) Scan(src any) error {
 	return json.Unmarshal(b, &s)
 }
 
+// Clone returns a deep copy of the state, as it would be read back from the storage.
+// A nil state is cloned as nil.
+func (s StateValue) Clone() (StateValue, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINVALID, "Error while encoding state: %s", err.Error())
+	}
+	return NewStateFromBytes(b)
+}
+
+// Diff returns the keys changed from s to other.
+// The values are compared by their JSON encoding, so the numbers exported by the engines are equal to the ones read from the storage.
+func (s StateValue) Diff(other StateValue) StateDiff {
+
+	diff := make(StateDiff)
+
+	for key, before := range s {
+		after, ok := other[key]
+		if !ok {
+			diff[key] = StateChange{Before: before}
+		} else if !jsonEqual(before, after) {
+			diff[key] = StateChange{Before: before, After: after}
+		}
+	}
+
+	for key, after := range other {
+		if _, ok := s[key]; !ok {
+			diff[key] = StateChange{After: after}
+		}
+	}
+
+	return diff
+}
+
+// StateDiff represents the changes of a state, by key.
+type StateDiff map[string]StateChange
+
+// StateChange represents the change of a state key.
+// Before is nil if the key is added, After is nil if the key is removed.
+type StateChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// jsonEqual reports whether a and b have the same JSON encoding.
+func jsonEqual(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
 // NewStateFromBytes creates a state from bytes.
 // If b is nil, it returns an empty state.
 func NewStateFromBytes(b []byte) (StateValue, error) {
@@ -36,9 +36,15 @@ const (
 	VmOperationExecuteContract VmOperation = "execute-contract"
 	VmOperationUpdateContract  VmOperation = "update-contract"
 	VmOperationDeleteContract  VmOperation = "delete-contract"
+	VmOperationRestoreContract VmOperation = "restore-contract"
+	VmOperationReplayExecution VmOperation = "replay-execution"
+	VmOperationDryRunContract  VmOperation = "dry-run-contract"
 
 	VmOperationMakeContractRevision VmOperation = "make-contract-revision"
 
+	VmOperationSetContractAlias    VmOperation = "set-contract-alias"
+	VmOperationDeleteContractAlias VmOperation = "delete-contract-alias"
+
 	VmOperationCreateUser VmOperation = "create-user"
 	VmOperationUpdateUser VmOperation = "update-user"
 	VmOperationDeleteUser VmOperation = "delete-user"
@@ -65,7 +71,12 @@ var vmOperationCostTable = map[VmOperation]Fuel{
 	VmOperationCreateContract:       Fuel(10),
 	VmOperationUpdateContract:       Fuel(5),
 	VmOperationDeleteContract:       Fuel(15),
+	VmOperationRestoreContract:      Fuel(10),
+	VmOperationReplayExecution:      0, // Like the execution, the replay costs the fuel declared by the revision.
+	VmOperationDryRunContract:       0, // Like the execution, the dry run costs the fuel declared by the revision.
 	VmOperationMakeContractRevision: Fuel(5),
+	VmOperationSetContractAlias:     Fuel(5),
+	VmOperationDeleteContractAlias:  Fuel(5),
 	VmOperationCreateUser:           Fuel(15),
 	VmOperationUpdateUser:           Fuel(5),
 	VmOperationDeleteUser:           Fuel(10),
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+// ContractAliasSearchService is the interface for searching the aliases of the contracts.
+type ContractAliasSearchService interface {
+	// FindContractAliasByName returns the alias of the contract with the given name.
+	// Return ENOTFOUND if the alias does not exist.
+	FindContractAliasByName(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error)
+
+	// FindContractAliases returns all the aliases of the contract, sorted by name.
+	FindContractAliases(ctx context.Context, contractID int64) (entity.ContractAliases, error)
+}
+
+// ContractAliasManagmentService is the interface for managing the aliases of the contracts.
+// It is required that in passed context is injected the owner of the contract, otherwise it will return EUNAUTHORIZED.
+type ContractAliasManagmentService interface {
+	// DeleteContractAlias deletes the alias of the contract with the given name.
+	// Return ENOTFOUND if the alias does not exist.
+	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+	DeleteContractAlias(ctx context.Context, contractID int64, name string) error
+
+	// SetContractAlias creates the alias or moves it to the given revisions if it already exists.
+	// Return EINVALID if the alias is invalid.
+	// Return ENOTFOUND if the contract or one of the revisions does not exist.
+	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+	SetContractAlias(ctx context.Context, alias *entity.ContractAlias) error
+}
+
+// ContractAliasService rapresents the contract aliases service.
+type ContractAliasService interface {
+	ContractAliasSearchService
+	ContractAliasManagmentService
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+// ContractLogService is the interface for storing the logs written by contracts.
+// Only the most recent logs of each contract are kept.
+type ContractLogService interface {
+	// AppendLogs stores the given logs for the contract.
+	// Older entries may be discarded to respect the retention policy of the implementation.
+	AppendLogs(ctx context.Context, contractID int64, logs entity.ContractLogs) error
+
+	// FindLogsByContractID returns the most recent logs of the contract, oldest first.
+	FindLogsByContractID(ctx context.Context, contractID int64) (entity.ContractLogs, error)
+}
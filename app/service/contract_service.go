@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
@@ -20,6 +21,10 @@ type ContractSearchService interface {
 	// FindRevisionByContractAndRev returns the revision searched by the given contract and revision number.
 	// Return ENOTFOUND if the revision does not exist.
 	FindRevisionByContractAndRev(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error)
+
+	// FindRevisions returns a list of revisions filtered by the given options, the newest first.
+	// Also returns the total count of revisions.
+	FindRevisions(ctx context.Context, filter RevisionFilter) (entity.Revisions, int, error)
 }
 
 // ContractManagmentService is the interface for managing contracts.
@@ -36,26 +41,74 @@ type ContractManagmentService interface {
 	// DeleteContract deletes the contract with the given id.
 	// Return EUNAUTHORIZED if the contract is not the same as the authenticated user.
 	// Return ENOTFOUND if the contract does not exist.
-	// This service also deletes the revisions of the contract.
+	// The contract is soft deleted, it is hidden from the searches until it is restored or purged.
 	DeleteContract(ctx context.Context, id int64) error
 
 	// MakeRevision creates a new revision of the contract.
+	// The revision number and the hash of the compiled code are assigned by the service, the passed ones are ignored.
 	// Return ENOTFOUND if the contract does not exist.
 	// Return EINVALID if the revision is invalid.
-	// It shouldn't return ECONFLICT because the revision is generated by the database and most likely there should be a UNIQUE constraint on the revision number and the Contract ID.
+	// Return ECONFLICT if the compiled code is identical to the last revision of the contract.
 	MakeRevision(ctx context.Context, revision *entity.Revision) error
 
+	// RollbackActiveRevision pins again the revision active before the last change of the active revision.
+	// If no change is recorded, the revision preceding the active one is pinned.
+	// Return ENOTFOUND if the contract does not exist.
+	// Return ECONFLICT if there is no revision to roll back to.
+	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+	RollbackActiveRevision(ctx context.Context, id int64) (*entity.Contract, error)
+
+	// RestoreContract restores the soft deleted contract with the given id.
+	// Return ENOTFOUND if the contract does not exist or it is not deleted.
+	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+	RestoreContract(ctx context.Context, id int64) (*entity.Contract, error)
+
+	// SetActiveRevision pins the revision run by the calls without a revision number, rev 0 unpins it so the last revision is run.
+	// Return ENOTFOUND if the contract or the revision does not exist.
+	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+	SetActiveRevision(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error)
+
 	// UpdateContract updates the given contract.
 	// Return ENOTFOUND if the contract does not exist.
 	// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
 	UpdateContract(ctx context.Context, id int64, contract ContractUpdate) (*entity.Contract, error)
 }
 
+// ContractPurgeService is the interface for purging the soft deleted contracts.
+type ContractPurgeService interface {
+	// PurgeContracts deletes for good the contracts deleted before the given time, together with their revisions and states.
+	// Returns the number of purged contracts.
+	PurgeContracts(ctx context.Context, deletedBefore time.Time) (int, error)
+}
+
 // ContractCallOpt defines the options for calling a contract.
 type ContractCallOpt struct {
 	ContractRef *entity.Contract
 	RevisionRef *entity.Revision
 	StateRef    *entity.State
+
+	// AliasRef is the alias resolved to RevisionRef, it is empty if the revision is called by number.
+	AliasRef string
+
+	// Input is the payload passed by the caller, it is exposed to the contract as input/args object.
+	// Can be nil if the caller does not provide any payload.
+	Input any
+
+	// LogRef is the buffer where the console output of the contract is captured.
+	// Can be nil, in this case the console output is discarded.
+	LogRef *entity.ContractLogBuffer
+
+	// MeterRef measures the work done by the contract, the executor stops the contract when its fuel is exhausted.
+	// Can be nil, in this case the contract is limited only by the execution time.
+	MeterRef *entity.FuelMeter
+
+	// SandboxRef is the seed and the frozen time the contract sees through Math.random and Date.
+	// Can be nil, in this case the contract sees the random source and the clock of the host.
+	SandboxRef *entity.Sandbox
+
+	// DryRunRef is filled with the state changes and the fuel of the call, the state changes are not stored.
+	// Can be nil, in this case the call stores the state left by the contract.
+	DryRunRef *entity.DryRun
 }
 
 // Contract returns the contract attached to the contract call options.
@@ -74,8 +127,10 @@ func (opt ContractCallOpt) Revision() (*entity.Revision, error) {
 	if opt.RevisionRef != nil {
 		return opt.RevisionRef, nil
 	}
-	if opt.ContractRef != nil && opt.ContractRef.LastRevision != nil {
-		return opt.ContractRef.LastRevision, nil
+	if opt.ContractRef != nil {
+		if revision, err := opt.ContractRef.UnwrapActiveRevision(); err == nil {
+			return revision, nil
+		}
 	}
 	return nil, apperr.Errorf(apperr.EINVALID, "No revision specified")
 }
@@ -83,7 +138,12 @@ func (opt ContractCallOpt) Revision() (*entity.Revision, error) {
 // ContractExecutorService rapresents the contract executor service.
 type ContractExecutorService interface {
 	// ExecContract executes a contract.
+	// The result is a plain JSON value (object, array, number, boolean, string or nil).
 	ExecContract(ctx context.Context, opt ContractCallOpt) (res interface{}, err error)
+
+	// CompileContract validates the code of the revision without executing it.
+	// Return EINVALID if the code is not valid, the problems found are attached as []*entity.RevisionCodeError details.
+	CompileContract(ctx context.Context, revision *entity.Revision) error
 }
 
 // ContractService rapresents the contract managment service.
@@ -95,10 +155,19 @@ type ContractService interface {
 
 // ContractFilter represents the options used to filter the contracts.
 type ContractFilter struct {
-	ID          *int64  `json:"id"`
-	Name        *string `json:"name"`
+	ID   *int64  `json:"id"`
+	Name *string `json:"name"`
+	// Description matches the contracts whose description contains it.
 	Description *string `json:"description"`
 	UserID      *int64  `json:"user_id"`
+	// VisibleTo hides the private contracts not owned by the given user, 0 means an anonymous user that sees only the public ones.
+	VisibleTo *int64 `json:"visible_to"`
+	// Deleted searches the soft deleted contracts instead of the live ones.
+	Deleted bool `json:"deleted"`
+
+	// OrderBy is the field used to sort the contracts, by default they are sorted by id.
+	OrderBy   entity.ContractOrderBy `json:"order_by"`
+	OrderDesc bool                   `json:"order_desc"`
 
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+// ExecutionSearchService is the interface for searching execution records.
+type ExecutionSearchService interface {
+	// FindExecutionByID returns the execution with the given id, together with its recorded call.
+	// Return ENOTFOUND if the execution does not exist.
+	FindExecutionByID(ctx context.Context, id int64) (*entity.Execution, error)
+
+	// FindExecutions returns a list of executions filtered by the given options.
+	// Also returns the total count of executions.
+	FindExecutions(ctx context.Context, filter ExecutionFilter) (entity.Executions, int, error)
+}
+
+// ExecutionManagementService is the interface for recording executions.
+type ExecutionManagementService interface {
+	// CreateExecution stores a new execution record, together with its recorded call if any.
+	// Return EINVALID if the execution is invalid.
+	CreateExecution(ctx context.Context, execution *entity.Execution) error
+}
+
+// ExecutionService is the interface for recording and searching executions.
+// Executions are written by the VM, no authorization check is performed by this service.
+type ExecutionService interface {
+	ExecutionSearchService
+	ExecutionManagementService
+}
+
+// ExecutionSnapshotPurgeService is the interface for purging the recorded calls of the old executions.
+type ExecutionSnapshotPurgeService interface {
+	// PurgeExecutionSnapshots deletes the recorded calls of the executions started before the given time, the executions are kept.
+	// Returns the number of purged snapshots.
+	PurgeExecutionSnapshots(ctx context.Context, startedBefore time.Time) (int, error)
+}
+
+// ExecutionReplayService is the interface for replaying the recorded executions.
+type ExecutionReplayService interface {
+	// ReplayExecution runs the recorded call of the execution again on the revision, in the same sandbox and from the same state.
+	// The live state of the contract is never read or written.
+	// Return EINVALID if the execution has no recorded call.
+	ReplayExecution(ctx context.Context, execution *entity.Execution, revision *entity.Revision) (*entity.ExecutionReplay, error)
+}
+
+// ExecutionFilter represents the options used to filter the executions.
+type ExecutionFilter struct {
+	ID            *int64                   `json:"id"`
+	ContractID    *int64                   `json:"contract_id"`
+	RevisionID    *int64                   `json:"revision_id"`
+	UserID        *int64                   `json:"user_id"`
+	Outcome       *entity.ExecutionOutcome `json:"outcome"`
+	StartedAfter  *time.Time               `json:"started_after"`
+	StartedBefore *time.Time               `json:"started_before"`
+
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+// FuelLedgerRecorderService is the interface for appending entries to the fuel ledger.
+type FuelLedgerRecorderService interface {
+	// RecordFuelEntries appends the given entries to the ledger.
+	// Return EINVALID if any entry is invalid, in this case no entry is recorded.
+	RecordFuelEntries(ctx context.Context, entries entity.FuelLedgerEntries) error
+}
+
+// FuelLedgerSearchService is the interface for aggregating the fuel ledger.
+type FuelLedgerSearchService interface {
+	// FindFuelUsages returns the fuel ledger aggregated as specified by the filter.
+	// Return EINVALID if the group by or the bucket are invalid.
+	FindFuelUsages(ctx context.Context, filter FuelUsageFilter) (entity.FuelUsages, error)
+}
+
+// FuelLedgerService is the interface for recording and aggregating the fuel ledger.
+// No authorization check is performed by this service.
+type FuelLedgerService interface {
+	FuelLedgerRecorderService
+	FuelLedgerSearchService
+}
+
+// FuelUsageFilter represents the options used to aggregate the fuel ledger.
+type FuelUsageFilter struct {
+	GroupBy entity.FuelUsageGroupBy `json:"group_by"`
+	// Bucket is the size of the time buckets, it is required only if GroupBy is bucket.
+	Bucket entity.FuelUsageBucket `json:"bucket"`
+
+	UserID     *int64     `json:"user_id"`
+	ContractID *int64     `json:"contract_id"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
@@ -25,7 +25,20 @@ type FuelTankService interface {
 	// Burn consumes the specified amount of fuel.
 	Burn(ctx context.Context, fuel entity.Fuel) error
 	// Refuel refills the fuel tank by the specified amount.
-	Refuel(ctx context.Context, fuelToRefill entity.Fuel) error
+	// It returns the fuel actually refilled, it is lower than the specified amount when less fuel is used.
+	Refuel(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error)
+}
+
+// FuelWalletService is the interface for the per-user fuel wallets.
+// Wallets are checked together with the global fuel tank, so a single user cannot drain the tank for everyone.
+type FuelWalletService interface {
+	// FuelWallet returns the fuel wallet of the given user, with the elapsed refills applied.
+	FuelWallet(ctx context.Context, userID int64) (*entity.FuelWallet, error)
+	// Burn consumes the specified amount of fuel from the user wallet.
+	// Return EMGVM_USER_LOWFUEL if the wallet has not enough fuel.
+	Burn(ctx context.Context, userID int64, fuel entity.Fuel) error
+	// Refuel gives back the specified amount of fuel to the user wallet.
+	Refuel(ctx context.Context, userID int64, fuel entity.Fuel) error
 }
 
 // FuelStationService is the interface for the fuel station.
@@ -3,7 +3,8 @@ package service
 import "context"
 
 const (
-	FuelTankLockName = "fuel_tank_mux"
+	FuelTankLockName        = "fuel_tank_mux"
+	FuelStationElectionName = "fuel_station"
 )
 
 // LockService is the interface for the lock service.
@@ -16,3 +17,12 @@ type LockService interface {
 	// UnlockContext releases the lock.
 	UnlockContext(ctx context.Context) (bool, error)
 }
+
+// LeaderService is the interface for the leader election between instances.
+// Only one instance at a time is the leader, until it stops renewing its lease.
+type LeaderService interface {
+	// IsLeader acquires or renews the leadership and reports if the current instance is the leader.
+	IsLeader(ctx context.Context) (bool, error)
+	// Name returns the name of the election.
+	Name() string
+}
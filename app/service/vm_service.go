@@ -9,6 +9,12 @@ import (
 const (
 	// EngineExecutionTimeoutPanic is the panic message when the engine execution time is exceeded.
 	EngineExecutionTimeoutPanic = "engine-execution-panic-timeout"
+
+	// EngineFuelExhaustedPanic is the panic message when the contract uses all the fuel of its meter.
+	EngineFuelExhaustedPanic = "engine-execution-panic-fuel-exhausted"
+
+	// EngineMemoryLimitPanic is the panic message when the contract exceeds the memory ceiling of its revision.
+	EngineMemoryLimitPanic = "engine-execution-panic-memory-limit"
 )
 
 type EngineStateService interface {
@@ -39,8 +45,10 @@ type VmService interface {
 // VmCallableService defines all callable services of the MusicGang VM.
 type VmCallableService interface {
 	AuthManagmentService
+	ContractAliasManagmentService
 	ContractExecutorService
 	ContractManagmentService
+	ExecutionReplayService
 	FuelStatsService
 	UserManagmentService
 }
@@ -58,11 +66,23 @@ type VmCallable interface {
 	Fuel() entity.Fuel
 	// MaxFuel returns the maximum fuel that the caller can use.
 	MaxFuel() entity.Fuel
+	// Meter returns the meter of the work done by the operation.
+	// Can be nil if the operation is charged on the execution time.
+	Meter() *entity.FuelMeter
+	// Sandbox returns the seed and the frozen time of the operation.
+	// Can be nil if the operation does not run a contract.
+	Sandbox() *entity.Sandbox
+	// Snapshot returns the record of the call, filled by the operation.
+	// Can be nil if the call must not be recorded.
+	Snapshot() *entity.ExecutionSnapshot
 	// Operation returns the operation that is being called.
 	Operation() entity.VmOperation
 	// Revision is the revision of the contract that is being called.
 	// Can be nil if the Revision is not defined.
 	Revision() *entity.Revision
+	// Alias returns the alias resolved to the revision that is being called.
+	// It is empty if the revision is called by number.
+	Alias() string
 	// WithEngineState returns true if the engine state should not be ignored.
 	WithEngineState() bool
 	// WithRefuel returns true if is necessary to refuel remaining fuel after Call ends.
@@ -80,12 +100,24 @@ type VmCall struct {
 	User *entity.User `json:"caller"`
 
 	// RevisionRef is the revision of the contract that is being called.
-	// If RevisionRef is nil but ContractRef is not nil, RevisionRef is set to ContractRef.LastRevision.
+	// If RevisionRef is nil but ContractRef is not nil, RevisionRef is set to the active revision of ContractRef.
 	RevisionRef *entity.Revision `json:"revision"`
 
+	// AliasRef is the alias resolved to RevisionRef, empty if the revision is called by number.
+	AliasRef string `json:"alias"`
+
 	// CustomMaxFuel is the maximum fuel that can be used to call the vm.
 	CustomMaxFuel *entity.Fuel `json:"custom_max_fuel"`
 
+	// MeterRef measures the work done by the operation, nil if the operation is charged on the execution time.
+	MeterRef *entity.FuelMeter `json:"-"`
+
+	// SandboxRef is the seed and the frozen time of the contract, nil if the operation does not run a contract.
+	SandboxRef *entity.Sandbox `json:"-"`
+
+	// SnapshotRef is the record of the call, nil if the call must not be recorded.
+	SnapshotRef *entity.ExecutionSnapshot `json:"-"`
+
 	// VmOperation is the operation that is being called.
 	VmOperation entity.VmOperation `json:"operation"`
 
@@ -101,7 +133,11 @@ type VmCallOpt struct {
 	ContractRef       *entity.Contract
 	User              *entity.User
 	RevisionRef       *entity.Revision
+	AliasRef          string
 	CustomMaxFuel     *entity.Fuel
+	MeterRef          *entity.FuelMeter
+	SandboxRef        *entity.Sandbox
+	SnapshotRef       *entity.ExecutionSnapshot
 	VmOperation       entity.VmOperation
 	IgnoreRefuel      bool
 	IgnoreEngineState bool
@@ -118,13 +154,22 @@ func NewVmCallWithConfig(opt VmCallOpt) *VmCall {
 		ContractRef:       opt.ContractRef,
 		User:              opt.User,
 		RevisionRef:       opt.RevisionRef,
+		AliasRef:          opt.AliasRef,
 		CustomMaxFuel:     opt.CustomMaxFuel,
+		MeterRef:          opt.MeterRef,
+		SandboxRef:        opt.SandboxRef,
+		SnapshotRef:       opt.SnapshotRef,
 		VmOperation:       opt.VmOperation,
 		IgnoreRefuel:      opt.IgnoreRefuel,
 		IgnoreEngineState: opt.IgnoreEngineState,
 	}
 }
 
+// Alias returns the alias resolved to the revision that is being called.
+func (c *VmCall) Alias() string {
+	return c.AliasRef
+}
+
 // Caller returns the caller of the contract.
 func (c *VmCall) Caller() *entity.User {
 	return c.User
@@ -159,6 +204,21 @@ func (c *VmCall) MaxFuel() entity.Fuel {
 	return c.Fuel() + stateFulFuel
 }
 
+// Meter returns the meter of the work done by the operation.
+func (c *VmCall) Meter() *entity.FuelMeter {
+	return c.MeterRef
+}
+
+// Sandbox returns the seed and the frozen time of the operation.
+func (c *VmCall) Sandbox() *entity.Sandbox {
+	return c.SandboxRef
+}
+
+// Snapshot returns the record of the call.
+func (c *VmCall) Snapshot() *entity.ExecutionSnapshot {
+	return c.SnapshotRef
+}
+
 // Operation returns the operation type that is being called.
 func (c *VmCall) Operation() entity.VmOperation {
 	if c.VmOperation == "" {
@@ -171,8 +231,9 @@ func (c *VmCall) Operation() entity.VmOperation {
 func (c *VmCall) Revision() *entity.Revision {
 	if c.RevisionRef != nil {
 		return c.RevisionRef
-	} else if c.ContractRef != nil && c.ContractRef.LastRevision != nil {
-		return c.ContractRef.LastRevision
+	} else if c.ContractRef != nil {
+		revision, _ := c.ContractRef.UnwrapActiveRevision()
+		return revision
 	} else {
 		return nil
 	}
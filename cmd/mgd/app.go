@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/inconshreveable/log15"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
@@ -17,9 +19,12 @@ import (
 	"github.com/music-gang/music-gang-api/executor"
 	"github.com/music-gang/music-gang-api/handler"
 	"github.com/music-gang/music-gang-api/http"
+	"github.com/music-gang/music-gang-api/inmem"
 	"github.com/music-gang/music-gang-api/mgvm"
 	"github.com/music-gang/music-gang-api/postgres"
 	"github.com/music-gang/music-gang-api/redis"
+	"github.com/music-gang/music-gang-api/sqlite"
+	"golang.org/x/time/rate"
 )
 
 type App struct {
@@ -27,10 +32,18 @@ type App struct {
 
 	VM *mgvm.MusicGangVM
 
+	// Engine is the engine of the VM, it is closed after the VM to release the executors.
+	Engine *mgvm.Engine
+
+	// SQLite replaces Postgres when a single instance runs, only one of them is set.
 	Postgres *postgres.DB
+	SQLite   *sqlite.DB
 
 	Redis *redis.DB
 
+	// Inmem replaces Redis when a single instance runs, only one of them is set.
+	Inmem *inmem.DB
+
 	HTTPServerAPI *http.ServerAPI
 
 	EventService *event.EventService
@@ -39,19 +52,33 @@ type App struct {
 // NewApp returns a new instance of Main
 func NewApp() *App {
 
-	redisHost := config.GetConfig().APP.Redis.Host
-	redisPort := config.GetConfig().APP.Redis.Port
-	redisPassword := config.GetConfig().APP.Redis.Password
-
-	redisAddr := fmt.Sprintf("%s:%d", redisHost, redisPort)
-
-	return &App{
-		Postgres:      postgres.NewDB(config.BuildDSNFromDatabaseConfigForPostgres(config.GetConfig().APP.Databases.Postgres)),
-		Redis:         redis.NewDB(redisAddr, redisPassword),
+	a := &App{
 		HTTPServerAPI: http.NewServerAPI(),
 		VM:            mgvm.NewMusicGangVM(),
 		EventService:  event.NewEventService(),
 	}
+
+	switch config.GetConfig().APP.Databases.Driver {
+	case config.DatabaseDriverPostgres:
+		a.Postgres = postgres.NewDB(config.BuildDSNFromDatabaseConfigForPostgres(config.GetConfig().APP.Databases.Postgres))
+	case config.DatabaseDriverSQLite:
+		a.SQLite = sqlite.NewDB(config.BuildDSNFromDatabaseConfigForSQLite(config.GetConfig().APP.Databases.SQLite))
+	}
+
+	switch config.GetConfig().APP.SharedStore {
+	case config.SharedStoreRedis:
+		redisHost := config.GetConfig().APP.Redis.Host
+		redisPort := config.GetConfig().APP.Redis.Port
+		redisPassword := config.GetConfig().APP.Redis.Password
+
+		redisAddr := fmt.Sprintf("%s:%d", redisHost, redisPort)
+
+		a.Redis = redis.NewDB(redisAddr, redisPassword)
+	case config.SharedStoreInmem:
+		a.Inmem = inmem.NewDB()
+	}
+
+	return a
 }
 
 // Close closes the main application
@@ -63,6 +90,12 @@ func (a *App) Close() error {
 		}
 	}
 
+	if a.Engine != nil {
+		if err := a.Engine.Close(); err != nil {
+			return err
+		}
+	}
+
 	if a.HTTPServerAPI != nil {
 		if err := a.HTTPServerAPI.Close(); err != nil {
 			return err
@@ -75,36 +108,104 @@ func (a *App) Close() error {
 		}
 	}
 
+	if a.SQLite != nil {
+		if err := a.SQLite.Close(); err != nil {
+			return err
+		}
+	}
+
 	if a.Redis != nil {
 		if err := a.Redis.Close(); err != nil {
 			return err
 		}
 	}
 
+	if a.Inmem != nil {
+		if err := a.Inmem.Close(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// Run starts the main application
-func (a *App) Run(ctx context.Context) error {
+// cacheStateService is the cache of the states, used both to store and to search them.
+type cacheStateService interface {
+	service.StateCacheService
+	service.StateSearchService
+}
 
-	a.ctx = ctx
+// sharedServices groups the services shared between instances, backed by redis or in memory.
+type sharedServices struct {
+	CacheState   cacheStateService
+	ContractLog  service.ContractLogService
+	FuelTank     service.FuelTankService
+	FuelWallet   service.FuelWalletService
+	JWTBlacklist service.JWTBlacklistService
+	NewLock      func(name string) service.LockService
+
+	// FuelStationLeader is nil if a single instance runs.
+	FuelStationLeader service.LeaderService
+}
 
-	if err := a.Postgres.Open(); err != nil {
-		return err
-	}
+// openSharedServices opens the configured shared store and creates the services backed by it.
+func (a *App) openSharedServices() (*sharedServices, error) {
 
-	if err := a.Redis.Open(); err != nil {
-		return err
+	switch {
+	case a.Redis != nil:
+		if err := a.Redis.Open(); err != nil {
+			return nil, err
+		}
+		return &sharedServices{
+			CacheState:   redis.NewStateService(a.Redis),
+			ContractLog:  redis.NewContractLogService(a.Redis),
+			FuelTank:     redis.NewFuelTankService(a.Redis),
+			FuelWallet:   redis.NewFuelWalletService(a.Redis),
+			JWTBlacklist: redis.NewJWTBlacklistService(a.Redis),
+			NewLock: func(name string) service.LockService {
+				return redis.NewLockService(a.Redis, name)
+			},
+			// the lease outlives a few ticks, so the leader keeps refueling until it stops.
+			FuelStationLeader: redis.NewLeaderService(a.Redis, service.FuelStationElectionName, 3*entity.FuelRefillRate),
+		}, nil
+	case a.Inmem != nil:
+		if err := a.Inmem.Open(); err != nil {
+			return nil, err
+		}
+		return &sharedServices{
+			CacheState:   inmem.NewStateService(a.Inmem),
+			ContractLog:  inmem.NewContractLogService(a.Inmem),
+			FuelTank:     inmem.NewFuelTankService(a.Inmem),
+			FuelWallet:   inmem.NewFuelWalletService(a.Inmem),
+			JWTBlacklist: inmem.NewJWTBlacklistService(a.Inmem),
+			NewLock: func(name string) service.LockService {
+				return inmem.NewLockService(a.Inmem, name)
+			},
+		}, nil
+	default:
+		return nil, apperr.Errorf(apperr.EINTERNAL, "invalid shared store: %s", config.GetConfig().APP.SharedStore)
 	}
+}
 
-	cacheStateService := redis.NewStateService(a.Redis)
+// storageServices groups the services persisting the data, backed by postgres or sqlite.
+type storageServices struct {
+	Auth          service.AuthService
+	User          service.UserService
+	Contract      service.ContractService
+	ContractPurge service.ContractPurgeService
+	ContractAlias service.ContractAliasService
+	State         service.StateService
+	Execution     service.ExecutionService
+	FuelLedger    service.FuelLedgerService
+
+	ExecutionSnapshotPurge service.ExecutionSnapshotPurgeService
+}
 
-	postgresAuthService := postgres.NewAuthService(a.Postgres)
-	postgresUserService := postgres.NewUserService(a.Postgres)
-	postgresContractService := postgres.NewContractService(a.Postgres)
-	postgresStateService := postgres.NewStateService(a.Postgres)
+// openStorageServices opens the configured database and creates the services backed by it.
+// The states are locked and cached through the shared services.
+func (a *App) openStorageServices(shared *sharedServices) (*storageServices, error) {
 
-	postgresStateService.CreateLockService = func(ctx context.Context, revisionID int64) (service.LockService, error) {
+	createStateLockService := func(ctx context.Context, revisionID int64) (service.LockService, error) {
 		userID := app.UserIDFromContext(ctx)
 		if userID == 0 {
 			return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "user not authorized")
@@ -112,42 +213,126 @@ func (a *App) Run(ctx context.Context) error {
 		if revisionID == 0 {
 			return nil, apperr.Errorf(apperr.EINVALID, "revisionID is 0")
 		}
-		return redis.NewLockService(a.Redis, fmt.Sprintf(redis.StateLockKeyTemplate, userID, revisionID)), nil
+		return shared.NewLock(fmt.Sprintf(redis.StateLockKeyTemplate, userID, revisionID)), nil
 	}
-	postgresStateService.CacheStateSearchService = cacheStateService
 
-	authService := auth.NewAuth(postgresAuthService, postgresUserService, config.GetConfig().APP.Auths)
+	switch {
+	case a.Postgres != nil:
+		if err := a.Postgres.Open(); err != nil {
+			return nil, err
+		}
+		stateService := postgres.NewStateService(a.Postgres)
+		stateService.CreateLockService = createStateLockService
+		stateService.CacheStateSearchService = shared.CacheState
+		contractService := postgres.NewContractService(a.Postgres)
+		executionService := postgres.NewExecutionService(a.Postgres)
+		return &storageServices{
+			Auth:          postgres.NewAuthService(a.Postgres),
+			User:          postgres.NewUserService(a.Postgres),
+			Contract:      contractService,
+			ContractPurge: contractService,
+			ContractAlias: postgres.NewContractAliasService(a.Postgres),
+			State:         stateService,
+			Execution:     executionService,
+			FuelLedger:    postgres.NewFuelLedgerService(a.Postgres),
+
+			ExecutionSnapshotPurge: executionService,
+		}, nil
+	case a.SQLite != nil:
+		if err := a.SQLite.Open(); err != nil {
+			return nil, err
+		}
+		stateService := sqlite.NewStateService(a.SQLite)
+		stateService.CreateLockService = createStateLockService
+		stateService.CacheStateSearchService = shared.CacheState
+		contractService := sqlite.NewContractService(a.SQLite)
+		executionService := sqlite.NewExecutionService(a.SQLite)
+		return &storageServices{
+			Auth:          sqlite.NewAuthService(a.SQLite),
+			User:          sqlite.NewUserService(a.SQLite),
+			Contract:      contractService,
+			ContractPurge: contractService,
+			ContractAlias: sqlite.NewContractAliasService(a.SQLite),
+			State:         stateService,
+			Execution:     executionService,
+			FuelLedger:    sqlite.NewFuelLedgerService(a.SQLite),
+
+			ExecutionSnapshotPurge: executionService,
+		}, nil
+	default:
+		return nil, apperr.Errorf(apperr.EINTERNAL, "invalid database driver: %s", config.GetConfig().APP.Databases.Driver)
+	}
+}
+
+// Run starts the main application
+func (a *App) Run(ctx context.Context) error {
+
+	a.ctx = ctx
+
+	shared, err := a.openSharedServices()
+	if err != nil {
+		return err
+	}
+
+	storage, err := a.openStorageServices(shared)
+	if err != nil {
+		return err
+	}
+
+	authService := auth.NewAuth(storage.Auth, storage.User, config.GetConfig().APP.Auths)
 
 	jwtService := jwt.NewJWTService()
 	jwtService.Secret = config.GetConfig().APP.JWT.Secret
-	jwtService.JWTBlacklistService = redis.NewJWTBlacklistService(a.Redis)
+	jwtService.JWTBlacklistService = shared.JWTBlacklist
 
 	logService := log15.New("app", "mgd")
 
 	a.HTTPServerAPI.Addr = config.GetConfig().APP.HTTP.Addr
 	a.HTTPServerAPI.Domain = config.GetConfig().APP.HTTP.Domain
+	if limit := config.GetConfig().APP.HTTP.PublicRateLimit; limit > 0 {
+		a.HTTPServerAPI.PublicRateLimiter = middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:  rate.Limit(limit),
+			Burst: config.GetConfig().APP.HTTP.PublicRateBurst,
+		})
+	}
+	a.HTTPServerAPI.CallBodyLimit = config.GetConfig().APP.HTTP.CallBodyLimit << 10
+	a.HTTPServerAPI.OperatorToken = config.GetConfig().APP.HTTP.OperatorToken
 	a.HTTPServerAPI.LogService = logService.New("module", "http")
 
 	a.HTTPServerAPI.ServiceHandler = handler.NewServiceHandler()
 	a.HTTPServerAPI.ServiceHandler.AuthSearchService = authService
-	a.HTTPServerAPI.ServiceHandler.ContractSearchService = postgresContractService
-	a.HTTPServerAPI.ServiceHandler.UserSearchService = postgresUserService
+	a.HTTPServerAPI.ServiceHandler.ContractSearchService = storage.Contract
+	a.HTTPServerAPI.ServiceHandler.UserSearchService = storage.User
 	a.HTTPServerAPI.ServiceHandler.JWTService = jwtService
+	a.HTTPServerAPI.ServiceHandler.ContractLogService = shared.ContractLog
+	a.HTTPServerAPI.ServiceHandler.ExecutionSearchService = storage.Execution
+	a.HTTPServerAPI.ServiceHandler.FuelLedgerSearchService = storage.FuelLedger
+	a.HTTPServerAPI.ServiceHandler.ContractPurgeService = storage.ContractPurge
+	a.HTTPServerAPI.ServiceHandler.ExecutionSnapshotPurgeService = storage.ExecutionSnapshotPurge
+	a.HTTPServerAPI.ServiceHandler.ContractAliasService = storage.ContractAlias
 	a.HTTPServerAPI.ServiceHandler.Logger = a.HTTPServerAPI.LogService
 
+	// the shared fuel tank burns and refuels atomically, so no lock is required.
 	fuelTankService := mgvm.NewFuelTank()
-	fuelTankService.LockService = redis.NewLockService(a.Redis, "fuel-tank-lock")
-	fuelTankService.FuelTankService = redis.NewFuelTankService(a.Redis)
+	fuelTankService.FuelTankService = shared.FuelTank
 
 	fuelStationService := mgvm.NewFuelStation()
 	fuelStationService.FuelTankService = fuelTankService
 	fuelStationService.LogService = logService.New("module", "fuel-station")
 	fuelStationService.FuelRefillAmount = entity.FuelRefillAmount
 	fuelStationService.FuelRefillRate = entity.FuelRefillRate
+	fuelStationService.FuelLedger = storage.FuelLedger
+	fuelStationService.LeaderService = shared.FuelStationLeader
 
 	anchorageExecutor := executor.NewAnchorageContractExecutor()
+	if size := config.GetConfig().APP.Vm.ScriptCacheSize; size > 0 {
+		anchorageExecutor.ScriptCache = executor.NewScriptCache(size << 20)
+	}
 	engineService := mgvm.NewEngine()
 	engineService.Executors[entity.AnchorageVersion] = anchorageExecutor
+	engineService.Executors[entity.BostonVersion] = executor.NewBostonContractExecutor()
+	engineService.Executors[entity.ChicagoVersion] = executor.NewChicagoContractExecutor()
+	a.Engine = engineService
 
 	fuelMonitorService := mgvm.NewFuelMonitor()
 	fuelMonitorService.EngineStateService = engineService
@@ -169,11 +354,19 @@ func (a *App) Run(ctx context.Context) error {
 	a.VM.EngineService = engineService
 	a.VM.CPUsPoolService = cpusPoolService
 
-	a.VM.ContractManagmentService = postgresContractService
-	a.VM.UserManagmentService = postgresUserService
+	a.VM.ContractManagmentService = storage.Contract
+	a.VM.ContractAliasManagmentService = storage.ContractAlias
+	a.VM.UserManagmentService = storage.User
 	a.VM.AuthManagmentService = authService
-	a.VM.StateService = postgresStateService
-	a.VM.CacheStateService = cacheStateService
+	a.VM.StateService = storage.State
+	a.VM.CacheStateService = shared.CacheState
+	a.VM.ExecutionService = storage.Execution
+	a.VM.FuelLedger = storage.FuelLedger
+
+	if config.GetConfig().APP.Vm.UserFuelWallet {
+		a.VM.FuelWallet = shared.FuelWallet
+		a.HTTPServerAPI.ServiceHandler.FuelWalletService = shared.FuelWallet
+	}
 
 	if err := a.VM.Run(); err != nil {
 		return err
@@ -185,6 +378,9 @@ func (a *App) Run(ctx context.Context) error {
 		return err
 	}
 
+	go a.purgeContracts(ctx)
+	go a.purgeExecutionSnapshots(ctx)
+
 	if a.HTTPServerAPI.UseTLS() {
 		go func() {
 			log.Fatal(http.ListenAndServeTLSRedirect(""))
@@ -195,11 +391,74 @@ func (a *App) Run(ctx context.Context) error {
 		"addr", a.HTTPServerAPI.Addr,
 		"domain", a.HTTPServerAPI.Domain,
 		"tls", a.HTTPServerAPI.UseTLS(),
+		"db_driver", config.GetConfig().APP.Databases.Driver,
+		"shared_store", config.GetConfig().APP.SharedStore,
 		"vm_fuel_tank_capacity", entity.FuelTankCapacity,
 		"vm_fuel_refill_amount", entity.FuelRefillAmount,
 		"vm_fuel_refill_rate", entity.FuelRefillRate,
 		"vm_max_execution_time", entity.MaxExecutionTime,
+		"vm_fuel_prices", entity.FuelPrices,
+		"vm_script_cache_size_mib", config.GetConfig().APP.Vm.ScriptCacheSize,
+		"vm_contract_memory", entity.DefaultContractMemory,
+		"vm_contract_max_memory", entity.MaxContractMemory,
+		"vm_contract_max_result_size", entity.MaxContractResultSize,
+		"vm_contract_max_state_size", entity.MaxContractStateSize,
+		"vm_user_fuel_wallet", config.GetConfig().APP.Vm.UserFuelWallet,
+		"vm_user_fuel_capacity", entity.UserFuelCapacity,
+		"vm_user_fuel_refill_amount", entity.UserFuelRefillAmount,
+		"vm_user_fuel_refill_rate", entity.UserFuelRefillRate,
+		"contract_retention", entity.ContractRetention,
+		"contract_purge_rate", entity.ContractPurgeRate,
+		"execution_snapshot_retention", entity.ExecutionSnapshotRetention,
+		"execution_snapshot_purge_rate", entity.ExecutionSnapshotPurgeRate,
 	)
 
 	return nil
 }
+
+// purgeContracts purges every ContractPurgeRate the contracts deleted before the retention period, until the context is done.
+// The purge is idempotent, so every running instance can purge without coordination.
+// A purge rate lower or equal than 0 disables the purge.
+func (a *App) purgeContracts(ctx context.Context) {
+
+	if entity.ContractPurgeRate <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(entity.ContractPurgeRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := a.HTTPServerAPI.ServiceHandler.PurgeContracts(ctx, entity.ContractRetention); err == nil && n > 0 {
+				a.HTTPServerAPI.LogService.Info("Purged deleted contracts", "count", n)
+			}
+		}
+	}
+}
+
+// purgeExecutionSnapshots purges every ExecutionSnapshotPurgeRate the recorded calls of the executions started before the retention period,
+// until the context is done. As for the contracts, the purge is idempotent and a purge rate lower or equal than 0 disables it.
+func (a *App) purgeExecutionSnapshots(ctx context.Context) {
+
+	if entity.ExecutionSnapshotPurgeRate <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(entity.ExecutionSnapshotPurgeRate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := a.HTTPServerAPI.ServiceHandler.PurgeExecutionSnapshots(ctx, entity.ExecutionSnapshotRetention); err == nil && n > 0 {
+				a.HTTPServerAPI.LogService.Info("Purged execution snapshots", "count", n)
+			}
+		}
+	}
+}
@@ -32,10 +32,72 @@ func init() {
 		entity.FuelTankCapacity = f
 	}
 
+	userFuelCapacityFromConfig := config.GetConfig().APP.Vm.UserMaxFuel
+	if f, err := entity.ParseFuel(userFuelCapacityFromConfig); err == nil {
+		entity.UserFuelCapacity = f
+		entity.UserFuelRefillAmount = entity.Fuel(f * 10 / 100)
+	}
+
+	userFuelRefillAmountFromConfig := config.GetConfig().APP.Vm.UserRefuelAmount
+	if f, err := entity.ParseFuel(userFuelRefillAmountFromConfig); err == nil {
+		entity.UserFuelRefillAmount = f
+	}
+
+	userFuelRefillRateFromConfig := config.GetConfig().APP.Vm.UserRefuelRate
+	if r, err := time.ParseDuration(userFuelRefillRateFromConfig); err == nil {
+		entity.UserFuelRefillRate = r
+	}
+
 	maxExecutionTimeFromConfig := config.GetConfig().APP.Vm.MaxExecutionTime
 	if t, err := time.ParseDuration(maxExecutionTimeFromConfig); err == nil {
 		entity.MaxExecutionTime = t
 	}
+
+	fuelPricesFromConfig := config.GetConfig().APP.Vm.FuelPrices
+	if p, err := entity.ParseFuelPriceTable(fuelPricesFromConfig); err == nil {
+		entity.FuelPrices = p
+	}
+
+	// a zero contract memory disables the memory ceiling of the revisions that do not set their own.
+	contractMemoryFromConfig := config.GetConfig().APP.Vm.ContractMemory
+	if contractMemoryFromConfig >= 0 {
+		entity.DefaultContractMemory = int64(contractMemoryFromConfig) << 20
+	}
+
+	contractMaxMemoryFromConfig := config.GetConfig().APP.Vm.ContractMaxMemory
+	if contractMaxMemoryFromConfig > 0 {
+		entity.MaxContractMemory = int64(contractMaxMemoryFromConfig) << 20
+	}
+
+	contractMaxResultSizeFromConfig := config.GetConfig().APP.Vm.ContractMaxResultSize
+	if contractMaxResultSizeFromConfig > 0 {
+		entity.MaxContractResultSize = contractMaxResultSizeFromConfig << 10
+	}
+
+	contractMaxStateSizeFromConfig := config.GetConfig().APP.Vm.ContractMaxStateSize
+	if contractMaxStateSizeFromConfig > 0 {
+		entity.MaxContractStateSize = contractMaxStateSizeFromConfig << 10
+	}
+
+	contractRetentionFromConfig := config.GetConfig().APP.Contract.Retention
+	if t, err := time.ParseDuration(contractRetentionFromConfig); err == nil {
+		entity.ContractRetention = t
+	}
+
+	contractPurgeRateFromConfig := config.GetConfig().APP.Contract.PurgeRate
+	if t, err := time.ParseDuration(contractPurgeRateFromConfig); err == nil {
+		entity.ContractPurgeRate = t
+	}
+
+	executionSnapshotRetentionFromConfig := config.GetConfig().APP.Execution.SnapshotRetention
+	if t, err := time.ParseDuration(executionSnapshotRetentionFromConfig); err == nil {
+		entity.ExecutionSnapshotRetention = t
+	}
+
+	executionSnapshotPurgeRateFromConfig := config.GetConfig().APP.Execution.SnapshotPurgeRate
+	if t, err := time.ParseDuration(executionSnapshotPurgeRateFromConfig); err == nil {
+		entity.ExecutionSnapshotPurgeRate = t
+	}
 }
 
 func main() {
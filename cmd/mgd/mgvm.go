@@ -40,11 +40,32 @@ func InitializerCPUsPool() {
 					entity.VmOperationCost(entity.VmOperationDeleteContract): make(mgvm.CorePool, 5),
 				},
 			},
+			entity.VmOperationRestoreContract: {
+				Pools: map[entity.Fuel]mgvm.CorePool{
+					entity.VmOperationCost(entity.VmOperationRestoreContract): make(mgvm.CorePool, 5),
+				},
+			},
+			entity.VmOperationReplayExecution: {
+				Fallback: make(mgvm.CorePool, 5),
+			},
+			entity.VmOperationDryRunContract: {
+				Fallback: make(mgvm.CorePool, 5),
+			},
 			entity.VmOperationMakeContractRevision: {
 				Pools: map[entity.Fuel]mgvm.CorePool{
 					entity.VmOperationCost(entity.VmOperationMakeContractRevision): make(mgvm.CorePool, 15),
 				},
 			},
+			entity.VmOperationSetContractAlias: {
+				Pools: map[entity.Fuel]mgvm.CorePool{
+					entity.VmOperationCost(entity.VmOperationSetContractAlias): make(mgvm.CorePool, 10),
+				},
+			},
+			entity.VmOperationDeleteContractAlias: {
+				Pools: map[entity.Fuel]mgvm.CorePool{
+					entity.VmOperationCost(entity.VmOperationDeleteContractAlias): make(mgvm.CorePool, 5),
+				},
+			},
 			entity.VmOperationCreateUser: {
 				Pools: map[entity.Fuel]mgvm.CorePool{
 					entity.VmOperationCost(entity.VmOperationCreateUser): make(mgvm.CorePool, 5),
@@ -158,6 +158,17 @@ type DatabaseConfig struct {
 type HTTPConfig struct {
 	Domain string `env:"DOMAIN"`
 	Addr   string `env:"ADDR" envDefault:":8888"`
+
+	// PublicRateLimit is the number of requests per second allowed to every anonymous caller of the public routes, 0 disables the limit.
+	PublicRateLimit float64 `env:"PUBLIC_RATE_LIMIT" envDefault:"1"`
+	// PublicRateBurst is the number of requests allowed to an anonymous caller at once.
+	PublicRateBurst int `env:"PUBLIC_RATE_BURST" envDefault:"5"`
+
+	// CallBodyLimit is the max size, in KiB, of the input of the contract calls, 0 disables the limit.
+	CallBodyLimit int64 `env:"CALL_BODY_LIMIT" envDefault:"1024"`
+
+	// OperatorToken is the token that the operators send in the X-Operator-Token header to use the operator routes, empty disables them.
+	OperatorToken string `env:"OPERATOR_TOKEN"`
 }
 
 // JWTConfig contains the jwt config
@@ -167,6 +178,12 @@ type JWTConfig struct {
 	RefreshExpiresIn int    `env:"REFRESH_EXPIRES_IN" envDefault:"20160"`
 }
 
+// SharedStore* consts for the backends of the services shared between instances.
+const (
+	SharedStoreRedis = "redis"
+	SharedStoreInmem = "inmem"
+)
+
 // RedisConfig contains the redis config
 type RedisConfig struct {
 	Host     string `env:"HOST" envDefault:"localhost"`
@@ -174,10 +191,29 @@ type RedisConfig struct {
 	Password string `env:"PASSWORD" envDefault:""`
 }
 
+// DatabaseDriver* consts for the storage backends.
+const (
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverSQLite   = "sqlite"
+)
+
+// SQLiteConfig contains the sqlite config
+type SQLiteConfig struct {
+	// Path is the path of the database file, it is created if it does not exist.
+	Path string `env:"PATH" envDefault:"musicgang.db"`
+}
+
 // DatabaseListConfig contains the list of database configs
 type DatabaseListConfig struct {
+	// Driver is the storage backend, it can be postgres or sqlite.
+	// sqlite keeps the data in a single local file, so it is suitable only for a single instance.
+	Driver string `env:"DB_DRIVER" envDefault:"postgres"`
+
 	// Postgres is the Postgres database configuration
 	Postgres DatabaseConfig `envPrefix:"PG_"`
+
+	// SQLite is the SQLite database configuration
+	SQLite SQLiteConfig `envPrefix:"SQLITE_"`
 }
 
 // VmConfig contains the vm config
@@ -186,6 +222,44 @@ type VmConfig struct {
 	MaxExecutionTime string `env:"MAX_EXECUTION_TIME" envDefault:"10s"`
 	RefuelAmount     string `env:"REFUEL_AMOUNT" envDefault:""`
 	RefuelRate       string `env:"REFUEL_RATE" envDefault:"400ms"`
+
+	// FuelPrices is the number of work units that costs one vFuel for every revision version, like "Anchorage:500,Boston:2500,Chicago:50000".
+	// The versions without a price are charged on the execution time.
+	FuelPrices string `env:"FUEL_PRICES" envDefault:"Anchorage:500,Boston:2500,Chicago:50000"`
+
+	// ScriptCacheSize is the memory, in MiB, of the cache of the compiled contracts, 0 disables the cache.
+	ScriptCacheSize int `env:"SCRIPT_CACHE_SIZE" envDefault:"32"`
+
+	// ContractMemory is the memory ceiling, in MiB, of the revisions that do not set their own.
+	ContractMemory int `env:"CONTRACT_MEMORY" envDefault:"16"`
+	// ContractMaxMemory is the highest memory ceiling, in MiB, that a revision can set.
+	ContractMaxMemory int `env:"CONTRACT_MAX_MEMORY" envDefault:"64"`
+	// ContractMaxResultSize is the maximum size, in KiB, of the JSON encoded result of a contract call.
+	ContractMaxResultSize int `env:"CONTRACT_MAX_RESULT_SIZE" envDefault:"1024"`
+	// ContractMaxStateSize is the maximum size, in KiB, of the JSON encoded state of a stateful contract.
+	ContractMaxStateSize int `env:"CONTRACT_MAX_STATE_SIZE" envDefault:"1024"`
+
+	// UserFuelWallet enables the per-user fuel wallets.
+	UserFuelWallet   bool   `env:"USER_FUEL_WALLET" envDefault:"true"`
+	UserMaxFuel      string `env:"USER_MAX_FUEL" envDefault:"10 vKFuel"`
+	UserRefuelAmount string `env:"USER_REFUEL_AMOUNT" envDefault:""`
+	UserRefuelRate   string `env:"USER_REFUEL_RATE" envDefault:"1m"`
+}
+
+// ContractConfig contains the contract config
+type ContractConfig struct {
+	// Retention is how long a deleted contract can be restored before it is purged.
+	Retention string `env:"RETENTION" envDefault:"720h"`
+	// PurgeRate is the rate of the purge of the deleted contracts.
+	PurgeRate string `env:"PURGE_RATE" envDefault:"1h"`
+}
+
+// ExecutionConfig contains the execution config
+type ExecutionConfig struct {
+	// SnapshotRetention is how long the recorded call of an execution is kept before it is purged.
+	SnapshotRetention string `env:"SNAPSHOT_RETENTION" envDefault:"168h"`
+	// SnapshotPurgeRate is the rate of the purge of the recorded calls.
+	SnapshotPurgeRate string `env:"SNAPSHOT_PURGE_RATE" envDefault:"1h"`
 }
 
 type AppConfig struct {
@@ -204,8 +278,18 @@ type AppConfig struct {
 	// Redis contains the redis configuration
 	Redis RedisConfig `envPrefix:"REDIS_"`
 
+	// SharedStore is the backend of the services shared between instances, like the fuel tank and the JWT blacklist.
+	// It can be redis or inmem, inmem keeps the data in memory so it is suitable only for a single instance.
+	SharedStore string `env:"SHARED_STORE" envDefault:"redis"`
+
 	// Vm contains the vm configuration
 	Vm VmConfig `envPrefix:"VM_"`
+
+	// Contract contains the contract configuration
+	Contract ContractConfig `envPrefix:"CONTRACT_"`
+
+	// Execution contains the execution configuration
+	Execution ExecutionConfig `envPrefix:"EXECUTION_"`
 }
 
 // Config - Configuration
@@ -219,6 +303,12 @@ func BuildDSNFromDatabaseConfigForPostgres(dbConfig DatabaseConfig) string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s&TimeZone=UTC", dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Database, dbConfig.SSLMode)
 }
 
+// BuildDSNFromDatabaseConfigForSQLite returns a DSN string for SQLite
+// Foreign keys are enabled because the schema relies on cascading deletes.
+func BuildDSNFromDatabaseConfigForSQLite(dbConfig SQLiteConfig) string {
+	return fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", dbConfig.Path)
+}
+
 // GetConfig returns the config
 func GetConfig() Config {
 	return cfg
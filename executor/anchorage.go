@@ -2,27 +2,57 @@ package executor
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 	"github.com/robertkrimen/otto"
+	"github.com/robertkrimen/otto/ast"
+	"github.com/robertkrimen/otto/parser"
 )
 
 var _ service.ContractExecutorService = (*AnchorageContractExecutor)(nil)
 
 // AnchorageContractExecutor is the contract executor for the anchorage contract version.
-type AnchorageContractExecutor struct{}
+type AnchorageContractExecutor struct {
+	// ScriptCache keeps the compiled code of the revisions, so the warm calls skip the parsing.
+	// Can be nil if the code is parsed on every call.
+	ScriptCache *ScriptCache
+}
 
 // NewAnchorageContractExecutor creates a new AnchorageContractExecutor.
 func NewAnchorageContractExecutor() *AnchorageContractExecutor {
 	return &AnchorageContractExecutor{}
 }
 
+// CompileContract parses the code of the revision without executing it.
+// Return EINVALID with the position of the syntax errors in the details, or if the code never assigns result.
+func (*AnchorageContractExecutor) CompileContract(ctx context.Context, revision *entity.Revision) error {
+
+	program, err := parser.ParseFile(nil, "", revision.CompiledCode, 0)
+	if err != nil {
+		return apperr.Errorf(apperr.EINVALID, "Syntax error in contract: %s", err.Error()).WithDetails(syntaxErrors(err))
+	}
+
+	if !assignsResult(program) {
+		return apperr.Errorf(apperr.EINVALID, "Contract never assigns result").WithDetails([]*entity.RevisionCodeError{
+			{Message: "result is never assigned"},
+		})
+	}
+
+	return nil
+}
+
 // ExecContract effectively executes the contract and returns the result.
 // If the engine goes into execution timeout, it panics with EngineExecutionTimeoutPanic.
-func (*AnchorageContractExecutor) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+// If the contract uses all the fuel of the meter, it panics with EngineFuelExhaustedPanic.
+// If the contract holds more memory than the ceiling of the revision, it panics with EngineMemoryLimitPanic.
+// If the options carry a sandbox, Math.random and Date of the contract derive from it.
+func (e *AnchorageContractExecutor) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
 
 	revision, err := opt.Revision()
 	if err != nil {
@@ -46,35 +76,342 @@ func (*AnchorageContractExecutor) ExecContract(ctx context.Context, opt service.
 	timeoutTicker := time.NewTicker(entity.MaxExecutionTimeFromFuel(revision.MaxFuel))
 	defer timeoutTicker.Stop()
 
+	stop := make(chan struct{})
+
+	var timeout sync.WaitGroup
+	timeout.Add(1)
+
 	go func() {
-		<-timeoutTicker.C
-		ottoVm.Interrupt <- func() {
+		defer timeout.Done()
+		select {
+		case <-timeoutTicker.C:
+		case <-stop:
+			return
+		}
+		// the send waits for the metering func to leave a free slot, the vm takes it at the next statement.
+		select {
+		case ottoVm.Interrupt <- func() {
 			panic(service.EngineExecutionTimeoutPanic)
+		}:
+		case <-stop:
 		}
 	}()
 
+	// stopTimeout stops the timeout goroutine and waits for it to exit, so no timeout is queued once it returns.
+	stopped := false
+	stopTimeout := func() {
+		if !stopped {
+			stopped = true
+			close(stop)
+			timeout.Wait()
+		}
+	}
+	defer stopTimeout()
+
+	if err := injectInput(ottoVm, opt.Input); err != nil {
+		return nil, err
+	}
+
+	if err := injectConsole(ottoVm, opt.LogRef); err != nil {
+		return nil, err
+	}
+
 	if contract.Stateful && opt.StateRef != nil && opt.StateRef.Value != nil {
 		injectStateAccessor(ottoVm, opt.StateRef)
 	}
 
-	_, err = ottoVm.Run(revision.CompiledCode)
-	close(ottoVm.Interrupt)
+	if opt.SandboxRef != nil {
+		if err := injectSandbox(ottoVm, opt.SandboxRef); err != nil {
+			return nil, err
+		}
+	}
+
+	var src interface{} = revision.CompiledCode
+
+	if e.ScriptCache != nil {
+		script, err := e.ScriptCache.Script(ottoVm, revision)
+		if err != nil {
+			return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while executing contract: %s", err.Error())
+		}
+		src = script
+	}
+
+	global, err := ottoVm.Object("this")
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while executing contract: %s", err.Error())
+	}
+
+	guard := newMemoryGuard(revision, opt.StateRef, anchorageMemory(global))
+
+	if opt.MeterRef != nil || guard != nil {
+		hookStatements(ottoVm, opt.MeterRef, guard)
+	}
+
+	_, err = ottoVm.Run(src)
+
+	// the statements run to export the result are neither charged to the contract nor timed out.
+	stopTimeout()
+	select {
+	case <-ottoVm.Interrupt:
+	default:
+	}
 
 	if err != nil {
 		return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while executing contract: %s", err.Error())
 	}
 
+	if !guard.Check() {
+		panic(service.EngineMemoryLimitPanic)
+	}
+
 	value, err := ottoVm.Get("result")
 	if err != nil {
 		return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while retrieving result: %s", err.Error())
 	}
 
-	str, err := value.ToString()
+	return exportResult(ottoVm, value)
+}
+
+// hookStatements charges one work unit to the meter and to the memory guard for every statement executed by the vm.
+// Both can be nil.
+// otto runs the pending interrupt func before every statement, so the hook func is always kept pending:
+// it re-queues itself after every run, unless another interrupt, like the timeout, took the slot.
+func hookStatements(vm *otto.Otto, meter *entity.FuelMeter, guard *memoryGuard) {
+
+	var hook func()
+	hook = func() {
+		if meter != nil && !meter.Consume(1) {
+			panic(service.EngineFuelExhaustedPanic)
+		}
+		if !guard.Consume(1) {
+			panic(service.EngineMemoryLimitPanic)
+		}
+		select {
+		case vm.Interrupt <- hook:
+		default:
+		}
+	}
+
+	// the slot is already taken if the timeout fired before the contract started.
+	select {
+	case vm.Interrupt <- hook:
+	default:
+	}
+}
+
+// syntaxErrors converts the errors of the otto parser into the details reported to the uploader.
+func syntaxErrors(err error) []*entity.RevisionCodeError {
+
+	var list parser.ErrorList
+
+	switch e := err.(type) {
+	case parser.ErrorList:
+		list = e
+	case *parser.Error:
+		list = parser.ErrorList{e}
+	default:
+		return []*entity.RevisionCodeError{{Message: err.Error()}}
+	}
+
+	details := make([]*entity.RevisionCodeError, 0, len(list))
+
+	for _, e := range list {
+		details = append(details, &entity.RevisionCodeError{
+			Line:    e.Position.Line,
+			Column:  e.Position.Column,
+			Message: e.Message,
+		})
+	}
+
+	return details
+}
+
+// resultVisitor looks for an assignment of the result variable, like var result = 1 or result = 1.
+type resultVisitor struct {
+	found bool
+}
+
+func (v *resultVisitor) Enter(n ast.Node) ast.Visitor {
+
+	if v.found {
+		return nil
+	}
+
+	switch n := n.(type) {
+	case *ast.AssignExpression:
+		if id, ok := n.Left.(*ast.Identifier); ok && id.Name == "result" {
+			v.found = true
+		}
+	case *ast.VariableExpression:
+		if n.Name == "result" && n.Initializer != nil {
+			v.found = true
+		}
+	}
+
+	return v
+}
+
+func (v *resultVisitor) Exit(n ast.Node) {}
+
+// assignsResult reports whether the program assigns the result variable somewhere.
+// The check is static, an assignment in a branch that never runs is accepted.
+func assignsResult(program *ast.Program) bool {
+	v := &resultVisitor{}
+	ast.Walk(v, program)
+	return v.found
+}
+
+// exportResult converts the contract result into plain JSON values (objects, arrays, numbers, booleans, strings).
+// The conversion passes through JSON.stringify, so the result is exactly what a JS client would see.
+// If the contract does not assign the result, nil is returned.
+// Return EMGVM_OUTPUT_TOO_LARGE if the encoded result is larger than MaxContractResultSize.
+func exportResult(vm *otto.Otto, value otto.Value) (any, error) {
+
+	if value.IsUndefined() || value.IsNull() {
+		return nil, nil
+	}
+
+	raw, err := vm.Call("JSON.stringify", nil, value)
 	if err != nil {
 		return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while parsing contract result: %s", err.Error())
+	} else if raw.IsUndefined() {
+		// functions and symbols are not serializable.
+		return nil, nil
+	}
+
+	encoded := raw.String()
+	if len(encoded) > entity.MaxContractResultSize {
+		return nil, apperr.Errorf(apperr.EMGVM_OUTPUT_TOO_LARGE, "Contract result is %d bytes, max result size is %d bytes", len(encoded), entity.MaxContractResultSize)
+	}
+
+	var res any
+	if err := json.Unmarshal([]byte(encoded), &res); err != nil {
+		return nil, apperr.Errorf(apperr.EANCHORAGE, "Error while parsing contract result: %s", err.Error())
+	}
+
+	return res, nil
+}
+
+// anchorageSandboxDate replaces the Date of the otto vm with a constructor frozen at the given time.
+// Only the current time is frozen, the dates built from explicit values are left to the builtin.
+const anchorageSandboxDate = `(function (now) {
+	var NativeDate = Date;
+	var SandboxDate = function (year, month, day, hours, minutes, seconds, ms) {
+		if (!(this instanceof SandboxDate)) {
+			return new NativeDate(now).toString();
+		}
+		var n = arguments.length;
+		if (n === 0) {
+			return new NativeDate(now);
+		} else if (n === 1) {
+			return new NativeDate(year);
+		}
+		return new NativeDate(year, month, n > 2 ? day : 1, n > 3 ? hours : 0, n > 4 ? minutes : 0, n > 5 ? seconds : 0, n > 6 ? ms : 0);
+	};
+	SandboxDate.prototype = NativeDate.prototype;
+	SandboxDate.prototype.constructor = SandboxDate;
+	SandboxDate.parse = NativeDate.parse;
+	SandboxDate.UTC = NativeDate.UTC;
+	SandboxDate.now = function () {
+		return now;
+	};
+	Date = SandboxDate;
+})`
+
+// injectSandbox derives Math.random and Date of the contract from the seed and the frozen time of the sandbox.
+func injectSandbox(vm *otto.Otto, sandbox *entity.Sandbox) error {
+
+	vm.SetRandomSource(sandbox.Random())
+
+	install, err := vm.Run(anchorageSandboxDate)
+	if err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract sandbox: %s", err.Error())
+	}
+
+	if _, err := install.Call(otto.NullValue(), sandbox.UnixMilli()); err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract sandbox: %s", err.Error())
+	}
+
+	return nil
+}
+
+// injectConsole replaces the console object of the otto vm, so the output is captured into the log buffer instead of the server stdout.
+// If the buffer is nil, the console output is discarded.
+func injectConsole(vm *otto.Otto, logBuffer *entity.ContractLogBuffer) error {
+
+	console, err := vm.Object(`({})`)
+	if err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract console: %s", err.Error())
+	}
+
+	levels := map[string]string{
+		"log":   entity.LogLevelInfo,
+		"info":  entity.LogLevelInfo,
+		"debug": entity.LogLevelDebug,
+		"warn":  entity.LogLevelWarn,
+		"error": entity.LogLevelError,
+	}
+
+	for name, level := range levels {
+		level := level
+		if err := console.Set(name, func(call otto.FunctionCall) otto.Value {
+			if logBuffer != nil {
+				logBuffer.Append(level, formatConsoleArgs(vm, call.ArgumentList))
+			}
+			return otto.UndefinedValue()
+		}); err != nil {
+			return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract console: %s", err.Error())
+		}
+	}
+
+	if err := vm.Set("console", console); err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract console: %s", err.Error())
+	}
+
+	return nil
+}
+
+// formatConsoleArgs joins the console arguments like a browser does.
+// Objects are serialized as JSON, every other value is converted to string.
+func formatConsoleArgs(vm *otto.Otto, args []otto.Value) string {
+
+	parts := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if arg.IsObject() && arg.Class() != "Function" {
+			if raw, err := vm.Call("JSON.stringify", nil, arg); err == nil && raw.IsString() {
+				parts = append(parts, raw.String())
+				continue
+			}
+		}
+		parts = append(parts, arg.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// injectInput exposes the call payload to the contract as the input and args objects.
+// If no payload is provided, an empty object is exposed so the contract can safely access its properties.
+func injectInput(vm *otto.Otto, input any) error {
+
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	value, err := vm.ToValue(input)
+	if err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract input: %s", err.Error())
+	}
+
+	if err := vm.Set("input", value); err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract input: %s", err.Error())
+	}
+
+	if err := vm.Set("args", value); err != nil {
+		return apperr.Errorf(apperr.EANCHORAGE, "Error while injecting contract input: %s", err.Error())
 	}
 
-	return str, nil
+	return nil
 }
 
 // injectStateAccessor injects the state accessor into the otto vm.
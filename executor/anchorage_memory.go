@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"github.com/robertkrimen/otto"
+)
+
+// anchorageMemory returns the measure of the memory held by an otto vm.
+// otto has no lexical declarations, so every global of the contract is a property of the global object.
+func anchorageMemory(global *otto.Object) func(sizer *memorySizer) {
+	return func(sizer *memorySizer) {
+		seen := make(map[otto.Value]struct{})
+		for _, key := range global.Keys() {
+			value, err := global.Get(key)
+			if err != nil {
+				continue
+			}
+			if !sizer.Add(memoryPropertySize+int64(len(key))) || !anchorageValue(sizer, value, seen) {
+				return
+			}
+		}
+	}
+}
+
+// anchorageValue adds the size of an otto value and of the values reachable from it.
+// The functions are not walked, the values held by their closures are not visible to the vm api.
+func anchorageValue(sizer *memorySizer, value otto.Value, seen map[otto.Value]struct{}) bool {
+
+	switch {
+	case value.IsString():
+		return sizer.Add(memoryValueSize + int64(len(value.String())))
+	case !value.IsObject():
+		return sizer.Add(memoryValueSize)
+	}
+
+	if _, ok := seen[value]; ok {
+		return true
+	}
+	seen[value] = struct{}{}
+
+	object := value.Object()
+	if object.Class() == "Function" {
+		return sizer.Add(memoryObjectSize)
+	}
+
+	if !sizer.Add(memoryObjectSize) {
+		return false
+	}
+
+	for _, key := range object.Keys() {
+		property, err := object.Get(key)
+		if err != nil {
+			continue
+		}
+		if !sizer.Add(memoryPropertySize+int64(len(key))) || !anchorageValue(sizer, property, seen) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,7 +2,9 @@ package executor_test
 
 import (
 	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
@@ -10,6 +12,79 @@ import (
 	"github.com/music-gang/music-gang-api/executor"
 )
 
+func TestAnchorageContractExecutor_CompileContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		code := `
+			function sum(a, b) {
+				return a+b;
+			}
+			var result = sum(1, 2);
+		`
+
+		if err := executor.NewAnchorageContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("AssignedInFunction", func(t *testing.T) {
+
+		code := `
+			var result;
+			function main() {
+				result = 1;
+			}
+			main();
+		`
+
+		if err := executor.NewAnchorageContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("SyntaxError", func(t *testing.T) {
+
+		code := "var a = 1;\nvar result = sum(1, 2;\n"
+
+		err := executor.NewAnchorageContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Fatalf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		details, ok := apperr.ErrorDetails(err).([]*entity.RevisionCodeError)
+		if !ok || len(details) == 0 {
+			t.Fatalf("Expected code errors in details, got %v", apperr.ErrorDetails(err))
+		} else if details[0].Line != 2 || details[0].Column != 22 {
+			t.Errorf("Expected error at 2:22, got %d:%d", details[0].Line, details[0].Column)
+		}
+	})
+
+	t.Run("ResultNotAssigned", func(t *testing.T) {
+
+		code := `
+			var res = 1;
+			function result() {}
+		`
+
+		if err := executor.NewAnchorageContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err == nil {
+			t.Error("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
+
 func TestAnchorageContractExecutor_ExecContract(t *testing.T) {
 
 	code := `
@@ -37,11 +112,127 @@ func TestAnchorageContractExecutor_ExecContract(t *testing.T) {
 			t.Errorf("Unexpected error: %s", err.Error())
 		} else if res == nil {
 			t.Errorf("Expected response, got nil")
-		} else if res.(string) != "3" {
+		} else if res.(float64) != 3 {
 			t.Errorf("Expected response, got %v", res)
 		}
 	})
 
+	t.Run("Input", func(t *testing.T) {
+
+		code := `
+			var result = input.a + args.b;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			Input:       map[string]any{"a": float64(1), "b": float64(2)},
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res.(float64) != 3 {
+			t.Errorf("Expected 3, got %v", res)
+		}
+	})
+
+	t.Run("MissingInput", func(t *testing.T) {
+
+		code := `
+			var result = input.a === undefined;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != true {
+			t.Errorf("Expected true, got %v", res)
+		}
+	})
+
+	t.Run("StructuredResult", func(t *testing.T) {
+
+		code := `
+			var result = {
+				name: "test",
+				values: [1, "two", false],
+				nested: { ok: true },
+				empty: null
+			};
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		expected := map[string]any{
+			"name":   "test",
+			"values": []any{float64(1), "two", false},
+			"nested": map[string]any{"ok": true},
+			"empty":  nil,
+		}
+
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+
+	t.Run("UndefinedResult", func(t *testing.T) {
+
+		code := `
+			var x = 1;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != nil {
+			t.Errorf("Expected nil, got %v", res)
+		}
+	})
+
 	t.Run("ContextCancelled", func(t *testing.T) {
 
 		executor := executor.NewAnchorageContractExecutor()
@@ -88,6 +279,45 @@ func TestAnchorageContractExecutor_ExecContract(t *testing.T) {
 		})
 	})
 
+	t.Run("EngineExecutionTimeoutAfterRun", func(t *testing.T) {
+
+		defer func(d time.Duration) { entity.MaxExecutionTime = d }(entity.MaxExecutionTime)
+		entity.MaxExecutionTime = 50 * time.Millisecond
+
+		// the timeout fires during the last statement, while the metering func holds the interrupt slot.
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelAbsoluteActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelAbsoluteActionAmount,
+				CompiledCode: []byte(`var result = input.wait();`),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("Unexpected panic after the contract finished: %v", r)
+			}
+		}()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			MeterRef:    entity.NewFuelMeter(contract.LastRevision.MaxFuel, 1),
+			Input: map[string]any{
+				"wait": func() int {
+					time.Sleep(4 * entity.MaxExecutionTime)
+					return 1
+				},
+			},
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if res != float64(1) {
+			t.Errorf("Expected 1, got %v", res)
+		}
+	})
+
 	t.Run("ErrRuntime", func(t *testing.T) {
 
 		code := `
@@ -153,7 +383,7 @@ func TestAnchorageContractExecutor_Stateful(t *testing.T) {
 			t.Errorf("Unexpected error: %s", err.Error())
 		} else if res == nil {
 			t.Errorf("Expected response, got nil")
-		} else if res.(string) != "3" {
+		} else if res.(float64) != 3 {
 			t.Errorf("Expected response, got %v", res)
 		} else if v, ok := contractState.Value["sum"]; !ok {
 			t.Errorf("Expected state, got nil")
@@ -162,3 +392,367 @@ func TestAnchorageContractExecutor_Stateful(t *testing.T) {
 		}
 	})
 }
+
+func TestAnchorageContractExecutor_Console(t *testing.T) {
+
+	code := `
+		console.log("hello", 1, { a: true });
+		console.warn("warning");
+		console.error("error");
+		var result = true;
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			CompiledCode: []byte(code),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		logBuffer := entity.NewContractLogBuffer()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			LogRef:      logBuffer,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		logs := logBuffer.Logs()
+
+		if len(logs) != 3 {
+			t.Fatalf("Expected 3 logs, got %d", len(logs))
+		}
+
+		if logs[0].Level != entity.LogLevelInfo || logs[0].Message != `hello 1 {"a":true}` {
+			t.Errorf("Unexpected log %v", logs[0])
+		}
+
+		if logs[1].Level != entity.LogLevelWarn || logs[1].Message != "warning" {
+			t.Errorf("Unexpected log %v", logs[1])
+		}
+
+		if logs[2].Level != entity.LogLevelError || logs[2].Message != "error" {
+			t.Errorf("Unexpected log %v", logs[2])
+		}
+	})
+
+	t.Run("NoBuffer", func(t *testing.T) {
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if res != true {
+			t.Errorf("Expected true, got %v", res)
+		}
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+
+		code := `
+			for (var i = 0; i < 1000; i++) {
+				console.log(i);
+			}
+			var result = true;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		logBuffer := entity.NewContractLogBuffer()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			LogRef:      logBuffer,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if n := len(logBuffer.Logs()); n != entity.MaxContractLogEntries {
+			t.Errorf("Expected %d logs, got %d", entity.MaxContractLogEntries, n)
+		} else if !logBuffer.Truncated() {
+			t.Errorf("Expected truncated buffer")
+		}
+	})
+}
+
+func TestAnchorageContractExecutor_Meter(t *testing.T) {
+
+	code := `
+		var result = 0;
+		for (var i = 0; i < 100; i++) {
+			result += i;
+		}
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(code),
+		},
+	}
+
+	t.Run("Deterministic", func(t *testing.T) {
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		var units []uint64
+
+		for i := 0; i < 2; i++ {
+
+			meter := entity.NewFuelMeter(contract.LastRevision.MaxFuel, 1)
+
+			if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+				ContractRef: contract,
+				RevisionRef: contract.LastRevision,
+				MeterRef:    meter,
+			}); err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			} else if res != float64(4950) {
+				t.Fatalf("Expected 4950, got %v", res)
+			}
+
+			units = append(units, meter.Units())
+		}
+
+		if units[0] < 200 {
+			t.Errorf("Expected at least a unit for every statement of the loop, got %d", units[0])
+		} else if units[0] != units[1] {
+			t.Errorf("Expected the same units for every run, got %v", units)
+		}
+	})
+
+	t.Run("FuelExhausted", func(t *testing.T) {
+
+		code := `
+			var result = 0;
+			try {
+				for (;;) {
+					result++;
+				}
+			} catch (e) {
+				result = -1;
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		meter := entity.NewFuelMeter(entity.FuelInstantActionAmount, 1)
+
+		defer func() {
+			if r := recover(); r != service.EngineFuelExhaustedPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineFuelExhaustedPanic, r)
+			} else if meter.Used() != entity.FuelInstantActionAmount {
+				t.Errorf("Expected %d fuel used, got %d", entity.FuelInstantActionAmount, meter.Used())
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			MeterRef:    meter,
+		})
+	})
+}
+
+func TestAnchorageContractExecutor_Limits(t *testing.T) {
+
+	t.Run("MemoryLimit", func(t *testing.T) {
+
+		code := `
+			var values = [];
+			for (;;) {
+				values.push(new Array(100).join("x"));
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    1 << 20,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		defer func() {
+			if r := recover(); r != service.EngineMemoryLimitPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineMemoryLimitPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("MemoryUnderLimit", func(t *testing.T) {
+
+		code := `
+			var values = [];
+			for (var i = 0; i < 1000; i++) {
+				values.push(i);
+			}
+			var result = values.length;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    1 << 20,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(1000) {
+			t.Errorf("Expected 1000, got %v", res)
+		}
+	})
+
+	t.Run("ResultTooLarge", func(t *testing.T) {
+
+		code := `
+			var result = new Array(2048).join("x");
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		defer func(size int) {
+			entity.MaxContractResultSize = size
+		}(entity.MaxContractResultSize)
+
+		entity.MaxContractResultSize = 1024
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_OUTPUT_TOO_LARGE {
+			t.Errorf("Expected %s, got %s", apperr.EMGVM_OUTPUT_TOO_LARGE, errCode)
+		}
+	})
+}
+
+func TestAnchorageContractExecutor_Sandbox(t *testing.T) {
+
+	code := `
+		var result = {
+			random: [Math.random(), Math.random()],
+			now: Date.now(),
+			date: new Date().getTime(),
+			string: Date() === new Date().toString(),
+			fixed: new Date(2020, 0, 2).getFullYear(),
+			isDate: new Date() instanceof Date
+		};
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(code),
+		},
+	}
+
+	sandbox := &entity.Sandbox{
+		Seed: 42,
+		Now:  time.Date(2021, time.March, 4, 5, 6, 7, 8000000, time.UTC),
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			SandboxRef:  sandbox,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		random := sandbox.Random()
+
+		expected := map[string]any{
+			"random": []any{random(), random()},
+			"now":    float64(sandbox.UnixMilli()),
+			"date":   float64(sandbox.UnixMilli()),
+			"string": true,
+			"fixed":  float64(2020),
+			"isDate": true,
+		}
+
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+
+		executor := executor.NewAnchorageContractExecutor()
+
+		var results []any
+
+		for i := 0; i < 2; i++ {
+			res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+				ContractRef: contract,
+				RevisionRef: contract.LastRevision,
+				SandboxRef:  sandbox,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			}
+			results = append(results, res)
+		}
+
+		if !reflect.DeepEqual(results[0], results[1]) {
+			t.Errorf("Expected the same result, got %v and %v", results[0], results[1])
+		}
+	})
+}
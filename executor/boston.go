@@ -0,0 +1,416 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.ContractExecutorService = (*BostonContractExecutor)(nil)
+
+// bostonResultProgram reads the result of the contract.
+// The result can be declared with let or const, so it is not always a property of the global object.
+var bostonResultProgram = goja.MustCompile("result", "typeof result === 'undefined' ? undefined : result", false)
+
+// BostonContractExecutor is the contract executor for the boston contract version.
+// It runs ES2015+ code, like let, const, arrow functions, classes and promises.
+type BostonContractExecutor struct{}
+
+// NewBostonContractExecutor creates a new BostonContractExecutor.
+func NewBostonContractExecutor() *BostonContractExecutor {
+	return &BostonContractExecutor{}
+}
+
+// CompileContract parses the code of the revision without executing it.
+// Return EINVALID with the position of the syntax errors in the details, or if the code never assigns result.
+func (*BostonContractExecutor) CompileContract(ctx context.Context, revision *entity.Revision) error {
+
+	program, err := parser.ParseFile(nil, "", string(revision.CompiledCode), 0, parser.WithDisableSourceMaps)
+	if err != nil {
+		return apperr.Errorf(apperr.EINVALID, "Syntax error in contract: %s", err.Error()).WithDetails(bostonSyntaxErrors(err))
+	}
+
+	if err := checkBostonReserved(program); err != nil {
+		return err
+	}
+
+	// some errors, like a redeclared let, are reported only by the compiler.
+	if _, err := goja.CompileAST(program, false); err != nil {
+		return apperr.Errorf(apperr.EINVALID, "Syntax error in contract: %s", err.Error()).WithDetails(bostonSyntaxErrors(err))
+	}
+
+	if !bostonAssignsResult(program) {
+		return apperr.Errorf(apperr.EINVALID, "Contract never assigns result").WithDetails([]*entity.RevisionCodeError{
+			{Message: "result is never assigned"},
+		})
+	}
+
+	return nil
+}
+
+// ExecContract effectively executes the contract and returns the result.
+// If the contract assigns a promise to result, the settled value of the promise is returned.
+// If the engine goes into execution timeout, it panics with EngineExecutionTimeoutPanic.
+// If the contract uses all the fuel of the meter, it panics with EngineFuelExhaustedPanic.
+// If the contract holds more memory than the ceiling of the revision, it panics with EngineMemoryLimitPanic.
+// If the options carry a sandbox, Math.random and Date of the contract derive from it.
+func (*BostonContractExecutor) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+
+	revision, err := opt.Revision()
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := opt.Contract()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, apperr.Errorf(apperr.EBOSTON, "Timeout while executing contract")
+	default:
+	}
+
+	parsed, err := parser.ParseFile(nil, "", string(revision.CompiledCode), 0, parser.WithDisableSourceMaps)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while executing contract: %s", err.Error())
+	}
+
+	vm := goja.New()
+
+	measure, err := bostonMemory(vm, parsed)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while executing contract: %s", err.Error())
+	}
+
+	guard := newMemoryGuard(revision, opt.StateRef, measure)
+
+	if opt.MeterRef != nil || guard != nil {
+		if err := checkBostonReserved(parsed); err != nil {
+			return nil, err
+		}
+		meterBostonProgram(parsed)
+		if err := injectBostonMeter(vm, opt.MeterRef, guard); err != nil {
+			return nil, err
+		}
+	}
+
+	program, err := goja.CompileAST(parsed, false)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while executing contract: %s", err.Error())
+	}
+
+	timeoutTimer := time.NewTimer(entity.MaxExecutionTimeFromFuel(revision.MaxFuel))
+	defer timeoutTimer.Stop()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-timeoutTimer.C:
+			vm.Interrupt(service.EngineExecutionTimeoutPanic)
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	if err := injectBostonInput(vm, opt.Input); err != nil {
+		return nil, err
+	}
+
+	if err := injectBostonConsole(vm, opt.LogRef); err != nil {
+		return nil, err
+	}
+
+	if contract.Stateful && opt.StateRef != nil && opt.StateRef.Value != nil {
+		if err := injectBostonStateAccessor(vm, opt.StateRef); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.SandboxRef != nil {
+		injectBostonSandbox(vm, opt.SandboxRef)
+	}
+
+	if _, err := vm.RunProgram(program); err != nil {
+		return nil, bostonRunError(err)
+	}
+
+	if !guard.Check() {
+		panic(service.EngineMemoryLimitPanic)
+	}
+
+	value, err := vm.RunProgram(bostonResultProgram)
+	if err != nil {
+		return nil, bostonRunError(err)
+	}
+
+	if p, ok := value.Export().(*goja.Promise); ok {
+		switch p.State() {
+		case goja.PromiseStateFulfilled:
+			value = p.Result()
+		case goja.PromiseStateRejected:
+			return nil, apperr.Errorf(apperr.EBOSTON, "Contract result rejected: %s", p.Result().String())
+		default:
+			return nil, apperr.Errorf(apperr.EBOSTON, "Contract result is a promise never settled")
+		}
+	}
+
+	return exportBostonResult(vm, value)
+}
+
+// bostonRunError converts the error of a contract run.
+// The timeout, the exhausted fuel and the memory limit panic like the anchorage executor does.
+func bostonRunError(err error) error {
+	if ie, ok := err.(*goja.InterruptedError); ok {
+		switch ie.Value() {
+		case service.EngineExecutionTimeoutPanic, service.EngineFuelExhaustedPanic, service.EngineMemoryLimitPanic:
+			panic(ie.Value())
+		}
+		return apperr.Errorf(apperr.EBOSTON, "Timeout while executing contract")
+	}
+	return apperr.Errorf(apperr.EBOSTON, "Error while executing contract: %s", err.Error())
+}
+
+// bostonSyntaxErrors converts the errors of the goja parser and compiler into the details reported to the uploader.
+func bostonSyntaxErrors(err error) []*entity.RevisionCodeError {
+
+	switch e := err.(type) {
+	case parser.ErrorList:
+		details := make([]*entity.RevisionCodeError, 0, len(e))
+		for _, pe := range e {
+			details = append(details, &entity.RevisionCodeError{
+				Line:    pe.Position.Line,
+				Column:  pe.Position.Column,
+				Message: pe.Message,
+			})
+		}
+		return details
+	case *goja.CompilerSyntaxError:
+		detail := &entity.RevisionCodeError{Message: e.Message}
+		if e.File != nil {
+			position := e.File.Position(e.Offset)
+			detail.Line, detail.Column = position.Line, position.Column
+		}
+		return []*entity.RevisionCodeError{detail}
+	}
+
+	return []*entity.RevisionCodeError{{Message: err.Error()}}
+}
+
+// bostonAssignsResult reports whether the program assigns the result variable somewhere, like let result = 1 or result = 1.
+func bostonAssignsResult(program *ast.Program) bool {
+
+	assigned := false
+
+	walkBostonAST(reflect.ValueOf(program), func(node interface{}) bool {
+		switch n := node.(type) {
+		case *ast.AssignExpression:
+			if id, ok := n.Left.(*ast.Identifier); ok && id.Name == "result" {
+				assigned = true
+			}
+		case *ast.Binding:
+			if id, ok := n.Target.(*ast.Identifier); ok && id.Name == "result" && n.Initializer != nil {
+				assigned = true
+			}
+		}
+		return !assigned
+	})
+
+	return assigned
+}
+
+// walkBostonAST calls visit for every node of the program, the parents before their children, until visit returns false.
+// The goja ast has no walker, so the nodes are visited by reflection, only the types of the ast package are followed.
+// visit can replace the children of the node, the walk descends into the new ones.
+func walkBostonAST(v reflect.Value, visit func(node interface{}) bool) bool {
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || walkBostonAST(v.Elem(), visit)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if !walkBostonAST(v.Index(i), visit) {
+				return false
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Type().PkgPath() != astPkgPath {
+			return true
+		}
+		return visit(v.Interface()) && walkBostonAST(v.Elem(), visit)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && !walkBostonAST(v.Field(i), visit) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// astPkgPath is the package of the goja ast nodes.
+var astPkgPath = reflect.TypeOf(ast.Program{}).PkgPath()
+
+// exportBostonResult converts the contract result into plain JSON values (objects, arrays, numbers, booleans, strings).
+// The conversion passes through JSON.stringify, so the result is exactly what a JS client would see.
+// If the contract does not assign the result, nil is returned.
+// Return EMGVM_OUTPUT_TOO_LARGE if the encoded result is larger than MaxContractResultSize.
+func exportBostonResult(vm *goja.Runtime, value goja.Value) (any, error) {
+
+	if goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, nil
+	}
+
+	stringify, ok := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+	if !ok {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while parsing contract result: JSON.stringify is not a function")
+	}
+
+	raw, err := stringify(goja.Undefined(), value)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while parsing contract result: %s", err.Error())
+	} else if goja.IsUndefined(raw) {
+		// functions and symbols are not serializable.
+		return nil, nil
+	}
+
+	encoded := raw.String()
+	if len(encoded) > entity.MaxContractResultSize {
+		return nil, apperr.Errorf(apperr.EMGVM_OUTPUT_TOO_LARGE, "Contract result is %d bytes, max result size is %d bytes", len(encoded), entity.MaxContractResultSize)
+	}
+
+	var res any
+	if err := json.Unmarshal([]byte(encoded), &res); err != nil {
+		return nil, apperr.Errorf(apperr.EBOSTON, "Error while parsing contract result: %s", err.Error())
+	}
+
+	return res, nil
+}
+
+// injectBostonSandbox derives Math.random and Date of the contract from the seed and the frozen time of the sandbox.
+func injectBostonSandbox(vm *goja.Runtime, sandbox *entity.Sandbox) {
+	vm.SetRandSource(goja.RandSource(sandbox.Random()))
+	vm.SetTimeSource(func() time.Time {
+		return sandbox.Now
+	})
+}
+
+// injectBostonConsole exposes a console object to the contract, the output is captured into the log buffer.
+// If the buffer is nil, the console output is discarded.
+func injectBostonConsole(vm *goja.Runtime, logBuffer *entity.ContractLogBuffer) error {
+
+	console := vm.NewObject()
+
+	levels := map[string]string{
+		"log":   entity.LogLevelInfo,
+		"info":  entity.LogLevelInfo,
+		"debug": entity.LogLevelDebug,
+		"warn":  entity.LogLevelWarn,
+		"error": entity.LogLevelError,
+	}
+
+	for name, level := range levels {
+		level := level
+		if err := console.Set(name, func(call goja.FunctionCall) goja.Value {
+			if logBuffer != nil {
+				logBuffer.Append(level, formatBostonConsoleArgs(vm, call.Arguments))
+			}
+			return goja.Undefined()
+		}); err != nil {
+			return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract console: %s", err.Error())
+		}
+	}
+
+	if err := vm.Set("console", console); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract console: %s", err.Error())
+	}
+
+	return nil
+}
+
+// formatBostonConsoleArgs joins the console arguments like a browser does.
+// Objects are serialized as JSON, every other value is converted to string.
+func formatBostonConsoleArgs(vm *goja.Runtime, args []goja.Value) string {
+
+	parts := make([]string, 0, len(args))
+
+	stringify, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+
+	for _, arg := range args {
+		if obj, ok := arg.(*goja.Object); ok && stringify != nil {
+			if _, isFunc := goja.AssertFunction(obj); !isFunc {
+				if raw, err := stringify(goja.Undefined(), arg); err == nil && !goja.IsUndefined(raw) {
+					parts = append(parts, raw.String())
+					continue
+				}
+			}
+		}
+		parts = append(parts, arg.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// injectBostonInput exposes the call payload to the contract as the input and args objects.
+// If no payload is provided, an empty object is exposed so the contract can safely access its properties.
+func injectBostonInput(vm *goja.Runtime, input any) error {
+
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	value := vm.ToValue(input)
+
+	if err := vm.Set("input", value); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract input: %s", err.Error())
+	}
+
+	if err := vm.Set("args", value); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract input: %s", err.Error())
+	}
+
+	return nil
+}
+
+// injectBostonStateAccessor exposes the getState and setState functions to the contract.
+func injectBostonStateAccessor(vm *goja.Runtime, contractState *entity.State) error {
+
+	if err := vm.Set("setState", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 2 {
+			return goja.Undefined()
+		}
+		contractState.Value[call.Argument(0).String()] = call.Argument(1).Export()
+		return goja.Undefined()
+	}); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract state: %s", err.Error())
+	}
+
+	if err := vm.Set("getState", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) != 1 {
+			return goja.Undefined()
+		}
+		value, ok := contractState.Value[call.Argument(0).String()]
+		if !ok {
+			return goja.Undefined()
+		}
+		return vm.ToValue(value)
+	}); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract state: %s", err.Error())
+	}
+
+	return nil
+}
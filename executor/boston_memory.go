@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+)
+
+// bostonMemory returns the measure of the memory held by a goja vm running the program.
+// The var and function declarations are properties of the global object, while the let, const and class declarations
+// of the top level live in the global scope: they are read by a program compiled from the names declared by the contract.
+func bostonMemory(vm *goja.Runtime, program *ast.Program) (func(sizer *memorySizer), error) {
+
+	lexical, err := bostonLexicalReader(program)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(sizer *memorySizer) {
+
+		seen := make(map[*goja.Object]struct{})
+
+		global := vm.GlobalObject()
+		for _, key := range global.Keys() {
+			if !sizer.Add(memoryPropertySize+int64(len(key))) || !bostonValue(sizer, global.Get(key), seen) {
+				return
+			}
+		}
+
+		if lexical == nil {
+			return
+		}
+
+		// the reader runs nested in the contract, the declarations still in their temporal dead zone read as undefined.
+		values, err := vm.RunProgram(lexical)
+		if err != nil {
+			return
+		}
+		bostonValue(sizer, values, seen)
+	}, nil
+}
+
+// bostonLexicalReader compiles the program returning the values of the top level let, const and class declarations.
+// Return nil if the contract has none.
+func bostonLexicalReader(program *ast.Program) (*goja.Program, error) {
+
+	var names []string
+
+	for _, statement := range program.Body {
+		switch s := statement.(type) {
+		case *ast.LexicalDeclaration:
+			for _, binding := range s.List {
+				walkBostonAST(reflect.ValueOf(binding.Target), func(node interface{}) bool {
+					if id, ok := node.(*ast.Identifier); ok {
+						names = append(names, string(id.Name))
+					}
+					return true
+				})
+			}
+		case *ast.ClassDeclaration:
+			if s.Class != nil && s.Class.Name != nil {
+				names = append(names, string(s.Class.Name.Name))
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var src strings.Builder
+	src.WriteString("[")
+	for _, name := range names {
+		src.WriteString("(() => { try { return " + name + "; } catch (e) {} })(),")
+	}
+	src.WriteString("]")
+
+	return goja.Compile("", src.String(), false)
+}
+
+// bostonArrayBufferType is the exported type of the ArrayBuffer objects.
+var bostonArrayBufferType = reflect.TypeOf(goja.ArrayBuffer{})
+
+// isBostonBufferElem reports whether t is the element type exported by a typed array.
+func isBostonBufferElem(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// bostonValue adds the size of a goja value and of the values reachable from it.
+// The functions are not walked, the values held by their closures are not visible to the vm api.
+// The maps and the sets are estimated from their size.
+func bostonValue(sizer *memorySizer, value goja.Value, seen map[*goja.Object]struct{}) bool {
+
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return sizer.Add(memoryValueSize)
+	}
+
+	object, ok := value.(*goja.Object)
+	if !ok {
+		if s, ok := value.Export().(string); ok {
+			return sizer.Add(memoryValueSize + int64(len(s)))
+		}
+		return sizer.Add(memoryValueSize)
+	}
+
+	if _, ok := seen[object]; ok {
+		return true
+	}
+	seen[object] = struct{}{}
+
+	if !sizer.Add(memoryObjectSize) {
+		return false
+	}
+
+	if object.ClassName() == "Function" {
+		return true
+	}
+
+	// goja does not tell apart the builtin classes, their exported type does.
+	if t := object.ExportType(); t == bostonArrayBufferType || t != nil && t.Kind() == reflect.Slice && isBostonBufferElem(t.Elem()) {
+		// the buffers and the typed arrays hold their elements in bytes, the indexes are not walked.
+		return sizer.Add(object.Get("byteLength").ToInteger())
+	} else if t != nil && t.Kind() == reflect.Slice && object.ClassName() != "Array" {
+		// the maps and the sets.
+		return sizer.Add(object.Get("size").ToInteger() * memoryPropertySize)
+	}
+
+	for _, key := range object.Keys() {
+		if !sizer.Add(memoryPropertySize+int64(len(key))) || !bostonValue(sizer, object.Get(key), seen) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/unistring"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+// bostonMeterName is the function called by the metered programs to charge their work units.
+// It is reserved, the contracts cannot declare or reference it.
+const bostonMeterName = "__mgFuel"
+
+// checkBostonReserved returns EINVALID if the program references the reserved identifiers.
+// A contract shadowing the meter would run its loops without being charged.
+func checkBostonReserved(program *ast.Program) error {
+
+	var reserved *ast.Identifier
+
+	walkBostonAST(reflect.ValueOf(program), func(node interface{}) bool {
+		if id, ok := node.(*ast.Identifier); ok && id.Name == bostonMeterName {
+			reserved = id
+		}
+		return reserved == nil
+	})
+
+	if reserved == nil {
+		return nil
+	}
+
+	detail := &entity.RevisionCodeError{Message: bostonMeterName + " is a reserved identifier"}
+	if program.File != nil {
+		position := program.File.Position(int(reserved.Idx) - program.File.Base())
+		detail.Line, detail.Column = position.Line, position.Column
+	}
+
+	return apperr.Errorf(apperr.EINVALID, "Contract uses the reserved identifier %s", bostonMeterName).WithDetails([]*entity.RevisionCodeError{detail})
+}
+
+// meterBostonProgram inserts a call to the meter at the start of every list of statements of the program.
+// Every call charges the number of statements of its list, so every block entered, like a loop iteration or a function call,
+// is charged at least one work unit. The bodies of the loops and of the if statements are wrapped in blocks when needed,
+// the bodies of the arrow functions are converted to blocks returning the expression.
+// The code built at runtime, with eval or Function, is not metered: it is limited only by the execution time.
+func meterBostonProgram(program *ast.Program) {
+
+	program.Body = meterBostonStatements(program.Body, program.Idx0())
+
+	walkBostonAST(reflect.ValueOf(program), func(node interface{}) bool {
+		switch n := node.(type) {
+		case *ast.BlockStatement:
+			n.List = meterBostonStatements(n.List, n.LeftBrace)
+		case *ast.CaseStatement:
+			if len(n.Consequent) > 0 {
+				n.Consequent = meterBostonStatements(n.Consequent, n.Case)
+			}
+		case *ast.IfStatement:
+			n.Consequent = wrapBostonStatement(n.Consequent)
+			n.Alternate = wrapBostonStatement(n.Alternate)
+		case *ast.ForStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.ForInStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.ForOfStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.WhileStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.DoWhileStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.WithStatement:
+			n.Body = wrapBostonStatement(n.Body)
+		case *ast.ArrowFunctionLiteral:
+			if body, ok := n.Body.(*ast.ExpressionBody); ok {
+				idx := body.Expression.Idx0()
+				n.Body = &ast.BlockStatement{
+					LeftBrace:  idx,
+					List:       []ast.Statement{&ast.ReturnStatement{Return: idx, Argument: body.Expression}},
+					RightBrace: body.Expression.Idx1(),
+				}
+			}
+		}
+		return true
+	})
+}
+
+// meterBostonStatements returns the list with the call to the meter inserted before its statements.
+// The call is inserted after the directives, like "use strict", so they keep their meaning.
+func meterBostonStatements(list []ast.Statement, idx file.Idx) []ast.Statement {
+
+	if len(list) > 0 && isBostonMeterCall(list[0]) {
+		return list
+	}
+
+	directives := 0
+	for _, stmt := range list {
+		expr, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			break
+		}
+		if _, ok := expr.Expression.(*ast.StringLiteral); !ok {
+			break
+		}
+		directives++
+	}
+
+	units := len(list) - directives
+	if units == 0 {
+		units = 1
+	}
+
+	call := &ast.ExpressionStatement{
+		Expression: &ast.CallExpression{
+			Callee:           &ast.Identifier{Name: unistring.String(bostonMeterName), Idx: idx},
+			LeftParenthesis:  idx,
+			ArgumentList:     []ast.Expression{&ast.NumberLiteral{Idx: idx, Literal: strconv.Itoa(units), Value: int64(units)}},
+			RightParenthesis: idx,
+		},
+	}
+
+	metered := make([]ast.Statement, 0, len(list)+1)
+	metered = append(metered, list[:directives]...)
+	metered = append(metered, call)
+	metered = append(metered, list[directives:]...)
+
+	return metered
+}
+
+// wrapBostonStatement wraps the statement in a block, so the walk can meter it like the other blocks.
+// The blocks, the labelled statements and the nil statements are returned as they are.
+func wrapBostonStatement(stmt ast.Statement) ast.Statement {
+	switch stmt.(type) {
+	case nil, *ast.BlockStatement, *ast.LabelledStatement:
+		return stmt
+	}
+	return &ast.BlockStatement{LeftBrace: stmt.Idx0(), List: []ast.Statement{stmt}, RightBrace: stmt.Idx1()}
+}
+
+// isBostonMeterCall reports whether the statement is a call to the meter inserted by meterBostonStatements.
+func isBostonMeterCall(stmt ast.Statement) bool {
+	expr, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	call, ok := expr.Expression.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+	id, ok := call.Callee.(*ast.Identifier)
+	return ok && id.Name == bostonMeterName
+}
+
+// injectBostonMeter exposes the meter function to the metered program, the work units are charged to the meter and to the memory guard.
+// Both can be nil.
+// The function is a read-only global, the contract cannot replace it.
+// When the fuel is exhausted or the memory ceiling is exceeded the vm is interrupted, the interruption cannot be caught by the contract.
+func injectBostonMeter(vm *goja.Runtime, meter *entity.FuelMeter, guard *memoryGuard) error {
+
+	charge := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		units := uint64(call.Argument(0).ToInteger())
+		if meter != nil && !meter.Consume(units) {
+			vm.Interrupt(service.EngineFuelExhaustedPanic)
+		} else if !guard.Consume(units) {
+			vm.Interrupt(service.EngineMemoryLimitPanic)
+		}
+		return goja.Undefined()
+	})
+
+	if err := vm.GlobalObject().DefineDataProperty(bostonMeterName, charge, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE); err != nil {
+		return apperr.Errorf(apperr.EBOSTON, "Error while injecting contract meter: %s", err.Error())
+	}
+
+	return nil
+}
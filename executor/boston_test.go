@@ -0,0 +1,786 @@
+package executor_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/executor"
+)
+
+func TestBostonContractExecutor_CompileContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		code := `
+			const sum = (a, b) => a + b;
+			let result = sum(1, 2);
+		`
+
+		if err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("AssignedInClass", func(t *testing.T) {
+
+		code := `
+			var result;
+			class Counter {
+				constructor() {
+					result = 1;
+				}
+			}
+			new Counter();
+		`
+
+		if err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("SyntaxError", func(t *testing.T) {
+
+		code := "let a = 1;\nlet result = sum(1, 2;\n"
+
+		err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Fatalf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		details, ok := apperr.ErrorDetails(err).([]*entity.RevisionCodeError)
+		if !ok || len(details) == 0 {
+			t.Fatalf("Expected code errors in details, got %v", apperr.ErrorDetails(err))
+		} else if details[0].Line != 2 {
+			t.Errorf("Expected error at line 2, got %d", details[0].Line)
+		}
+	})
+
+	t.Run("Redeclared", func(t *testing.T) {
+
+		code := "let result = 1;\nlet result = 2;\n"
+
+		err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Fatalf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		details, ok := apperr.ErrorDetails(err).([]*entity.RevisionCodeError)
+		if !ok || len(details) != 1 {
+			t.Fatalf("Expected one code error in details, got %v", apperr.ErrorDetails(err))
+		} else if details[0].Line != 2 {
+			t.Errorf("Expected error at line 2, got %d", details[0].Line)
+		}
+	})
+
+	t.Run("ReservedIdentifier", func(t *testing.T) {
+
+		code := "let result = 1;\nconst __mgFuel = () => {};\n"
+
+		err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Fatalf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		details, ok := apperr.ErrorDetails(err).([]*entity.RevisionCodeError)
+		if !ok || len(details) != 1 {
+			t.Fatalf("Expected one code error in details, got %v", apperr.ErrorDetails(err))
+		} else if details[0].Line != 2 || details[0].Column != 7 {
+			t.Errorf("Expected error at 2:7, got %d:%d", details[0].Line, details[0].Column)
+		}
+	})
+
+	t.Run("ResultNotAssigned", func(t *testing.T) {
+
+		code := `
+			const sum = (a, b) => a + b;
+			let total = sum(1, 2);
+		`
+
+		if err := executor.NewBostonContractExecutor().CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte(code),
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
+
+func TestBostonContractExecutor_ExecContract(t *testing.T) {
+
+	code := `
+		class Calculator {
+			sum(...values) {
+				return values.reduce((acc, v) => acc + v, 0);
+			}
+		}
+		const result = new Calculator().sum(1, 2);
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			CompiledCode: []byte(code),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewBostonContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(3) {
+			t.Errorf("Expected 3, got %v", res)
+		}
+	})
+
+	t.Run("Input", func(t *testing.T) {
+
+		code := `
+			const { a, b } = input;
+			let result = ` + "`${a}-${b}`" + `;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			Input:       map[string]any{"a": "x", "b": 2},
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != "x-2" {
+			t.Errorf("Expected x-2, got %v", res)
+		}
+	})
+
+	t.Run("StructuredResult", func(t *testing.T) {
+
+		code := `
+			const result = { list: [1, 2].map(v => v * 2), nested: { ok: true } };
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		expected := map[string]any{
+			"list":   []any{float64(2), float64(4)},
+			"nested": map[string]any{"ok": true},
+		}
+
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+
+	t.Run("Promise", func(t *testing.T) {
+
+		code := `
+			const double = async (v) => v * 2;
+			const result = double(2).then(v => v + 1);
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(5) {
+			t.Errorf("Expected 5, got %v", res)
+		}
+	})
+
+	t.Run("PromiseRejected", func(t *testing.T) {
+
+		code := `
+			const result = Promise.reject(new Error("boom"));
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EBOSTON {
+			t.Errorf("Expected error code %s, got %s", apperr.EBOSTON, errCode)
+		}
+	})
+
+	t.Run("UndefinedResult", func(t *testing.T) {
+
+		code := `
+			let result;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != nil {
+			t.Errorf("Expected nil, got %v", res)
+		}
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+
+		executor := executor.NewBostonContractExecutor()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		cancel()
+
+		if _, err := executor.ExecContract(ctx, service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+
+	t.Run("EngineExecutionTimeout", func(t *testing.T) {
+
+		code := `
+			let result = 0;
+			for (;;) {
+				result++;
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		defer func() {
+			if r := recover(); r != service.EngineExecutionTimeoutPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineExecutionTimeoutPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("ErrRuntime", func(t *testing.T) {
+
+		code := `
+			const result = missing();
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EBOSTON {
+			t.Errorf("Expected error code %s, got %s", apperr.EBOSTON, errCode)
+		}
+	})
+}
+
+func TestBostonContractExecutor_Stateful(t *testing.T) {
+
+	code := `
+		const sum = (a, b) => {
+			const s = a + b;
+			setState("sum", s);
+			return s;
+		};
+
+		sum(1, 2);
+
+		const result = getState("sum");
+	`
+
+	contract := &entity.Contract{
+		MaxFuel:  entity.FuelLongActionAmount,
+		Stateful: true,
+		LastRevision: &entity.Revision{
+			CompiledCode: []byte(code),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewBostonContractExecutor()
+
+		contractState := &entity.State{
+			Value: make(entity.StateValue),
+		}
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			StateRef:    contractState,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(3) {
+			t.Errorf("Expected 3, got %v", res)
+		} else if _, ok := contractState.Value["sum"]; !ok {
+			t.Errorf("Expected state, got nil")
+		}
+	})
+}
+
+func TestBostonContractExecutor_Console(t *testing.T) {
+
+	code := `
+		console.log("hello", 1, { a: true });
+		console.warn("warning");
+		const result = true;
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			CompiledCode: []byte(code),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewBostonContractExecutor()
+
+		logBuffer := entity.NewContractLogBuffer()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			LogRef:      logBuffer,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		logs := logBuffer.Logs()
+
+		if len(logs) != 2 {
+			t.Fatalf("Expected 2 logs, got %d", len(logs))
+		}
+
+		if logs[0].Level != entity.LogLevelInfo || logs[0].Message != `hello 1 {"a":true}` {
+			t.Errorf("Unexpected log %v", logs[0])
+		}
+
+		if logs[1].Level != entity.LogLevelWarn || logs[1].Message != "warning" {
+			t.Errorf("Unexpected log %v", logs[1])
+		}
+	})
+}
+
+func TestBostonContractExecutor_Meter(t *testing.T) {
+
+	t.Run("Semantics", func(t *testing.T) {
+
+		// the metered program must behave like the original one.
+		code := `
+			"use strict";
+			const double = v => v * 2;
+			let total = 0;
+			outer: for (let i = 0; i < 3; i++)
+				for (const j of [1, 2, 3]) {
+					if (j === 2) continue outer;
+					total += double(j);
+				}
+			switch (total) {
+				case 6:
+					total++;
+				default:
+					total++;
+			}
+			let i = 0;
+			while (i < 2) i++;
+			do i++; while (i < 5);
+			let strict = false;
+			try {
+				undeclared = 1;
+			} catch (e) {
+				strict = true;
+			}
+			class Counter {
+				static start = 1;
+				count() { return Counter.start + i; }
+			}
+			const result = [total, i, strict, new Counter().count()];
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		meter := entity.NewFuelMeter(contract.LastRevision.MaxFuel, 1)
+
+		res, err := executor.NewBostonContractExecutor().ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			MeterRef:    meter,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		expected := []any{float64(8), float64(5), true, float64(6)}
+
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		} else if meter.Units() == 0 {
+			t.Errorf("Expected units to be charged")
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+
+		code := `
+			let result = 0;
+			for (let i = 0; i < 100; i++) {
+				result += i;
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		var units []uint64
+
+		for i := 0; i < 2; i++ {
+
+			meter := entity.NewFuelMeter(contract.LastRevision.MaxFuel, 1)
+
+			if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+				ContractRef: contract,
+				RevisionRef: contract.LastRevision,
+				MeterRef:    meter,
+			}); err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			} else if res != float64(4950) {
+				t.Fatalf("Expected 4950, got %v", res)
+			}
+
+			units = append(units, meter.Units())
+		}
+
+		if units[0] < 100 {
+			t.Errorf("Expected at least a unit for every iteration of the loop, got %d", units[0])
+		} else if units[0] != units[1] {
+			t.Errorf("Expected the same units for every run, got %v", units)
+		}
+	})
+
+	t.Run("FuelExhausted", func(t *testing.T) {
+
+		code := `
+			let result = 0;
+			globalThis["__mg" + "Fuel"] = () => {};
+			try {
+				for (;;);
+			} catch (e) {
+				result = -1;
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		meter := entity.NewFuelMeter(entity.FuelInstantActionAmount, 1)
+
+		defer func() {
+			if r := recover(); r != service.EngineFuelExhaustedPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineFuelExhaustedPanic, r)
+			} else if meter.Used() != entity.FuelInstantActionAmount {
+				t.Errorf("Expected %d fuel used, got %d", entity.FuelInstantActionAmount, meter.Used())
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			MeterRef:    meter,
+		})
+	})
+}
+
+func TestBostonContractExecutor_Limits(t *testing.T) {
+
+	t.Run("MemoryLimit", func(t *testing.T) {
+
+		code := `
+			const values = [];
+			for (;;) {
+				values.push("x".repeat(100));
+			}
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    1 << 20,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		defer func() {
+			if r := recover(); r != service.EngineMemoryLimitPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineMemoryLimitPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("MemoryLimitAtExit", func(t *testing.T) {
+
+		code := `
+			const buffer = new ArrayBuffer(2 << 20);
+			const result = buffer.byteLength;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    1 << 20,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		defer func() {
+			if r := recover(); r != service.EngineMemoryLimitPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineMemoryLimitPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("MemoryUnderLimit", func(t *testing.T) {
+
+		code := `
+			const values = new Map();
+			for (let i = 0; i < 1000; i++) {
+				values.set(i, [i]);
+			}
+			const result = values.size;
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    1 << 20,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(1000) {
+			t.Errorf("Expected 1000, got %v", res)
+		}
+	})
+
+	t.Run("ResultTooLarge", func(t *testing.T) {
+
+		code := `
+			const result = "x".repeat(2048);
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: []byte(code),
+			},
+		}
+
+		executor := executor.NewBostonContractExecutor()
+
+		defer func(size int) {
+			entity.MaxContractResultSize = size
+		}(entity.MaxContractResultSize)
+
+		entity.MaxContractResultSize = 1024
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_OUTPUT_TOO_LARGE {
+			t.Errorf("Expected %s, got %s", apperr.EMGVM_OUTPUT_TOO_LARGE, errCode)
+		}
+	})
+}
+
+func TestBostonContractExecutor_Sandbox(t *testing.T) {
+
+	code := `
+		const result = {
+			random: [Math.random(), Math.random()],
+			now: Date.now(),
+			date: new Date().getTime(),
+			fixed: new Date(2020, 0, 2).getFullYear(),
+		};
+	`
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(code),
+		},
+	}
+
+	sandbox := &entity.Sandbox{
+		Seed: 42,
+		Now:  time.Date(2021, time.March, 4, 5, 6, 7, 8000000, time.UTC),
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewBostonContractExecutor()
+
+		res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			SandboxRef:  sandbox,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		random := sandbox.Random()
+
+		expected := map[string]any{
+			"random": []any{random(), random()},
+			"now":    float64(sandbox.UnixMilli()),
+			"date":   float64(sandbox.UnixMilli()),
+			"fixed":  float64(2020),
+		}
+
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+}
@@ -0,0 +1,473 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"strings"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+var _ service.ContractExecutorService = (*ChicagoContractExecutor)(nil)
+
+// The ABI between the chicago contracts and the executor.
+// The module exports its memory and a run function without params and results, called once per execution.
+// The host functions are imported from the env module, the pointers and the lengths are i32 offsets into the exported memory:
+//
+//	input(ptr, size i32) i32                          writes up to size bytes of the JSON input at ptr, returns the length of the whole input.
+//	output(ptr, len i32)                              sets the JSON result of the contract, the last call wins.
+//	getState(keyPtr, keyLen, ptr, size i32) i32       writes up to size bytes of the JSON value of the key at ptr, returns its whole length or -1 if the key is not set.
+//	setState(keyPtr, keyLen, valuePtr, valueLen i32)  sets the key to the JSON value.
+//	log(level, ptr, len i32)                          appends a line to the console output, the levels are 0 debug, 1 info, 2 warn and 3 error.
+//
+// The WASI preview 1 functions are available too, stdout and stderr are captured as console output.
+const (
+	chicagoHostModule = "env"
+	chicagoMemoryName = "memory"
+	chicagoRunName    = "run"
+)
+
+// chicagoInitializeName is the function exported by the WASI reactors to initialize their runtime, it is called before run.
+const chicagoInitializeName = "_initialize"
+
+// chicagoLogLevels are the console levels of the log host function, by number.
+var chicagoLogLevels = []string{entity.LogLevelDebug, entity.LogLevelInfo, entity.LogLevelWarn, entity.LogLevelError}
+
+// ChicagoContractExecutor is the contract executor for the chicago contract version.
+// It runs WebAssembly modules, like the ones built from Rust, TinyGo and AssemblyScript.
+type ChicagoContractExecutor struct {
+	// cache keeps the machine code of the modules, so the same revision is compiled once.
+	cache wazero.CompilationCache
+}
+
+// NewChicagoContractExecutor creates a new ChicagoContractExecutor.
+func NewChicagoContractExecutor() *ChicagoContractExecutor {
+	return &ChicagoContractExecutor{
+		cache: wazero.NewCompilationCache(),
+	}
+}
+
+// Close releases the compiled modules.
+func (e *ChicagoContractExecutor) Close() error {
+	return e.cache.Close(context.Background())
+}
+
+// CompileContract validates the module of the revision without executing it.
+// Return EINVALID if the module is malformed, if it needs more memory than the ceiling of the revision or if it does not follow the ABI.
+func (e *ChicagoContractExecutor) CompileContract(ctx context.Context, revision *entity.Revision) error {
+
+	code, err := meterChicagoModule(revision.CompiledCode, chicagoMemoryPages(revision))
+	if err != nil {
+		return chicagoInvalidModule(err)
+	}
+
+	runtime := e.newRuntime(ctx, revision)
+	defer runtime.Close(ctx)
+
+	if err := instantiateChicagoHost(ctx, runtime, &chicagoCall{}); err != nil {
+		return err
+	}
+
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		return chicagoInvalidModule(err)
+	}
+
+	if err := checkChicagoABI(runtime, compiled); err != nil {
+		return chicagoInvalidModule(err)
+	}
+
+	return nil
+}
+
+// ExecContract effectively executes the contract and returns the result.
+// If the engine goes into execution timeout, it panics with EngineExecutionTimeoutPanic.
+// If the contract uses all the fuel of the meter, it panics with EngineFuelExhaustedPanic.
+// If the module needs more memory than the ceiling of the revision, it panics with EngineMemoryLimitPanic,
+// while growing the memory past the ceiling fails inside the module like memory.grow does.
+// If the options carry a sandbox, the WASI clocks and random source derive from it.
+func (e *ChicagoContractExecutor) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+
+	revision, err := opt.Revision()
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := opt.Contract()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Timeout while executing contract")
+	default:
+	}
+
+	code, err := meterChicagoModule(revision.CompiledCode, chicagoMemoryPages(revision))
+	if errors.Is(err, errChicagoMemoryLimit) {
+		panic(service.EngineMemoryLimitPanic)
+	} else if err != nil {
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Error while executing contract: %s", err.Error())
+	}
+
+	call, err := newChicagoCall(opt, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	// the runtime closes the module when the context is done, so the timeout stops also the loops of the module.
+	runCtx, cancel := context.WithTimeout(ctx, entity.MaxExecutionTimeFromFuel(revision.MaxFuel))
+	defer cancel()
+
+	runtime := e.newRuntime(runCtx, revision)
+	defer runtime.Close(ctx)
+
+	if err := instantiateChicagoHost(runCtx, runtime, call); err != nil {
+		return nil, err
+	}
+
+	compiled, err := runtime.CompileModule(runCtx, code)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Error while executing contract: %s", err.Error())
+	}
+
+	module, err := runtime.InstantiateModule(runCtx, compiled, chicagoModuleConfig(opt))
+	if err != nil {
+		return nil, chicagoRunError(ctx, err)
+	}
+
+	meter, ok := module.ExportedGlobal(chicagoMeterName).(api.MutableGlobal)
+	if !ok {
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Error while executing contract: meter not found")
+	}
+
+	budget := int64(math.MaxInt64)
+	if opt.MeterRef != nil && opt.MeterRef.Remaining() < math.MaxInt64 {
+		budget = int64(opt.MeterRef.Remaining())
+	}
+	meter.Set(uint64(budget))
+
+	var runErr error
+	for _, name := range []string{chicagoStartName, chicagoInitializeName, chicagoRunName} {
+		fn := module.ExportedFunction(name)
+		if fn == nil {
+			continue
+		}
+		if _, runErr = fn.Call(runCtx); runErr != nil {
+			break
+		}
+	}
+
+	left := int64(meter.Get())
+	if opt.MeterRef != nil {
+		opt.MeterRef.Consume(uint64(budget - left))
+	}
+	if left < 0 {
+		panic(service.EngineFuelExhaustedPanic)
+	}
+
+	// a WASI module ending with exit code 0 completed its work.
+	var exitErr *sys.ExitError
+	if runErr != nil && !(errors.As(runErr, &exitErr) && exitErr.ExitCode() == 0) {
+		return nil, chicagoRunError(ctx, runErr)
+	}
+
+	return exportChicagoResult(call.output)
+}
+
+// newRuntime creates the runtime of a single execution, the memory of the module is limited to the ceiling of the revision.
+// The SIMD instructions are disabled, the meter does not count them.
+func (e *ChicagoContractExecutor) newRuntime(ctx context.Context, revision *entity.Revision) wazero.Runtime {
+	return wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(e.cache).
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(chicagoMemoryPages(revision)).
+		WithCoreFeatures(api.CoreFeaturesV2&^api.CoreFeatureSIMD))
+}
+
+// chicagoMemoryPages returns the memory ceiling of the revision in WASM pages.
+func chicagoMemoryPages(revision *entity.Revision) uint32 {
+	pages := revision.UnwrapMaxMemory() / chicagoPageSize
+	if pages > math.MaxUint16+1 {
+		return math.MaxUint16 + 1
+	}
+	return uint32(pages)
+}
+
+// chicagoInvalidModule returns the EINVALID reported to the uploader of a module that cannot run.
+func chicagoInvalidModule(err error) error {
+	return apperr.Errorf(apperr.EINVALID, "Invalid contract module: %s", err.Error()).WithDetails([]*entity.RevisionCodeError{
+		{Message: err.Error()},
+	})
+}
+
+// checkChicagoABI returns an error if the module does not export the run function and its memory,
+// or if it imports functions not provided by the host modules.
+func checkChicagoABI(runtime wazero.Runtime, compiled wazero.CompiledModule) error {
+
+	run, ok := compiled.ExportedFunctions()[chicagoRunName]
+	if !ok || len(run.ParamTypes()) > 0 || len(run.ResultTypes()) > 0 {
+		return fmt.Errorf("module must export a %s function without params and results", chicagoRunName)
+	}
+
+	if _, ok := compiled.ExportedMemories()[chicagoMemoryName]; !ok {
+		return fmt.Errorf("module must export its %s", chicagoMemoryName)
+	}
+
+	if len(compiled.ImportedMemories()) > 0 {
+		return errors.New("module cannot import memories")
+	}
+
+	for _, def := range compiled.ImportedFunctions() {
+
+		moduleName, name, _ := def.Import()
+
+		host := runtime.Module(moduleName)
+		if host == nil {
+			return fmt.Errorf("module imports the unknown module %s", moduleName)
+		}
+
+		hostDef, ok := host.ExportedFunctionDefinitions()[name]
+		if !ok {
+			return fmt.Errorf("module imports the unknown function %s.%s", moduleName, name)
+		}
+
+		if !bytes.Equal(def.ParamTypes(), hostDef.ParamTypes()) || !bytes.Equal(def.ResultTypes(), hostDef.ResultTypes()) {
+			return fmt.Errorf("module imports the function %s.%s with a wrong signature", moduleName, name)
+		}
+	}
+
+	return nil
+}
+
+// chicagoRunError converts the error of a module run.
+// The timeout panics like the other executors do, the cancellation of the caller is returned as error.
+func chicagoRunError(ctx context.Context, err error) error {
+
+	if ctx.Err() != nil {
+		return apperr.Errorf(apperr.ECHICAGO, "Timeout while executing contract")
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded {
+		panic(service.EngineExecutionTimeoutPanic)
+	}
+
+	return apperr.Errorf(apperr.ECHICAGO, "Error while executing contract: %s", err.Error())
+}
+
+// exportChicagoResult decodes the JSON result set by the module.
+// If the module does not set the result, nil is returned.
+// Return EMGVM_OUTPUT_TOO_LARGE if the result is larger than MaxContractResultSize.
+func exportChicagoResult(output []byte) (any, error) {
+
+	if output == nil {
+		return nil, nil
+	}
+
+	if len(output) > entity.MaxContractResultSize {
+		return nil, apperr.Errorf(apperr.EMGVM_OUTPUT_TOO_LARGE, "Contract result is %d bytes, max result size is %d bytes", len(output), entity.MaxContractResultSize)
+	}
+
+	var res any
+	if err := json.Unmarshal(output, &res); err != nil {
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Error while parsing contract result: %s", err.Error())
+	}
+
+	return res, nil
+}
+
+// chicagoModuleConfig returns the configuration of the module of a single execution.
+// No start function is run at instantiation, the executor runs them after setting the meter.
+func chicagoModuleConfig(opt service.ContractCallOpt) wazero.ModuleConfig {
+
+	config := wazero.NewModuleConfig().
+		WithStartFunctions().
+		WithStdout(&chicagoLogWriter{buffer: opt.LogRef, level: entity.LogLevelInfo}).
+		WithStderr(&chicagoLogWriter{buffer: opt.LogRef, level: entity.LogLevelError})
+
+	if opt.SandboxRef == nil {
+		return config.WithSysWalltime().WithSysNanotime().WithRandSource(rand.Reader)
+	}
+
+	now := opt.SandboxRef.Now
+
+	return config.
+		WithRandSource(mathrand.New(mathrand.NewSource(opt.SandboxRef.Seed))).
+		WithWalltime(func() (int64, int32) {
+			return now.Unix(), int32(now.Nanosecond())
+		}, 1).
+		WithNanotime(func() int64 {
+			return now.UnixNano()
+		}, 1)
+}
+
+// chicagoCall holds the data exchanged by the host functions with the module during a single execution.
+type chicagoCall struct {
+	input  []byte
+	output []byte
+	state  *entity.State // nil if the contract is stateless.
+	log    *entity.ContractLogBuffer
+}
+
+// newChicagoCall prepares the data of the execution.
+// If no payload is provided, an empty object is the input, so the contract can safely decode it.
+func newChicagoCall(opt service.ContractCallOpt, contract *entity.Contract) (*chicagoCall, error) {
+
+	input := opt.Input
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.ECHICAGO, "Error while injecting contract input: %s", err.Error())
+	}
+
+	call := &chicagoCall{input: encoded, log: opt.LogRef}
+
+	if contract.Stateful && opt.StateRef != nil && opt.StateRef.Value != nil {
+		call.state = opt.StateRef
+	}
+
+	return call, nil
+}
+
+// instantiateChicagoHost instantiates the WASI and the env host modules bound to the call.
+func instantiateChicagoHost(ctx context.Context, runtime wazero.Runtime, call *chicagoCall) error {
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return apperr.Errorf(apperr.ECHICAGO, "Error while injecting contract host: %s", err.Error())
+	}
+
+	_, err := runtime.NewHostModuleBuilder(chicagoHostModule).
+		NewFunctionBuilder().WithFunc(call.readInput).Export("input").
+		NewFunctionBuilder().WithFunc(call.writeOutput).Export("output").
+		NewFunctionBuilder().WithFunc(call.getState).Export("getState").
+		NewFunctionBuilder().WithFunc(call.setState).Export("setState").
+		NewFunctionBuilder().WithFunc(call.writeLog).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return apperr.Errorf(apperr.ECHICAGO, "Error while injecting contract host: %s", err.Error())
+	}
+
+	return nil
+}
+
+// readInput is the input host function.
+func (c *chicagoCall) readInput(ctx context.Context, m api.Module, ptr, size uint32) uint32 {
+	writeChicagoMemory(m, ptr, size, c.input)
+	return uint32(len(c.input))
+}
+
+// writeOutput is the output host function.
+func (c *chicagoCall) writeOutput(ctx context.Context, m api.Module, ptr, length uint32) {
+	c.output = readChicagoMemory(m, ptr, length)
+}
+
+// getState is the getState host function, the state of a stateless contract has no keys.
+func (c *chicagoCall) getState(ctx context.Context, m api.Module, keyPtr, keyLen, ptr, size uint32) int32 {
+
+	key := string(readChicagoMemory(m, keyPtr, keyLen))
+
+	if c.state == nil {
+		return -1
+	}
+
+	value, ok := c.state.Value[key]
+	if !ok {
+		return -1
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Errorf("getState: %w", err))
+	}
+
+	writeChicagoMemory(m, ptr, size, encoded)
+
+	return int32(len(encoded))
+}
+
+// setState is the setState host function, the state of a stateless contract is discarded.
+func (c *chicagoCall) setState(ctx context.Context, m api.Module, keyPtr, keyLen, valuePtr, valueLen uint32) {
+
+	key := string(readChicagoMemory(m, keyPtr, keyLen))
+	raw := readChicagoMemory(m, valuePtr, valueLen)
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		panic(fmt.Errorf("setState: invalid JSON value: %w", err))
+	}
+
+	if c.state != nil {
+		c.state.Value[key] = value
+	}
+}
+
+// writeLog is the log host function.
+func (c *chicagoCall) writeLog(ctx context.Context, m api.Module, level, ptr, length uint32) {
+
+	message := string(readChicagoMemory(m, ptr, length))
+
+	if c.log == nil {
+		return
+	}
+
+	if int(level) >= len(chicagoLogLevels) {
+		level = 1
+	}
+
+	c.log.Append(chicagoLogLevels[level], message)
+}
+
+// readChicagoMemory returns a copy of length bytes of the module memory at ptr.
+// It panics if the range is out of the memory, the panic traps the module.
+func readChicagoMemory(m api.Module, ptr, length uint32) []byte {
+	view, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		panic(fmt.Errorf("out of bounds memory access"))
+	}
+	return append([]byte(nil), view...)
+}
+
+// writeChicagoMemory writes up to size bytes of data into the module memory at ptr.
+// It panics if the range is out of the memory, the panic traps the module.
+func writeChicagoMemory(m api.Module, ptr, size uint32, data []byte) {
+	if uint32(len(data)) < size {
+		size = uint32(len(data))
+	}
+	if !m.Memory().Write(ptr, data[:size]) {
+		panic(fmt.Errorf("out of bounds memory access"))
+	}
+}
+
+// chicagoLogWriter captures the output written by the module to a WASI stream into the log buffer.
+// Every line is a log entry. If the buffer is nil, the output is discarded.
+type chicagoLogWriter struct {
+	buffer *entity.ContractLogBuffer
+	level  string
+}
+
+// Write appends the lines of p to the log buffer.
+func (w *chicagoLogWriter) Write(p []byte) (int, error) {
+	if w.buffer != nil {
+		for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+			w.buffer.Append(w.level, line)
+		}
+	}
+	return len(p), nil
+}
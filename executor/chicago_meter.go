@@ -0,0 +1,666 @@
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// chicagoReservedPrefix is the prefix of the exports added to the module, the modules cannot export names starting with it.
+const chicagoReservedPrefix = "__mg_"
+
+// chicagoMeterName is the export of the global counting down the work units left to the module.
+const chicagoMeterName = chicagoReservedPrefix + "fuel"
+
+// chicagoStartName is the export of the start function of the module.
+// The start function is not run at instantiation, the meter is set only after it, so the executor calls it.
+const chicagoStartName = chicagoReservedPrefix + "start"
+
+// chicagoPageSize is the size in bytes of a page of WASM memory.
+const chicagoPageSize = 1 << 16
+
+// The ids of the sections of a WASM module.
+const (
+	chicagoSectionCustom   = 0
+	chicagoSectionImport   = 2
+	chicagoSectionMemory   = 5
+	chicagoSectionGlobal   = 6
+	chicagoSectionExport   = 7
+	chicagoSectionStart    = 8
+	chicagoSectionCode     = 10
+	chicagoSectionDataSize = 12
+)
+
+// chicagoSectionOrder is the position of every known section in a module, the custom sections can be anywhere.
+var chicagoSectionOrder = map[byte]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9, chicagoSectionDataSize: 10, chicagoSectionCode: 11, 11: 12}
+
+// errChicagoMemoryLimit is returned when the module needs more memory than the ceiling of the revision.
+var errChicagoMemoryLimit = errors.New("module memory exceeds the memory ceiling of the revision")
+
+// chicagoSection is a section of a WASM module.
+type chicagoSection struct {
+	id      byte
+	content []byte
+}
+
+// meterChicagoModule returns the module rewritten to count the work units it does.
+// A mutable i64 global, exported as __mg_fuel, holds the work units left: every straight sequence of instructions,
+// the body of a function, of a loop, of an if or of an else, subtracts its number of instructions when it is entered
+// and traps with unreachable when the global goes below zero. The global is appended to the ones of the module,
+// so no index of the module is shifted. A branch leaving a sequence early does not give back the instructions skipped.
+// The start section is removed and its function is exported as __mg_start, the caller must run it after setting the meter.
+// The maximum of the memory is lowered to memoryLimitPages, errChicagoMemoryLimit is returned if the minimum is greater.
+// The SIMD and threads instructions are not supported.
+func meterChicagoModule(module []byte, memoryLimitPages uint32) ([]byte, error) {
+
+	if len(module) < 8 || !bytes.Equal(module[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a WebAssembly module")
+	} else if !bytes.Equal(module[4:8], []byte{1, 0, 0, 0}) {
+		return nil, errors.New("unsupported WebAssembly version")
+	}
+
+	var sections []*chicagoSection
+
+	r := &chicagoReader{buf: module, pos: 8}
+	for r.pos < len(r.buf) {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := chicagoSectionOrder[id]; !ok && id != chicagoSectionCustom {
+			return nil, fmt.Errorf("unknown section %d", id)
+		}
+		sections = append(sections, &chicagoSection{id: id, content: content})
+	}
+
+	globals := uint32(0)
+	var global, export, start *chicagoSection
+
+	for _, section := range sections {
+		var err error
+		switch section.id {
+		case chicagoSectionStart:
+			start = section
+		case chicagoSectionImport:
+			globals, err = chicagoImportedGlobals(section.content)
+		case chicagoSectionMemory:
+			section.content, err = limitChicagoMemory(section.content, memoryLimitPages)
+		case chicagoSectionGlobal:
+			global = section
+		case chicagoSectionExport:
+			export = section
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the meter is the global after the imported and the defined ones.
+	meterIndex := globals
+	if global != nil {
+		defined, err := (&chicagoReader{buf: global.content}).u32()
+		if err != nil {
+			return nil, err
+		}
+		meterIndex += defined
+	}
+
+	meterGlobal := []byte{0x7E, 0x01, 0x42, 0x00, 0x0B} // mutable i64 initialized to 0.
+	if global == nil {
+		global = &chicagoSection{id: chicagoSectionGlobal, content: []byte{0}}
+		sections = insertChicagoSection(sections, global)
+	}
+	var err error
+	if global.content, err = appendChicagoVec(global.content, meterGlobal); err != nil {
+		return nil, err
+	}
+
+	if export == nil {
+		export = &chicagoSection{id: chicagoSectionExport, content: []byte{0}}
+		sections = insertChicagoSection(sections, export)
+	} else if err := checkChicagoExports(export.content); err != nil {
+		return nil, err
+	}
+	meterExport := appendChicagoName(nil, chicagoMeterName)
+	meterExport = append(meterExport, 0x03)
+	meterExport = appendChicagoU32(meterExport, meterIndex)
+	if export.content, err = appendChicagoVec(export.content, meterExport); err != nil {
+		return nil, err
+	}
+
+	if start != nil {
+		function, err := (&chicagoReader{buf: start.content}).u32()
+		if err != nil {
+			return nil, err
+		}
+		startExport := appendChicagoName(nil, chicagoStartName)
+		startExport = append(startExport, 0x00)
+		startExport = appendChicagoU32(startExport, function)
+		if export.content, err = appendChicagoVec(export.content, startExport); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, section := range sections {
+		if section.id == chicagoSectionCode {
+			if section.content, err = meterChicagoCode(section.content, meterIndex); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := append([]byte(nil), module[:8]...)
+	for _, section := range sections {
+		if section == start {
+			continue
+		}
+		out = append(out, section.id)
+		out = appendChicagoU32(out, uint32(len(section.content)))
+		out = append(out, section.content...)
+	}
+
+	return out, nil
+}
+
+// insertChicagoSection inserts the section before the first known section that must follow it.
+func insertChicagoSection(sections []*chicagoSection, section *chicagoSection) []*chicagoSection {
+	for i, s := range sections {
+		if s.id != chicagoSectionCustom && chicagoSectionOrder[s.id] > chicagoSectionOrder[section.id] {
+			return append(sections[:i], append([]*chicagoSection{section}, sections[i:]...)...)
+		}
+	}
+	return append(sections, section)
+}
+
+// chicagoImportedGlobals returns the number of globals imported by the import section.
+func chicagoImportedGlobals(content []byte) (uint32, error) {
+
+	r := &chicagoReader{buf: content}
+
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+
+	globals := uint32(0)
+
+	for i := uint32(0); i < n; i++ {
+		if _, err := r.bytes(); err != nil { // module
+			return 0, err
+		}
+		if _, err := r.bytes(); err != nil { // name
+			return 0, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // function
+			_, err = r.u32()
+		case 0x01: // table
+			if _, err = r.byte(); err == nil {
+				_, _, err = r.limits()
+			}
+		case 0x02: // memory
+			_, _, err = r.limits()
+		case 0x03: // global
+			globals++
+			err = r.skip(2)
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return globals, nil
+}
+
+// limitChicagoMemory lowers the maximum of the memories to limit pages.
+// Return errChicagoMemoryLimit if the minimum of a memory is greater than the limit.
+func limitChicagoMemory(content []byte, limit uint32) ([]byte, error) {
+
+	r := &chicagoReader{buf: content}
+
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	out := appendChicagoU32(nil, n)
+
+	for i := uint32(0); i < n; i++ {
+		min, max, err := r.limits()
+		if err != nil {
+			return nil, err
+		}
+		if min > limit {
+			return nil, errChicagoMemoryLimit
+		}
+		if max == nil || *max > limit {
+			max = &limit
+		}
+		out = append(out, 0x01)
+		out = appendChicagoU32(out, min)
+		out = appendChicagoU32(out, *max)
+	}
+
+	return out, nil
+}
+
+// checkChicagoExports returns an error if the module exports a reserved name.
+func checkChicagoExports(content []byte) error {
+
+	r := &chicagoReader{buf: content}
+
+	n, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < n; i++ {
+		name, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(string(name), chicagoReservedPrefix) {
+			return fmt.Errorf("%s is a reserved export", name)
+		}
+		if err := r.skip(1); err != nil {
+			return err
+		}
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// meterChicagoCode rewrites the bodies of the code section to charge their instructions to the meter global.
+func meterChicagoCode(content []byte, meterIndex uint32) ([]byte, error) {
+
+	r := &chicagoReader{buf: content}
+
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	out := appendChicagoU32(nil, n)
+
+	for i := uint32(0); i < n; i++ {
+		body, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		metered, err := meterChicagoBody(body, meterIndex)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = appendChicagoU32(out, uint32(len(metered)))
+		out = append(out, metered...)
+	}
+
+	return out, nil
+}
+
+// chicagoSegment is a straight sequence of instructions, charged where it starts.
+type chicagoSegment struct {
+	start int
+	cost  int64
+}
+
+// meterChicagoBody inserts the charge of every segment of the body at its start.
+func meterChicagoBody(body []byte, meterIndex uint32) ([]byte, error) {
+
+	r := &chicagoReader{buf: body}
+
+	locals, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < locals; i++ {
+		if _, err := r.u32(); err != nil {
+			return nil, err
+		}
+		if err := r.skip(1); err != nil {
+			return nil, err
+		}
+	}
+
+	segments := []*chicagoSegment{{start: r.pos}}
+	// open is the stack of the segments being counted, frames tells for every open block if it opened a segment.
+	open := []*chicagoSegment{segments[0]}
+	frames := []bool{true}
+
+	for len(frames) > 0 {
+
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+
+		open[len(open)-1].cost++
+
+		switch op {
+		case 0x02, 0x03, 0x04: // block, loop, if
+			if err := r.blockType(); err != nil {
+				return nil, err
+			}
+			if op == 0x02 {
+				frames = append(frames, false)
+				continue
+			}
+			segment := &chicagoSegment{start: r.pos}
+			segments = append(segments, segment)
+			open = append(open, segment)
+			frames = append(frames, true)
+		case 0x05: // else
+			segment := &chicagoSegment{start: r.pos}
+			segments = append(segments, segment)
+			open[len(open)-1] = segment
+		case 0x0B: // end
+			if frames[len(frames)-1] {
+				open = open[:len(open)-1]
+			}
+			frames = frames[:len(frames)-1]
+		case 0x23, 0x24: // global.get, global.set
+			index, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			// the original module cannot reach the meter, the index would be out of range before the rewrite.
+			if index >= meterIndex {
+				return nil, fmt.Errorf("global index %d out of range", index)
+			}
+		default:
+			if err := r.immediates(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.pos != len(body) {
+		return nil, errors.New("instructions after the end of the function")
+	}
+
+	out := make([]byte, 0, len(body)+len(segments)*24)
+	last := 0
+	for _, segment := range segments {
+		out = append(out, body[last:segment.start]...)
+		out = appendChicagoCharge(out, meterIndex, segment.cost)
+		last = segment.start
+	}
+	out = append(out, body[last:]...)
+
+	return out, nil
+}
+
+// appendChicagoCharge appends the instructions subtracting cost from the meter, they trap if the meter goes below zero.
+func appendChicagoCharge(out []byte, meterIndex uint32, cost int64) []byte {
+	out = append(out, 0x23) // global.get
+	out = appendChicagoU32(out, meterIndex)
+	out = append(out, 0x42) // i64.const
+	out = appendChicagoS64(out, cost)
+	out = append(out, 0x7D, 0x24) // i64.sub, global.set
+	out = appendChicagoU32(out, meterIndex)
+	out = append(out, 0x23) // global.get
+	out = appendChicagoU32(out, meterIndex)
+	// i64.const 0, i64.lt_s, if, unreachable, end
+	return append(out, 0x42, 0x00, 0x53, 0x04, 0x40, 0x00, 0x0B)
+}
+
+// appendChicagoVec increments the count of the vector and appends the item.
+func appendChicagoVec(vec []byte, item []byte) ([]byte, error) {
+	r := &chicagoReader{buf: vec}
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	out := appendChicagoU32(nil, n+1)
+	out = append(out, vec[r.pos:]...)
+	return append(out, item...), nil
+}
+
+// appendChicagoName appends a name, its length followed by its bytes.
+func appendChicagoName(out []byte, name string) []byte {
+	out = appendChicagoU32(out, uint32(len(name)))
+	return append(out, name...)
+}
+
+// appendChicagoU32 appends the unsigned LEB128 encoding of v.
+func appendChicagoU32(out []byte, v uint32) []byte {
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// appendChicagoS64 appends the signed LEB128 encoding of v.
+func appendChicagoS64(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// errChicagoEOF is returned when a module ends in the middle of a section or of an instruction.
+var errChicagoEOF = errors.New("unexpected end of module")
+
+// chicagoReader decodes the binary format of a WASM module.
+type chicagoReader struct {
+	buf []byte
+	pos int
+}
+
+// byte reads a single byte.
+func (r *chicagoReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errChicagoEOF
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+// skip skips n bytes.
+func (r *chicagoReader) skip(n int) error {
+	if r.pos+n > len(r.buf) {
+		return errChicagoEOF
+	}
+	r.pos += n
+	return nil
+}
+
+// u32 reads an unsigned LEB128 32 bit integer.
+func (r *chicagoReader) u32() (uint32, error) {
+	var v uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("integer too long")
+}
+
+// leb skips a LEB128 integer of at most maxBytes bytes, signed or unsigned.
+func (r *chicagoReader) leb(maxBytes int) error {
+	for i := 0; i < maxBytes; i++ {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+	return errors.New("integer too long")
+}
+
+// bytes reads a vector of bytes, its length followed by its content.
+func (r *chicagoReader) bytes() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	start := r.pos
+	if err := r.skip(int(n)); err != nil {
+		return nil, err
+	}
+	return r.buf[start:r.pos], nil
+}
+
+// limits reads the limits of a memory or of a table, max is nil if it is not declared.
+func (r *chicagoReader) limits() (min uint32, max *uint32, err error) {
+	flags, err := r.byte()
+	if err != nil {
+		return 0, nil, err
+	}
+	if flags > 0x01 {
+		return 0, nil, errors.New("shared memories are not supported")
+	}
+	if min, err = r.u32(); err != nil {
+		return 0, nil, err
+	}
+	if flags == 0x01 {
+		m, err := r.u32()
+		if err != nil {
+			return 0, nil, err
+		}
+		max = &m
+	}
+	return min, max, nil
+}
+
+// blockType skips the type of a block, loop or if: empty, a value type or the index of a function type.
+func (r *chicagoReader) blockType() error {
+	if r.pos >= len(r.buf) {
+		return errChicagoEOF
+	}
+	switch r.buf[r.pos] {
+	case 0x40, 0x7F, 0x7E, 0x7D, 0x7C, 0x7B, 0x70, 0x6F:
+		r.pos++
+		return nil
+	}
+	return r.leb(5)
+}
+
+// immediates skips the immediates of the instruction op.
+// The control instructions opening and closing the blocks and the globals are decoded by the caller.
+func (r *chicagoReader) immediates(op byte) error {
+
+	switch {
+	case op == 0x00, op == 0x01, op == 0x0F, op == 0x1A, op == 0x1B, op == 0xD1:
+		// unreachable, nop, return, drop, select, ref.is_null
+		return nil
+	case op == 0x0C, op == 0x0D, op == 0x10, op == 0xD2:
+		// br, br_if, call, ref.func
+		_, err := r.u32()
+		return err
+	case op == 0x0E: // br_table
+		n, err := r.u32()
+		if err != nil {
+			return err
+		}
+		for i := uint32(0); i <= n; i++ {
+			if _, err := r.u32(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case op == 0x11: // call_indirect
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		_, err := r.u32()
+		return err
+	case op == 0x1C: // select with types, every type is a single byte.
+		_, err := r.bytes()
+		return err
+	case op >= 0x20 && op <= 0x22, op == 0x25, op == 0x26:
+		// local.get, local.set, local.tee, table.get, table.set
+		_, err := r.u32()
+		return err
+	case op >= 0x28 && op <= 0x3E: // loads and stores
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		_, err := r.u32()
+		return err
+	case op == 0x3F, op == 0x40, op == 0xD0:
+		// memory.size, memory.grow, ref.null
+		return r.skip(1)
+	case op == 0x41: // i32.const
+		return r.leb(5)
+	case op == 0x42: // i64.const
+		return r.leb(10)
+	case op == 0x43: // f32.const
+		return r.skip(4)
+	case op == 0x44: // f64.const
+		return r.skip(8)
+	case op >= 0x45 && op <= 0xC4:
+		// the numeric instructions, sign extension included.
+		return nil
+	case op == 0xFC:
+		return r.miscImmediates()
+	}
+
+	return fmt.Errorf("unsupported instruction 0x%02x", op)
+}
+
+// miscImmediates skips the immediates of the instructions prefixed by 0xFC:
+// the saturating truncations, the bulk memory and the table instructions.
+func (r *chicagoReader) miscImmediates() error {
+
+	sub, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case sub <= 7: // trunc_sat
+		return nil
+	case sub == 8: // memory.init
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		return r.skip(1)
+	case sub == 10: // memory.copy
+		return r.skip(2)
+	case sub == 11: // memory.fill
+		return r.skip(1)
+	case sub == 9, sub == 13, sub >= 15 && sub <= 17:
+		// data.drop, elem.drop, table.grow, table.size, table.fill
+		_, err := r.u32()
+		return err
+	case sub == 12, sub == 14: // table.init, table.copy
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+		_, err := r.u32()
+		return err
+	}
+
+	return fmt.Errorf("unsupported instruction 0xfc %d", sub)
+}
@@ -0,0 +1,745 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/executor"
+)
+
+func TestChicagoContractExecutor_CompileContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if err := executor.CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: echoModule(),
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		err := executor.CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: []byte("let result = 1;"),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+
+	t.Run("RunNotExported", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		module := &wasmModule{
+			types:   [][]byte{wasmFuncType(nil, nil)},
+			funcs:   []wasmFunc{{body: []byte{0x0b}}},
+			memory:  1,
+			exports: []wasmExport{{"main", 0x00, 0}, {"memory", 0x02, 0}},
+		}
+
+		err := executor.CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: module.encode(),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+
+	t.Run("ReservedExport", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		module := &wasmModule{
+			types:   [][]byte{wasmFuncType(nil, nil)},
+			funcs:   []wasmFunc{{body: []byte{0x0b}}},
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 0}, {"__mg_fuel", 0x00, 0}, {"memory", 0x02, 0}},
+		}
+
+		err := executor.CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: module.encode(),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+
+	t.Run("UnknownImport", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		module := &wasmModule{
+			types:   [][]byte{wasmFuncType(nil, nil)},
+			imports: []wasmImport{{"env", "fetch", 0}},
+			funcs:   []wasmFunc{{body: []byte{0x0b}}},
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 1}, {"memory", 0x02, 0}},
+		}
+
+		err := executor.CompileContract(context.Background(), &entity.Revision{
+			CompiledCode: module.encode(),
+		})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
+
+func TestChicagoContractExecutor_ExecContract(t *testing.T) {
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			Version:      entity.ChicagoVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: echoModule(),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			Input:       map[string]any{"a": 1, "b": "x"},
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if expected := map[string]any{"a": float64(1), "b": "x"}; !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+
+	t.Run("NoInput", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if expected := map[string]any{}; !reflect.DeepEqual(res, expected) {
+			t.Errorf("Expected %v, got %v", expected, res)
+		}
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		cancel()
+
+		if _, err := executor.ExecContract(ctx, service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		}
+	})
+
+	t.Run("EngineExecutionTimeout", func(t *testing.T) {
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelInstantActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelInstantActionAmount,
+				CompiledCode: loopModule(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		defer func() {
+			if r := recover(); r != service.EngineExecutionTimeoutPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineExecutionTimeoutPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("ErrRuntime", func(t *testing.T) {
+
+		module := &wasmModule{
+			types:   [][]byte{wasmFuncType(nil, nil)},
+			funcs:   []wasmFunc{{body: []byte{0x00, 0x0b}}}, // unreachable
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 0}, {"memory", 0x02, 0}},
+		}
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: module.encode(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ECHICAGO {
+			t.Errorf("Expected error code %s, got %s", apperr.ECHICAGO, errCode)
+		}
+	})
+}
+
+func TestChicagoContractExecutor_Stateful(t *testing.T) {
+
+	// run returns the previous value of the count key and sets it to 42.
+	module := &wasmModule{
+		types: [][]byte{
+			wasmFuncType([]byte{wasmI32, wasmI32, wasmI32, wasmI32}, []byte{wasmI32}),
+			wasmFuncType([]byte{wasmI32, wasmI32}, nil),
+			wasmFuncType([]byte{wasmI32, wasmI32, wasmI32, wasmI32}, nil),
+			wasmFuncType(nil, nil),
+		},
+		imports: []wasmImport{{"env", "getState", 0}, {"env", "output", 1}, {"env", "setState", 2}},
+		funcs: []wasmFunc{{
+			typ:    3,
+			locals: []byte{0x01, 0x01, wasmI32},
+			body: []byte{
+				0x41, 0x00, 0x41, 0x05, 0x41, 0xe4, 0x00, 0x41, 0xc0, 0x00, 0x10, 0x00, 0x21, 0x00, // local0 = getState(0, 5, 100, 64)
+				0x41, 0xe4, 0x00, 0x20, 0x00, 0x10, 0x01, // output(100, local0)
+				0x41, 0x00, 0x41, 0x05, 0x41, 0x10, 0x41, 0x02, 0x10, 0x02, // setState(0, 5, 16, 2)
+				0x0b,
+			},
+		}},
+		memory:  1,
+		exports: []wasmExport{{"run", 0x00, 3}, {"memory", 0x02, 0}},
+		data:    []wasmData{{0, []byte("count")}, {16, []byte("42")}},
+	}
+
+	contract := &entity.Contract{
+		MaxFuel:  entity.FuelLongActionAmount,
+		Stateful: true,
+		LastRevision: &entity.Revision{
+			Version:      entity.ChicagoVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: module.encode(),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		contractState := &entity.State{
+			Value: entity.StateValue{"count": float64(1)},
+		}
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			StateRef:    contractState,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != float64(1) {
+			t.Errorf("Expected 1, got %v", res)
+		} else if value := contractState.Value["count"]; value != float64(42) {
+			t.Errorf("Expected 42, got %v", value)
+		}
+	})
+}
+
+func TestChicagoContractExecutor_Console(t *testing.T) {
+
+	module := &wasmModule{
+		types: [][]byte{
+			wasmFuncType([]byte{wasmI32, wasmI32, wasmI32}, nil),
+			wasmFuncType(nil, nil),
+		},
+		imports: []wasmImport{{"env", "log", 0}},
+		funcs: []wasmFunc{{
+			typ: 1,
+			body: []byte{
+				0x41, 0x01, 0x41, 0x00, 0x41, 0x05, 0x10, 0x00, // log(1, 0, 5)
+				0x41, 0x02, 0x41, 0x05, 0x41, 0x07, 0x10, 0x00, // log(2, 5, 7)
+				0x0b,
+			},
+		}},
+		memory:  1,
+		exports: []wasmExport{{"run", 0x00, 1}, {"memory", 0x02, 0}},
+		data:    []wasmData{{0, []byte("hellowarning")}},
+	}
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			Version:      entity.ChicagoVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: module.encode(),
+		},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		logBuffer := entity.NewContractLogBuffer()
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			LogRef:      logBuffer,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		logs := logBuffer.Logs()
+
+		if len(logs) != 2 {
+			t.Fatalf("Expected 2 logs, got %d", len(logs))
+		}
+
+		if logs[0].Level != entity.LogLevelInfo || logs[0].Message != "hello" {
+			t.Errorf("Unexpected log %v", logs[0])
+		}
+
+		if logs[1].Level != entity.LogLevelWarn || logs[1].Message != "warning" {
+			t.Errorf("Unexpected log %v", logs[1])
+		}
+	})
+}
+
+func TestChicagoContractExecutor_Meter(t *testing.T) {
+
+	t.Run("Deterministic", func(t *testing.T) {
+
+		// run counts down from 100 to 0.
+		module := &wasmModule{
+			types: [][]byte{wasmFuncType(nil, nil)},
+			funcs: []wasmFunc{{
+				locals: []byte{0x01, 0x01, wasmI32},
+				body: []byte{
+					0x41, 0xe4, 0x00, 0x21, 0x00, // local0 = 100
+					0x03, 0x40, // loop
+					0x20, 0x00, 0x41, 0x01, 0x6b, 0x22, 0x00, 0x0d, 0x00, // br_if 0 (local0 = local0 - 1)
+					0x0b,
+					0x0b,
+				},
+			}},
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 0}, {"memory", 0x02, 0}},
+		}
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: module.encode(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		units := make([]uint64, 2)
+
+		for i := range units {
+
+			meter := entity.NewFuelMeter(entity.FuelLongActionAmount, entity.FuelPrices[entity.ChicagoVersion])
+
+			if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+				ContractRef: contract,
+				RevisionRef: contract.LastRevision,
+				MeterRef:    meter,
+			}); err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			}
+
+			units[i] = meter.Units()
+		}
+
+		if units[0] < 100*5 {
+			t.Errorf("Expected at least the instructions of every iteration of the loop, got %d", units[0])
+		} else if units[0] != units[1] {
+			t.Errorf("Expected the same units for every run, got %v", units)
+		}
+	})
+
+	t.Run("FuelExhausted", func(t *testing.T) {
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: loopModule(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		meter := entity.NewFuelMeter(entity.FuelInstantActionAmount, 1)
+
+		defer func() {
+			if r := recover(); r != service.EngineFuelExhaustedPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineFuelExhaustedPanic, r)
+			} else if meter.Used() != entity.FuelInstantActionAmount {
+				t.Errorf("Expected %d fuel used, got %d", entity.FuelInstantActionAmount, meter.Used())
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			MeterRef:    meter,
+		})
+	})
+}
+
+func TestChicagoContractExecutor_Limits(t *testing.T) {
+
+	t.Run("MemoryLimit", func(t *testing.T) {
+
+		module := &wasmModule{
+			types:   [][]byte{wasmFuncType(nil, nil)},
+			funcs:   []wasmFunc{{body: []byte{0x0b}}},
+			memory:  4,
+			exports: []wasmExport{{"run", 0x00, 0}, {"memory", 0x02, 0}},
+		}
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    2 << 16,
+				CompiledCode: module.encode(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if err := executor.CompileContract(context.Background(), contract.LastRevision); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		defer func() {
+			if r := recover(); r != service.EngineMemoryLimitPanic {
+				t.Errorf("Expected %v panic, got %v", service.EngineMemoryLimitPanic, r)
+			}
+		}()
+
+		executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+	})
+
+	t.Run("MemoryGrowFails", func(t *testing.T) {
+
+		// run returns the result of memory.grow by 4 pages, -1 if it fails.
+		module := &wasmModule{
+			types: [][]byte{
+				wasmFuncType([]byte{wasmI32, wasmI32}, nil),
+				wasmFuncType(nil, nil),
+			},
+			imports: []wasmImport{{"env", "output", 0}},
+			funcs: []wasmFunc{{
+				typ: 1,
+				body: []byte{
+					0x41, 0x04, 0x40, 0x00, 0x41, 0x7f, 0x46, // memory.grow(4) == -1
+					0x04, 0x40, // if
+					0x41, 0x00, 0x41, 0x04, 0x10, 0x00, // output(0, 4)
+					0x0b,
+					0x0b,
+				},
+			}},
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 1}, {"memory", 0x02, 0}},
+			data:    []wasmData{{0, []byte("true")}},
+		}
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				MaxMemory:    2 << 16,
+				CompiledCode: module.encode(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		if res, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if res != true {
+			t.Errorf("Expected the grow to fail, got %v", res)
+		}
+	})
+
+	t.Run("ResultTooLarge", func(t *testing.T) {
+
+		result := append(append([]byte(`"`), bytes.Repeat([]byte("x"), 2048)...), '"')
+
+		module := &wasmModule{
+			types: [][]byte{
+				wasmFuncType([]byte{wasmI32, wasmI32}, nil),
+				wasmFuncType(nil, nil),
+			},
+			imports: []wasmImport{{"env", "output", 0}},
+			funcs: []wasmFunc{{
+				typ:  1,
+				body: append(append([]byte{0x41, 0x00, 0x41}, wasmSLEB(int64(len(result)))...), 0x10, 0x00, 0x0b),
+			}},
+			memory:  1,
+			exports: []wasmExport{{"run", 0x00, 1}, {"memory", 0x02, 0}},
+			data:    []wasmData{{0, result}},
+		}
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				Version:      entity.ChicagoVersion,
+				MaxFuel:      entity.FuelLongActionAmount,
+				CompiledCode: module.encode(),
+			},
+		}
+
+		executor := executor.NewChicagoContractExecutor()
+		defer executor.Close()
+
+		defer func(size int) {
+			entity.MaxContractResultSize = size
+		}(entity.MaxContractResultSize)
+
+		entity.MaxContractResultSize = 1024
+
+		if _, err := executor.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_OUTPUT_TOO_LARGE {
+			t.Errorf("Expected %s, got %s", apperr.EMGVM_OUTPUT_TOO_LARGE, errCode)
+		}
+	})
+}
+
+// echoModule returns a module that outputs its input.
+func echoModule() []byte {
+	module := &wasmModule{
+		types: [][]byte{
+			wasmFuncType([]byte{wasmI32, wasmI32}, []byte{wasmI32}),
+			wasmFuncType([]byte{wasmI32, wasmI32}, nil),
+			wasmFuncType(nil, nil),
+		},
+		imports: []wasmImport{{"env", "input", 0}, {"env", "output", 1}},
+		funcs: []wasmFunc{{
+			typ:    2,
+			locals: []byte{0x01, 0x01, wasmI32},
+			body: []byte{
+				0x41, 0x00, 0x41, 0x80, 0x08, 0x10, 0x00, 0x21, 0x00, // local0 = input(0, 1024)
+				0x41, 0x00, 0x20, 0x00, 0x10, 0x01, // output(0, local0)
+				0x0b,
+			},
+		}},
+		memory:  1,
+		exports: []wasmExport{{"run", 0x00, 2}, {"memory", 0x02, 0}},
+	}
+	return module.encode()
+}
+
+// loopModule returns a module that never ends.
+func loopModule() []byte {
+	module := &wasmModule{
+		types:   [][]byte{wasmFuncType(nil, nil)},
+		funcs:   []wasmFunc{{body: []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b}}},
+		memory:  1,
+		exports: []wasmExport{{"run", 0x00, 0}, {"memory", 0x02, 0}},
+	}
+	return module.encode()
+}
+
+const wasmI32 = 0x7f
+
+// wasmModule is a minimal WebAssembly module, it is encoded with the binary format.
+type wasmModule struct {
+	types   [][]byte
+	imports []wasmImport
+	funcs   []wasmFunc
+	memory  uint32 // min pages of the memory.
+	exports []wasmExport
+	data    []wasmData
+}
+
+type wasmImport struct {
+	module, name string
+	typ          uint32
+}
+
+type wasmFunc struct {
+	typ    uint32
+	locals []byte // encoded vector of the local declarations, empty if none.
+	body   []byte
+}
+
+type wasmExport struct {
+	name  string
+	kind  byte
+	index uint32
+}
+
+type wasmData struct {
+	offset int64
+	bytes  []byte
+}
+
+// wasmFuncType encodes a function type.
+func wasmFuncType(params, results []byte) []byte {
+	b := append([]byte{0x60}, wasmULEB(uint64(len(params)))...)
+	b = append(b, params...)
+	b = append(b, wasmULEB(uint64(len(results)))...)
+	return append(b, results...)
+}
+
+// encode returns the binary format of the module.
+func (m *wasmModule) encode() []byte {
+
+	out := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+	out = wasmSection(out, 1, m.types)
+
+	var imports [][]byte
+	for _, imp := range m.imports {
+		b := wasmName(nil, imp.module)
+		b = wasmName(b, imp.name)
+		imports = append(imports, append(append(b, 0x00), wasmULEB(uint64(imp.typ))...))
+	}
+	out = wasmSection(out, 2, imports)
+
+	var funcs [][]byte
+	for _, fn := range m.funcs {
+		funcs = append(funcs, wasmULEB(uint64(fn.typ)))
+	}
+	out = wasmSection(out, 3, funcs)
+
+	out = wasmSection(out, 5, [][]byte{append([]byte{0x00}, wasmULEB(uint64(m.memory))...)})
+
+	var exports [][]byte
+	for _, exp := range m.exports {
+		exports = append(exports, append(append(wasmName(nil, exp.name), exp.kind), wasmULEB(uint64(exp.index))...))
+	}
+	out = wasmSection(out, 7, exports)
+
+	var code [][]byte
+	for _, fn := range m.funcs {
+		locals := fn.locals
+		if len(locals) == 0 {
+			locals = []byte{0x00}
+		}
+		body := append(append([]byte(nil), locals...), fn.body...)
+		code = append(code, append(wasmULEB(uint64(len(body))), body...))
+	}
+	out = wasmSection(out, 10, code)
+
+	var data [][]byte
+	for _, d := range m.data {
+		b := append([]byte{0x00, 0x41}, wasmSLEB(d.offset)...)
+		b = append(b, 0x0b)
+		b = append(b, wasmULEB(uint64(len(d.bytes)))...)
+		data = append(data, append(b, d.bytes...))
+	}
+	out = wasmSection(out, 11, data)
+
+	return out
+}
+
+// wasmSection appends the section with the given entries, nothing if there are no entries.
+func wasmSection(out []byte, id byte, entries [][]byte) []byte {
+	if len(entries) == 0 {
+		return out
+	}
+	content := wasmULEB(uint64(len(entries)))
+	for _, entry := range entries {
+		content = append(content, entry...)
+	}
+	out = append(out, id)
+	out = append(out, wasmULEB(uint64(len(content)))...)
+	return append(out, content...)
+}
+
+func wasmName(out []byte, name string) []byte {
+	return append(append(out, wasmULEB(uint64(len(name)))...), name...)
+}
+
+func wasmULEB(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b = append(b, c|0x80)
+			continue
+		}
+		return append(b, c)
+	}
+}
+
+func wasmSLEB(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
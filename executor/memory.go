@@ -0,0 +1,166 @@
+package executor
+
+import (
+	"reflect"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+const (
+	// memoryCheckInterval is the minimum number of work units run between two memory measures.
+	memoryCheckInterval = 10000
+
+	// memoryValueSize is the estimated size in bytes of a primitive value.
+	memoryValueSize = 16
+	// memoryObjectSize is the estimated size in bytes of an empty object or array.
+	memoryObjectSize = 64
+	// memoryPropertySize is the estimated size in bytes of a property slot, without the key and the value.
+	memoryPropertySize = 32
+)
+
+// memoryGuard enforces the memory ceiling of a contract execution.
+// The engines do not account their allocations, so the guard estimates the memory held by the contract
+// walking the values reachable from its globals and from its state.
+// The data only held by the local variables of a function is not measured until it escapes to them.
+type memoryGuard struct {
+	limit int64
+	state *entity.State
+
+	// measure walks the values of the engine, until the sizer is exceeded.
+	measure func(sizer *memorySizer)
+
+	units     uint64
+	next      uint64
+	measuring bool
+}
+
+// newMemoryGuard creates a memoryGuard for the ceiling of the revision.
+// Return nil if the revision has no ceiling.
+func newMemoryGuard(revision *entity.Revision, state *entity.State, measure func(sizer *memorySizer)) *memoryGuard {
+
+	limit := revision.UnwrapMaxMemory()
+	if limit <= 0 {
+		return nil
+	}
+
+	return &memoryGuard{
+		limit:   limit,
+		state:   state,
+		measure: measure,
+		next:    memoryCheckInterval,
+	}
+}
+
+// Consume counts the work units run by the contract and measures the memory when the check is due.
+// A nil guard never exceeds.
+// Return false if the memory ceiling is exceeded.
+func (g *memoryGuard) Consume(units uint64) bool {
+
+	if g == nil || g.measuring {
+		return true
+	}
+
+	g.units += units
+	if g.units < g.next {
+		return true
+	}
+
+	return g.Check()
+}
+
+// Check measures the memory held by the contract.
+// The next check is delayed by the number of values walked, so the cost of the walks stays proportional to the work of the contract.
+// A nil guard never exceeds.
+// Return false if the memory ceiling is exceeded.
+func (g *memoryGuard) Check() bool {
+
+	if g == nil || g.measuring {
+		return true
+	}
+
+	g.measuring = true
+	defer func() { g.measuring = false }()
+
+	sizer := &memorySizer{limit: g.limit}
+
+	if g.state != nil {
+		sizer.goValue(reflect.ValueOf(g.state.Value))
+	}
+
+	if !sizer.Exceeded() {
+		g.measure(sizer)
+	}
+
+	interval := uint64(memoryCheckInterval)
+	if uint64(sizer.visited) > interval {
+		interval = uint64(sizer.visited)
+	}
+	g.next = g.units + interval
+
+	return !sizer.Exceeded()
+}
+
+// memorySizer sums the estimated sizes of the values walked by a memory measure.
+type memorySizer struct {
+	limit   int64
+	size    int64
+	visited int
+}
+
+// Add adds the size of a value.
+// Return false if the limit is exceeded, so the walk can stop early.
+func (s *memorySizer) Add(size int64) bool {
+	s.size += size
+	s.visited++
+	return !s.Exceeded()
+}
+
+// Exceeded reports whether the size is over the limit.
+func (s *memorySizer) Exceeded() bool {
+	return s.size > s.limit
+}
+
+// goValue adds the size of a value exported from the engines, like the values of the state.
+func (s *memorySizer) goValue(v reflect.Value) bool {
+
+	if !v.IsValid() {
+		return s.Add(memoryValueSize)
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return s.Add(memoryValueSize)
+		}
+		return s.goValue(v.Elem())
+	case reflect.String:
+		return s.Add(memoryValueSize + int64(v.Len()))
+	case reflect.Slice, reflect.Array:
+		if !s.Add(memoryObjectSize) {
+			return false
+		}
+		if k := v.Type().Elem().Kind(); k != reflect.Interface && k != reflect.Map && k != reflect.Slice && k != reflect.String && k != reflect.Pointer {
+			// a typed array, like the bytes of an ArrayBuffer.
+			return s.Add(int64(v.Len()) * int64(v.Type().Elem().Size()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !s.goValue(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if !s.Add(memoryObjectSize) {
+			return false
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if !s.Add(memoryPropertySize) || !s.goValue(iter.Key()) || !s.goValue(iter.Value()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return s.Add(memoryValueSize)
+}
@@ -0,0 +1,190 @@
+package executor_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/executor"
+	"github.com/robertkrimen/otto"
+)
+
+func TestScriptCache_Script(t *testing.T) {
+
+	t.Run("Hit", func(t *testing.T) {
+
+		cache := executor.NewScriptCache(1 << 20)
+
+		revision := &entity.Revision{ID: 1, CompiledCode: []byte("var result = 1;")}
+
+		script, err := cache.Script(otto.New(), revision)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if cached, err := cache.Script(otto.New(), revision); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if cached != script {
+			t.Errorf("Expected the cached script")
+		} else if cache.Len() != 1 {
+			t.Errorf("Expected 1 cached script, got %d", cache.Len())
+		}
+	})
+
+	t.Run("CodeChanged", func(t *testing.T) {
+
+		cache := executor.NewScriptCache(1 << 20)
+
+		script, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: []byte("var result = 1;")})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if other, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: []byte("var result = 2;")}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if other == script {
+			t.Errorf("Expected a new script for the changed code")
+		}
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+
+		code := []byte("var result = 1;")
+
+		// room for two scripts only.
+		cache := executor.NewScriptCache(len(code) * 12 * 2)
+
+		first, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: code})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		for id := int64(2); id <= 3; id++ {
+			if _, err := cache.Script(otto.New(), &entity.Revision{ID: id, CompiledCode: code}); err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			}
+		}
+
+		if cache.Len() != 2 {
+			t.Fatalf("Expected 2 cached scripts, got %d", cache.Len())
+		} else if cache.Size() > len(code)*12*2 {
+			t.Fatalf("Expected size at most %d, got %d", len(code)*12*2, cache.Size())
+		}
+
+		if again, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: code}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if again == first {
+			t.Errorf("Expected the least recently used script to be evicted")
+		}
+	})
+
+	t.Run("TooBig", func(t *testing.T) {
+
+		cache := executor.NewScriptCache(10)
+
+		if _, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: []byte("var result = 1;")}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if cache.Len() != 0 {
+			t.Errorf("Expected no cached script, got %d", cache.Len())
+		}
+	})
+
+	t.Run("SyntaxError", func(t *testing.T) {
+
+		cache := executor.NewScriptCache(1 << 20)
+
+		if _, err := cache.Script(otto.New(), &entity.Revision{ID: 1, CompiledCode: []byte("var result = ;")}); err == nil {
+			t.Errorf("Expected error, got nil")
+		} else if cache.Len() != 0 {
+			t.Errorf("Expected no cached script, got %d", cache.Len())
+		}
+	})
+}
+
+func TestAnchorageContractExecutor_ScriptCache(t *testing.T) {
+
+	ex := executor.NewAnchorageContractExecutor()
+	ex.ScriptCache = executor.NewScriptCache(1 << 20)
+
+	contract := &entity.Contract{
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			ID:           1,
+			CompiledCode: []byte("var result = input.a * 2;"),
+		},
+	}
+
+	// the cached script must not keep the input of the previous calls.
+	for i := 1; i <= 3; i++ {
+		if res, err := ex.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			Input:       map[string]any{"a": i},
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		} else if res.(float64) != float64(i*2) {
+			t.Errorf("Expected %d, got %v", i*2, res)
+		}
+	}
+
+	if ex.ScriptCache.Len() != 1 {
+		t.Errorf("Expected 1 cached script, got %d", ex.ScriptCache.Len())
+	}
+}
+
+// BenchmarkAnchorageContractExecutor_ExecContract compares the cold calls, that parse the code every time,
+// with the warm calls, that run the script compiled by the first call.
+func BenchmarkAnchorageContractExecutor_ExecContract(b *testing.B) {
+
+	examples, err := filepath.Glob("../tests/examples/*.js")
+	if err != nil {
+		b.Fatal(err)
+	} else if len(examples) == 0 {
+		b.Fatal("no examples found")
+	}
+
+	for _, example := range examples {
+
+		code, err := os.ReadFile(example)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(example), ".js")
+
+		contract := &entity.Contract{
+			MaxFuel:  entity.FuelLongActionAmount,
+			Stateful: strings.HasPrefix(name, "stateful"),
+			LastRevision: &entity.Revision{
+				ID:           1,
+				CompiledCode: code,
+			},
+		}
+
+		for _, warm := range []bool{false, true} {
+
+			ex := executor.NewAnchorageContractExecutor()
+			if warm {
+				ex.ScriptCache = executor.NewScriptCache(1 << 20)
+			}
+
+			b.Run(fmt.Sprintf("%s/warm=%t", name, warm), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := ex.ExecContract(context.Background(), service.ContractCallOpt{
+						ContractRef: contract,
+						RevisionRef: contract.LastRevision,
+						StateRef:    &entity.State{Value: entity.StateValue{}},
+					}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -3,20 +3,26 @@ module github.com/music-gang/music-gang-api
 go 1.19
 
 require (
+	github.com/dop251/goja v0.0.0-20230806174421-c933cf95e127
 	github.com/go-redsync/redsync/v4 v4.5.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/tetratelabs/wazero v1.5.0
+	golang.org/x/time v0.0.0-20201208040808-7e3f01d25324
 	gopkg.in/guregu/null.v4 v4.0.0
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/dlclark/regexp2 v1.7.0 // indirect
+	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
 	github.com/hashicorp/errwrap v1.0.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.0 // indirect
-	golang.org/x/time v0.0.0-20201208040808-7e3f01d25324 // indirect
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/protobuf v1.26.0 // indirect
 	gopkg.in/sourcemap.v1 v1.0.5 // indirect
@@ -2,44 +2,339 @@ package handler
 
 import (
 	"context"
+	"math/rand"
+	"sync"
+	"time"
 
+	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 )
 
+// CallContractParams represents the parameters for a contract call.
+type CallContractParams struct {
+	// ContractID is the id of the contract to call.
+	ContractID int64
+	// Rev is the revision number to call, if 0 the active revision is called.
+	Rev entity.RevisionNumber
+	// Alias is the name of the alias to call, it takes precedence over Rev.
+	Alias string
+	// Input is the payload passed to the contract, can be nil.
+	Input any
+	// Debug returns the console output of the contract together with the result.
+	Debug bool
+	// DryRun runs the contract without storing the state it leaves, only the owner of the contract can dry run it.
+	DryRun bool
+}
+
+// CallContractResult represents the outcome of a contract call.
+type CallContractResult struct {
+	// Result is the JSON value assigned by the contract to result.
+	Result any
+	// Rev is the revision number that ran the call.
+	Rev entity.RevisionNumber
+	// Logs is the console output of the contract, it is filled only in debug mode.
+	Logs entity.ContractLogs
+	// DryRun holds the state changes and the fuel of the call, it is filled only in dry run mode.
+	DryRun *entity.DryRun
+}
+
 // CallContract handles the contract execution business logic.
-func (s *ServiceHandler) CallContract(ctx context.Context, contractID int64, revisionNumber entity.RevisionNumber) (res any, err error) {
+// If no user is in context the call is anonymous, only public and stateless contracts can be called anonymously.
+// In dry run mode the state left by the contract is not stored, the call still consumes fuel.
+// The console output of the contract is always stored, also when the execution fails.
+func (s *ServiceHandler) CallContract(ctx context.Context, params CallContractParams) (*CallContractResult, error) {
 
-	var revision *entity.Revision
+	rev := params.Rev
 
-	if revisionNumber != 0 {
-		revision, err = s.ContractSearchService.FindRevisionByContractAndRev(ctx, contractID, revisionNumber)
+	if params.Alias != "" {
+		// the access is checked before the alias is resolved, so the aliases of the private contracts cannot be probed.
+		if _, err := s.FindContractByID(ctx, params.ContractID); err != nil {
+			return nil, err
+		}
+		r, err := s.resolveContractAlias(ctx, params.ContractID, params.Alias)
 		if err != nil {
 			s.Logger.Error(apperr.ErrorLog(err))
 			return nil, err
 		}
-	} else {
+		rev = r
+	}
 
-		contract, err := s.ContractSearchService.FindContractByID(ctx, contractID)
-		if err != nil {
+	revision, err := s.findAccessibleRevision(ctx, params.ContractID, rev)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	// the state is scoped by user, so there is no state for the anonymous callers.
+	if revision.Contract.Stateful && app.UserFromContext(ctx) == nil {
+		err := apperr.Errorf(apperr.EFORBIDDEN, "stateful contracts cannot be called anonymously")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	var dryRun *entity.DryRun
+	if params.DryRun {
+		if userID := app.UserIDFromContext(ctx); userID == 0 || revision.Contract.UserID != userID {
+			err := apperr.Errorf(apperr.EUNAUTHORIZED, "only the owner of the contract can dry run it")
 			s.Logger.Error(apperr.ErrorLog(err))
 			return nil, err
 		}
-
-		revision = contract.LastRevision
+		dryRun = &entity.DryRun{}
 	}
 
+	logBuffer := entity.NewContractLogBuffer()
+
 	result, err := s.VmCallableService.ExecContract(ctx, service.ContractCallOpt{
 		ContractRef: revision.Contract,
 		RevisionRef: revision,
+		Input:       params.Input,
+		LogRef:      logBuffer,
+		AliasRef:    params.Alias,
+		DryRunRef:   dryRun,
 	})
+
+	logs := s.storeContractLogs(ctx, revision, logBuffer)
+
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	res := &CallContractResult{
+		Result: result,
+		Rev:    revision.Rev,
+		DryRun: dryRun,
+	}
+
+	if params.Debug {
+		res.Logs = logs
+	}
+
+	return res, nil
+}
+
+// ContractAliases handles the contract aliases search business logic.
+// The aliases are readable by everyone that can access the contract.
+func (s *ServiceHandler) ContractAliases(ctx context.Context, contractID int64) (entity.ContractAliases, error) {
+
+	if s.ContractAliasService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "contract aliases are not enabled")
+	}
+
+	contract, err := s.FindContractByID(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := s.ContractAliasService.FindContractAliases(ctx, contract.ID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// ContractLogs handles the contract logs search business logic.
+// Only the owner of the contract can read its logs.
+func (s *ServiceHandler) ContractLogs(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+
+	contract, err := s.ContractSearchService.FindContractByID(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if contract.UserID != app.UserIDFromContext(ctx) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if s.ContractLogService == nil {
+		return make(entity.ContractLogs, 0), nil
+	}
+
+	logs, err := s.ContractLogService.FindLogsByContractID(ctx, contractID)
 	if err != nil {
 		s.Logger.Error(apperr.ErrorLog(err))
 		return nil, err
 	}
 
-	return result, nil
+	return logs, nil
+}
+
+// ContractExecutions handles the contract executions search business logic.
+// Only the owner of the contract can read its executions, the contract filter is always forced to the given contract.
+func (s *ServiceHandler) ContractExecutions(ctx context.Context, contractID int64, filter service.ExecutionFilter) (entity.Executions, int, error) {
+
+	contract, err := s.ContractSearchService.FindContractByID(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, 0, err
+	}
+
+	if contract.UserID != app.UserIDFromContext(ctx) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, 0, err
+	}
+
+	if s.ExecutionSearchService == nil {
+		return make(entity.Executions, 0), 0, nil
+	}
+
+	filter.ContractID = &contractID
+
+	executions, n, err := s.ExecutionSearchService.FindExecutions(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, 0, err
+	}
+
+	return executions, n, nil
+}
+
+// ReplayExecution handles the replay of a recorded execution business logic.
+// Only the owner of the contract can replay its executions.
+// If rev is 0 the execution is replayed on the revision that ran it.
+// Return ENOTIMPLEMENTED if the executions are not recorded.
+func (s *ServiceHandler) ReplayExecution(ctx context.Context, contractID, executionID int64, rev entity.RevisionNumber) (*entity.ExecutionReplay, error) {
+
+	contract, err := s.ContractSearchService.FindContractByID(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if contract.UserID != app.UserIDFromContext(ctx) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if s.ExecutionSearchService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "executions are not recorded")
+	}
+
+	execution, err := s.ExecutionSearchService.FindExecutionByID(ctx, executionID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	// the executions of the other contracts are not disclosed.
+	if execution.ContractID != contract.ID {
+		err := apperr.Errorf(apperr.ENOTFOUND, "execution not found")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if rev == 0 {
+		rev = execution.Rev
+	}
+
+	revision, err := s.findAccessibleRevision(ctx, contract.ID, rev)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	replay, err := s.VmCallableService.ReplayExecution(ctx, execution, revision)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// ContractFuelUsage returns the fuel consumed by the contract, aggregated as specified by the filter.
+// Only the owner of the contract can read its fuel usage, the contract filter is always forced to the given contract.
+// Return ENOTIMPLEMENTED if the fuel ledger is not enabled.
+func (s *ServiceHandler) ContractFuelUsage(ctx context.Context, contractID int64, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+
+	contract, err := s.ContractSearchService.FindContractByID(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if contract.UserID != app.UserIDFromContext(ctx) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if s.FuelLedgerSearchService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "fuel ledger is not enabled")
+	}
+
+	filter.ContractID = &contractID
+
+	usages, err := s.FuelLedgerSearchService.FindFuelUsages(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return usages, nil
+}
+
+// ContractRevision handles the revision search business logic.
+// If rev is 0 the active revision of the contract is returned.
+// The revisions of private contracts can be read only by their owner.
+func (s *ServiceHandler) ContractRevision(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+
+	revision, err := s.findAccessibleRevision(ctx, contractID, rev)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// ContractRevisionCode handles the download of the code of a revision.
+// If rev is 0 the code of the active revision of the contract is returned.
+// Only the owner of the contract can download its code.
+// The revision is returned with its code, its version tells how the code is written.
+func (s *ServiceHandler) ContractRevisionCode(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+
+	revision, err := s.findAccessibleRevision(ctx, contractID, rev)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if revision.Contract.UserID != app.UserIDFromContext(ctx) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// ContractRevisions handles the revisions search business logic.
+// The revisions of private contracts can be read only by their owner, the contract filter is always forced to the given contract.
+func (s *ServiceHandler) ContractRevisions(ctx context.Context, contractID int64, filter service.RevisionFilter) (entity.Revisions, int, error) {
+
+	if _, err := s.FindContractByID(ctx, contractID); err != nil {
+		return nil, 0, err
+	}
+
+	filter.ContractID = contractID
+
+	revisions, n, err := s.ContractSearchService.FindRevisions(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, 0, err
+	}
+
+	return revisions, n, nil
 }
 
 // CreateContract handles the contract create business logic.
@@ -51,16 +346,62 @@ func (s *ServiceHandler) CreateContract(ctx context.Context, contract *entity.Co
 	return contract, nil
 }
 
+// DeleteContractAlias handles the contract alias delete business logic.
+func (s *ServiceHandler) DeleteContractAlias(ctx context.Context, contractID int64, name string) error {
+
+	if s.ContractAliasService == nil {
+		return apperr.Errorf(apperr.ENOTIMPLEMENTED, "contract aliases are not enabled")
+	}
+
+	if err := s.VmCallableService.DeleteContractAlias(ctx, contractID, name); err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteContract handles the contract delete business logic.
+// The contract is soft deleted, it can be restored until it is purged.
+func (s *ServiceHandler) DeleteContract(ctx context.Context, contractID int64) error {
+	if err := s.VmCallableService.DeleteContract(ctx, contractID); err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return err
+	}
+	return nil
+}
+
 // FindContractByID handles the contract search business logic.
+// Private contracts can be read only by their owner.
 func (s *ServiceHandler) FindContractByID(ctx context.Context, contractID int64) (res *entity.Contract, err error) {
 	if contract, err := s.ContractSearchService.FindContractByID(ctx, contractID); err != nil {
 		s.Logger.Error(apperr.ErrorLog(err))
 		return nil, err
+	} else if !contract.IsAccessibleBy(app.UserIDFromContext(ctx)) {
+		err := apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not accessible by the authenticated user")
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
 	} else {
 		return contract, nil
 	}
 }
 
+// FindContracts handles the contracts search business logic.
+// The private contracts of other users are always hidden, whatever filter is passed.
+func (s *ServiceHandler) FindContracts(ctx context.Context, filter service.ContractFilter) (entity.Contracts, int, error) {
+
+	userID := app.UserIDFromContext(ctx)
+	filter.VisibleTo = &userID
+
+	contracts, n, err := s.ContractSearchService.FindContracts(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, 0, err
+	}
+
+	return contracts, n, nil
+}
+
 // MakeContractRevision handles the creation of new revision of a contract business logic.
 func (s *ServiceHandler) MakeContractRevision(ctx context.Context, revision *entity.Revision) (*entity.Revision, error) {
 	if err := s.VmCallableService.MakeRevision(ctx, revision); err != nil {
@@ -70,6 +411,91 @@ func (s *ServiceHandler) MakeContractRevision(ctx context.Context, revision *ent
 	return revision, nil
 }
 
+// PurgeContracts handles the purge of the contracts deleted before the retention period.
+// Return ENOTIMPLEMENTED if the purge is not enabled.
+func (s *ServiceHandler) PurgeContracts(ctx context.Context, retention time.Duration) (int, error) {
+
+	if s.ContractPurgeService == nil {
+		return 0, apperr.Errorf(apperr.ENOTIMPLEMENTED, "contract purge is not enabled")
+	}
+
+	n, err := s.ContractPurgeService.PurgeContracts(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// PurgeExecutionSnapshots handles the purge of the recorded calls of the executions started before the retention period.
+// Return ENOTIMPLEMENTED if the purge is not enabled.
+func (s *ServiceHandler) PurgeExecutionSnapshots(ctx context.Context, retention time.Duration) (int, error) {
+
+	if s.ExecutionSnapshotPurgeService == nil {
+		return 0, apperr.Errorf(apperr.ENOTIMPLEMENTED, "execution snapshot purge is not enabled")
+	}
+
+	n, err := s.ExecutionSnapshotPurgeService.PurgeExecutionSnapshots(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// RollbackActiveRevision handles the rollback of the active revision of a contract.
+func (s *ServiceHandler) RollbackActiveRevision(ctx context.Context, contractID int64) (*entity.Contract, error) {
+
+	contract, err := s.VmCallableService.RollbackActiveRevision(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// RestoreContract handles the contract restore business logic.
+func (s *ServiceHandler) RestoreContract(ctx context.Context, contractID int64) (*entity.Contract, error) {
+
+	contract, err := s.VmCallableService.RestoreContract(ctx, contractID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// SetContractAlias handles the creation or the move of a contract alias.
+func (s *ServiceHandler) SetContractAlias(ctx context.Context, alias *entity.ContractAlias) (*entity.ContractAlias, error) {
+
+	if s.ContractAliasService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "contract aliases are not enabled")
+	}
+
+	if err := s.VmCallableService.SetContractAlias(ctx, alias); err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return alias, nil
+}
+
+// SetActiveRevision handles the pin of the active revision of a contract, rev 0 unpins it.
+func (s *ServiceHandler) SetActiveRevision(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+
+	contract, err := s.VmCallableService.SetActiveRevision(ctx, contractID, rev)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return contract, nil
+}
+
 // UpdateContract handles the contract update business logic.
 func (s *ServiceHandler) UpdateContract(ctx context.Context, contractID int64, params service.ContractUpdate) (*entity.Contract, error) {
 
@@ -81,3 +507,97 @@ func (s *ServiceHandler) UpdateContract(ctx context.Context, contractID int64, p
 
 	return contract, nil
 }
+
+// findAccessibleRevision returns the revision to read or call with its contract attached.
+// If rev is 0 the active revision of the contract is returned.
+// Return ENOTFOUND if the contract has no revision.
+// Return EUNAUTHORIZED if the contract is private and not owned by the authenticated user.
+func (s *ServiceHandler) findAccessibleRevision(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+
+	var contract *entity.Contract
+	var revision *entity.Revision
+
+	if rev != 0 {
+		r, err := s.ContractSearchService.FindRevisionByContractAndRev(ctx, contractID, rev)
+		if err != nil {
+			return nil, err
+		}
+		revision, contract = r, r.Contract
+	}
+
+	if contract == nil {
+		c, err := s.ContractSearchService.FindContractByID(ctx, contractID)
+		if err != nil {
+			return nil, err
+		}
+		contract = c
+	}
+
+	if !contract.IsAccessibleBy(app.UserIDFromContext(ctx)) {
+		return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not accessible by the authenticated user")
+	}
+
+	if revision == nil {
+		r, err := contract.UnwrapActiveRevision()
+		if err != nil {
+			return nil, err
+		}
+		revision = r
+	}
+
+	// the active revision of the contract is loaded without its contract, the vm needs both.
+	revision.Contract = contract
+
+	return revision, nil
+}
+
+// resolveContractAlias returns the revision number that runs the call made through the alias.
+// The alias is resolved on every call, so moving it or changing its split weight applies to the next calls.
+// Return ENOTIMPLEMENTED if the aliases are not enabled.
+func (s *ServiceHandler) resolveContractAlias(ctx context.Context, contractID int64, name string) (entity.RevisionNumber, error) {
+
+	if s.ContractAliasService == nil {
+		return 0, apperr.Errorf(apperr.ENOTIMPLEMENTED, "contract aliases are not enabled")
+	}
+
+	alias, err := s.ContractAliasService.FindContractAliasByName(ctx, contractID, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return alias.Resolve(rollContractAlias()), nil
+}
+
+// storeContractLogs attaches the call references to the captured logs and stores them.
+// A failure while storing the logs is only logged, it never fails the contract call.
+func (s *ServiceHandler) storeContractLogs(ctx context.Context, revision *entity.Revision, logBuffer *entity.ContractLogBuffer) entity.ContractLogs {
+
+	logs := logBuffer.Logs()
+
+	for _, l := range logs {
+		l.ContractID = revision.ContractID
+		l.RevisionID = revision.ID
+		l.UserID = app.UserIDFromContext(ctx)
+	}
+
+	if s.ContractLogService != nil && len(logs) > 0 {
+		if err := s.ContractLogService.AppendLogs(ctx, revision.ContractID, logs); err != nil {
+			s.Logger.Error(apperr.ErrorLog(err))
+		}
+	}
+
+	return logs
+}
+
+// aliasRand picks the revision of the aliases that split the calls.
+var aliasRand = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// rollContractAlias returns a random number in [0, ContractAliasMaxSplitWeight).
+func rollContractAlias() int {
+	aliasRand.Lock()
+	defer aliasRand.Unlock()
+	return aliasRand.Intn(entity.ContractAliasMaxSplitWeight)
+}
@@ -12,6 +12,34 @@ type ServiceHandler struct {
 	VmCallableService     service.VmCallableService
 	JWTService            service.JWTService
 
+	// ContractLogService stores the console output of the contracts.
+	// Can be nil if the logs are not stored.
+	ContractLogService service.ContractLogService
+
+	// ExecutionSearchService searches the execution records of the contracts.
+	// Can be nil if the executions are not recorded.
+	ExecutionSearchService service.ExecutionSearchService
+
+	// FuelWalletService reports the fuel budget of the users.
+	// Can be nil if the fuel wallets are not enabled.
+	FuelWalletService service.FuelWalletService
+
+	// FuelLedgerSearchService aggregates the fuel burned and refunded by the operations.
+	// Can be nil if the fuel ledger is not enabled.
+	FuelLedgerSearchService service.FuelLedgerSearchService
+
+	// ContractPurgeService deletes for good the contracts deleted before the retention period.
+	// Can be nil if the deleted contracts are never purged.
+	ContractPurgeService service.ContractPurgeService
+
+	// ExecutionSnapshotPurgeService deletes the recorded calls of the executions started before the retention period.
+	// Can be nil if the recorded calls are never purged.
+	ExecutionSnapshotPurgeService service.ExecutionSnapshotPurgeService
+
+	// ContractAliasService manages the named aliases of the contract revisions.
+	// Can be nil if the aliases are not enabled.
+	ContractAliasService service.ContractAliasService
+
 	Logger log.Logger
 }
 
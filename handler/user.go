@@ -20,6 +20,55 @@ func (s *ServiceHandler) CurrentAuthUser(ctx context.Context) (*entity.User, err
 	return user, nil
 }
 
+// UserFuel returns the fuel wallet of the current authenticated user.
+// Return ENOTIMPLEMENTED if the fuel wallets are not enabled.
+func (s *ServiceHandler) UserFuel(ctx context.Context) (*entity.FuelWallet, error) {
+
+	user, err := app.AuthUser(ctx)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if s.FuelWalletService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "fuel wallets are not enabled")
+	}
+
+	wallet, err := s.FuelWalletService.FuelWallet(ctx, user.ID)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// UserFuelUsage returns the fuel consumed by the current authenticated user, aggregated as specified by the filter.
+// The user filter is always forced to the authenticated user.
+// Return ENOTIMPLEMENTED if the fuel ledger is not enabled.
+func (s *ServiceHandler) UserFuelUsage(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+
+	user, err := app.AuthUser(ctx)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	if s.FuelLedgerSearchService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "fuel ledger is not enabled")
+	}
+
+	filter.UserID = &user.ID
+
+	usages, err := s.FuelLedgerSearchService.FindFuelUsages(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return usages, nil
+}
+
 // UpdateUser Updates the user with the given ID.
 func (s *ServiceHandler) UpdateUser(ctx context.Context, userID int64, userParams service.UserUpdate) (*entity.User, error) {
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
 )
 
 // StatsVM returns the VM stats.
@@ -18,3 +19,21 @@ func (s *ServiceHandler) StatsVM(ctx context.Context) (*entity.FuelStat, error)
 
 	return stats, nil
 }
+
+// FuelUsageVM returns the fuel consumed on the whole VM, aggregated as specified by the filter.
+// It is reserved to the operators, the authorization is up to the caller.
+// Return ENOTIMPLEMENTED if the fuel ledger is not enabled.
+func (s *ServiceHandler) FuelUsageVM(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+
+	if s.FuelLedgerSearchService == nil {
+		return nil, apperr.Errorf(apperr.ENOTIMPLEMENTED, "fuel ledger is not enabled")
+	}
+
+	usages, err := s.FuelLedgerSearchService.FindFuelUsages(ctx, filter)
+	if err != nil {
+		s.Logger.Error(apperr.ErrorLog(err))
+		return nil, err
+	}
+
+	return usages, nil
+}
@@ -3,14 +3,19 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/handler"
 )
 
 // ContractCallHandler is the handler for the /contract/:id/call API.
@@ -21,17 +26,235 @@ func (s *ServerAPI) ContractCallHandler(c echo.Context) error {
 		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
 	}
 
-	if res, err := s.ServiceHandler.CallContract(c.Request().Context(), contractID, 0); err != nil {
+	debug, _ := strconv.ParseBool(c.QueryParam("debug"))
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	return s.callContract(c, handler.CallContractParams{ContractID: contractID, Debug: debug, DryRun: dryRun})
+}
+
+// ContractCallRevHandler is the handler for the /contract/:id/call/:rev API.
+// The rev param is a revision number or an alias prefixed by @, like @canary.
+func (s *ServerAPI) ContractCallRevHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	rev, alias, err := bindCallTarget(c)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	debug, _ := strconv.ParseBool(c.QueryParam("debug"))
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	return s.callContract(c, handler.CallContractParams{ContractID: contractID, Rev: rev, Alias: alias, Debug: debug, DryRun: dryRun})
+}
+
+// PublicContractCallHandler is the handler for the /public/contract/:id/call API.
+// The call is anonymous, so the console output of the contract is never returned.
+func (s *ServerAPI) PublicContractCallHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	return s.callContract(c, handler.CallContractParams{ContractID: contractID})
+}
+
+// PublicContractCallRevHandler is the handler for the /public/contract/:id/call/:rev API.
+// The rev param is a revision number or an alias prefixed by @, like @canary.
+// The call is anonymous, so the console output of the contract is never returned.
+func (s *ServerAPI) PublicContractCallRevHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	rev, alias, err := bindCallTarget(c)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	return s.callContract(c, handler.CallContractParams{ContractID: contractID, Rev: rev, Alias: alias})
+}
+
+// ContractDeleteHandler is the handler for the /contract/:id delete API.
+func (s *ServerAPI) ContractDeleteHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if err := s.ServiceHandler.DeleteContract(c.Request().Context(), contractID); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	return SuccessResponseJSON(c, http.StatusOK, nil)
+}
+
+// ContractHandler is the handler for the /contract/:id search API.
+func (s *ServerAPI) ContractHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if contract, err := s.ServiceHandler.FindContractByID(c.Request().Context(), contractID); err != nil {
 		return ErrorResponseJSON(c, err, nil)
 	} else {
 		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
-			"result": res,
+			"contract": contract,
 		})
 	}
 }
 
-// ContractCallRevHandler is the handler for the /contract/:id/call/:rev API.
-func (s *ServerAPI) ContractCallRevHandler(c echo.Context) error {
+// ContractsHandler is the handler for the /contract search API.
+// Supported query params are limit, offset, name, description, user_id, order_by (id, created_at or updated_at) and order (asc or desc).
+func (s *ServerAPI) ContractsHandler(c echo.Context) error {
+
+	filter, err := bindContractFilter(c)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	if contracts, n, err := s.ServiceHandler.FindContracts(c.Request().Context(), filter); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"contracts": contracts,
+			"total":     n,
+		})
+	}
+}
+
+// ContractExecutionsHandler is the handler for the /contract/:id/executions API.
+// Supported query params are limit, offset, outcome, revision_id, user_id, started_after and started_before (RFC3339).
+func (s *ServerAPI) ContractExecutionsHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	filter, err := bindExecutionFilter(c)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	if executions, n, err := s.ServiceHandler.ContractExecutions(c.Request().Context(), contractID, filter); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"executions": executions,
+			"total":      n,
+		})
+	}
+}
+
+// ContractExecutionReplayHandler is the handler for the /contract/:id/executions/:execID/replay API.
+// The optional rev query param is the revision number that runs the replay, by default the revision that ran the execution.
+func (s *ServerAPI) ContractExecutionReplayHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	executionID, err := strconv.ParseInt(c.Param("execID"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid execution id"), nil)
+	}
+
+	var rev entity.RevisionNumber
+	if v := c.QueryParam("rev"); v != "" {
+		revisionNumber, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid revision number"), nil)
+		}
+		rev = entity.RevisionNumber(revisionNumber)
+	}
+
+	if replay, err := s.ServiceHandler.ReplayExecution(c.Request().Context(), contractID, executionID, rev); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"replay": replay,
+		})
+	}
+}
+
+// ContractFuelUsageHandler is the handler for the /contract/:id/fuel/usage API.
+// Supported query params are limit, offset, group_by (user or bucket), bucket, from and to (RFC3339).
+func (s *ServerAPI) ContractFuelUsageHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	filter, err := bindFuelUsageFilter(c, entity.FuelUsageGroupByUser)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	if usages, err := s.ServiceHandler.ContractFuelUsage(c.Request().Context(), contractID, filter); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"usages": usages,
+		})
+	}
+}
+
+// ContractLogsHandler is the handler for the /contract/:id/logs API.
+func (s *ServerAPI) ContractLogsHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if logs, err := s.ServiceHandler.ContractLogs(c.Request().Context(), contractID); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"logs": logs,
+		})
+	}
+}
+
+// ContractRevisionsHandler is the handler for the /contract/:id/revisions API.
+// Supported query params are limit and offset, the newest revisions come first.
+func (s *ServerAPI) ContractRevisionsHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	var filter service.RevisionFilter
+	if filter.Limit, filter.Offset, err = bindLimitOffset(c); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	if revisions, n, err := s.ServiceHandler.ContractRevisions(c.Request().Context(), contractID, filter); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"revisions": revisions,
+			"total":     n,
+		})
+	}
+}
+
+// ContractRevisionHandler is the handler for the /contract/:id/revision/:rev API.
+func (s *ServerAPI) ContractRevisionHandler(c echo.Context) error {
 
 	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -43,24 +266,74 @@ func (s *ServerAPI) ContractCallRevHandler(c echo.Context) error {
 		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid revision number"), nil)
 	}
 
-	if res, err := s.ServiceHandler.CallContract(c.Request().Context(), contractID, entity.RevisionNumber(revisionNumber)); err != nil {
+	if revision, err := s.ServiceHandler.ContractRevision(c.Request().Context(), contractID, entity.RevisionNumber(revisionNumber)); err != nil {
 		return ErrorResponseJSON(c, err, nil)
 	} else {
 		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
-			"result": res,
+			"revision": revision,
 		})
 	}
 }
 
-// ContractHandler is the handler for the /contract/:id search API.
-func (s *ServerAPI) ContractHandler(c echo.Context) error {
+// ContractRevisionCodeHandler is the handler for the /contract/:id/revision/:rev/code API.
+// The code is downloaded as an attachment.
+func (s *ServerAPI) ContractRevisionCodeHandler(c echo.Context) error {
 
 	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
 	}
 
-	if contract, err := s.ServiceHandler.FindContractByID(c.Request().Context(), contractID); err != nil {
+	revisionNumber, err := strconv.ParseUint(c.Param("rev"), 10, 32)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid revision number"), nil)
+	}
+
+	revision, err := s.ServiceHandler.ContractRevisionCode(c.Request().Context(), contractID, entity.RevisionNumber(revisionNumber))
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	filename := fmt.Sprintf("contract-%d-rev-%d%s", contractID, revision.Rev, revision.Version.CodeExtension())
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	return c.Blob(http.StatusOK, echo.MIMEOctetStream, revision.CompiledCode)
+}
+
+// ContractActiveRevisionHandler is the handler for the /contract/:id/active-revision update API.
+// The body is {"rev": n}, rev 0 unpins the active revision so the calls run the last revision.
+func (s *ServerAPI) ContractActiveRevisionHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	var params struct {
+		Rev entity.RevisionNumber `json:"rev"`
+	}
+	if err := c.Bind(&params); err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid request"), nil)
+	}
+
+	if contract, err := s.ServiceHandler.SetActiveRevision(c.Request().Context(), contractID, params.Rev); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"contract": contract,
+		})
+	}
+}
+
+// ContractActiveRevisionRollbackHandler is the handler for the /contract/:id/active-revision/rollback API.
+func (s *ServerAPI) ContractActiveRevisionRollbackHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if contract, err := s.ServiceHandler.RollbackActiveRevision(c.Request().Context(), contractID); err != nil {
 		return ErrorResponseJSON(c, err, nil)
 	} else {
 		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
@@ -69,6 +342,71 @@ func (s *ServerAPI) ContractHandler(c echo.Context) error {
 	}
 }
 
+// ContractAliasesHandler is the handler for the /contract/:id/aliases API.
+func (s *ServerAPI) ContractAliasesHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if aliases, err := s.ServiceHandler.ContractAliases(c.Request().Context(), contractID); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"aliases": aliases,
+		})
+	}
+}
+
+// ContractAliasDeleteHandler is the handler for the /contract/:id/alias/:name delete API.
+func (s *ServerAPI) ContractAliasDeleteHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if err := s.ServiceHandler.DeleteContractAlias(c.Request().Context(), contractID, c.Param("name")); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	return SuccessResponseJSON(c, http.StatusOK, nil)
+}
+
+// ContractAliasSetHandler is the handler for the /contract/:id/alias/:name update API.
+// The body is {"rev": n, "split_rev": m, "split_weight": w}, w percent of the calls run the revision m.
+func (s *ServerAPI) ContractAliasSetHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	var params struct {
+		Rev         entity.RevisionNumber `json:"rev"`
+		SplitRev    entity.RevisionNumber `json:"split_rev"`
+		SplitWeight int                   `json:"split_weight"`
+	}
+	if err := c.Bind(&params); err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid request"), nil)
+	}
+
+	if alias, err := s.ServiceHandler.SetContractAlias(c.Request().Context(), &entity.ContractAlias{
+		ContractID:  contractID,
+		Name:        c.Param("name"),
+		Rev:         params.Rev,
+		SplitRev:    params.SplitRev,
+		SplitWeight: params.SplitWeight,
+	}); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"alias": alias,
+		})
+	}
+}
+
 // ContractCreateHandler is the handler for the /contract create API.
 func (s *ServerAPI) ContractCreateHandler(c echo.Context) error {
 
@@ -87,6 +425,7 @@ func (s *ServerAPI) ContractCreateHandler(c echo.Context) error {
 }
 
 // ContractMakeRevisionHandler is the handler for the /contract/:id/revision create API.
+// The revision number is assigned by the server and returned together with the hash of the compiled code.
 func (s *ServerAPI) ContractMakeRevisionHandler(c echo.Context) error {
 
 	var revision entity.Revision
@@ -128,6 +467,23 @@ func (s *ServerAPI) ContractMakeRevisionHandler(c echo.Context) error {
 	}
 }
 
+// ContractRestoreHandler is the handler for the /contract/:id/restore API.
+func (s *ServerAPI) ContractRestoreHandler(c echo.Context) error {
+
+	contractID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return ErrorResponseJSON(c, apperr.Errorf(apperr.EINVALID, "invalid contract id"), nil)
+	}
+
+	if contract, err := s.ServiceHandler.RestoreContract(c.Request().Context(), contractID); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"contract": contract,
+		})
+	}
+}
+
 // ContractUpdateHandler is the handler for the /contract/:id update API.
 func (s *ServerAPI) ContractUpdateHandler(c echo.Context) error {
 
@@ -149,3 +505,254 @@ func (s *ServerAPI) ContractUpdateHandler(c echo.Context) error {
 		})
 	}
 }
+
+// callContract calls the contract with the input bound from the request body.
+// In debug mode the console output of the contract is returned together with the result.
+// In dry run mode the state changes and the fuel of the call are returned together with the result.
+func (s *ServerAPI) callContract(c echo.Context, params handler.CallContractParams) error {
+
+	input, err := bindContractInput(c, s.CallBodyLimit)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	params.Input = input
+
+	res, err := s.ServiceHandler.CallContract(c.Request().Context(), params)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	data := echo.Map{
+		"result": res.Result,
+		"rev":    res.Rev,
+	}
+
+	if params.Debug {
+		data["logs"] = res.Logs
+	}
+
+	if res.DryRun != nil {
+		data["state_diff"] = res.DryRun.StateDiff
+		data["fuel"] = res.DryRun.Fuel
+	}
+
+	return SuccessResponseJSON(c, http.StatusOK, data)
+}
+
+// bindCallTarget parses the rev param of a contract call, it is a revision number or an alias like @canary.
+func bindCallTarget(c echo.Context) (entity.RevisionNumber, string, error) {
+
+	param := c.Param("rev")
+
+	if strings.HasPrefix(param, entity.ContractAliasPrefix) {
+		alias := strings.TrimPrefix(param, entity.ContractAliasPrefix)
+		if err := entity.ValidateContractAliasName(alias); err != nil {
+			return 0, "", err
+		}
+		return 0, alias, nil
+	}
+
+	revisionNumber, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return 0, "", apperr.Errorf(apperr.EINVALID, "invalid revision number")
+	}
+
+	return entity.RevisionNumber(revisionNumber), "", nil
+}
+
+// bindContractInput decodes the JSON body of a contract call.
+// An empty body is legal and returns a nil input.
+// Return ETOOLARGE if the body is larger than limit bytes, 0 disables the limit.
+func bindContractInput(c echo.Context, limit int64) (any, error) {
+
+	if c.Request().Body == nil {
+		return nil, nil
+	}
+
+	reader := c.Request().Body
+	if limit > 0 {
+		reader = http.MaxBytesReader(c.Response(), reader, limit)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+
+	body, err := io.ReadAll(reader)
+	if errors.As(err, &maxBytesErr) {
+		return nil, apperr.Errorf(apperr.ETOOLARGE, "request body is larger than %d bytes", limit)
+	} else if err != nil {
+		return nil, apperr.Errorf(apperr.EINVALID, "unable to read request body")
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	var input any
+	if err := json.Unmarshal(body, &input); err != nil {
+		return nil, apperr.Errorf(apperr.EINVALID, "invalid contract input")
+	}
+
+	return input, nil
+}
+
+// bindContractFilter reads the contract filter from the query params.
+func bindContractFilter(c echo.Context) (filter service.ContractFilter, err error) {
+
+	if filter.Limit, filter.Offset, err = bindLimitOffset(c); err != nil {
+		return filter, err
+	}
+
+	if v := c.QueryParam("name"); v != "" {
+		filter.Name = &v
+	}
+
+	if v := c.QueryParam("description"); v != "" {
+		filter.Description = &v
+	}
+
+	if v := c.QueryParam("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid user id")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := c.QueryParam("order_by"); v != "" {
+		filter.OrderBy = entity.ContractOrderBy(v)
+		if err := filter.OrderBy.Validate(); err != nil {
+			return filter, err
+		}
+	}
+
+	switch c.QueryParam("order") {
+	case "", "asc":
+	case "desc":
+		filter.OrderDesc = true
+	default:
+		return filter, apperr.Errorf(apperr.EINVALID, "invalid order")
+	}
+
+	return filter, nil
+}
+
+// bindExecutionFilter reads the execution filter from the query params.
+func bindExecutionFilter(c echo.Context) (filter service.ExecutionFilter, err error) {
+
+	if filter.Limit, filter.Offset, err = bindLimitOffset(c); err != nil {
+		return filter, err
+	}
+
+	if v := c.QueryParam("outcome"); v != "" {
+		outcome := entity.ExecutionOutcome(v)
+		if err := outcome.Validate(); err != nil {
+			return filter, err
+		}
+		filter.Outcome = &outcome
+	}
+
+	if v := c.QueryParam("revision_id"); v != "" {
+		revisionID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid revision id")
+		}
+		filter.RevisionID = &revisionID
+	}
+
+	if v := c.QueryParam("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid user id")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := c.QueryParam("started_after"); v != "" {
+		startedAfter, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid started_after")
+		}
+		filter.StartedAfter = &startedAfter
+	}
+
+	if v := c.QueryParam("started_before"); v != "" {
+		startedBefore, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid started_before")
+		}
+		filter.StartedBefore = &startedBefore
+	}
+
+	return filter, nil
+}
+
+// bindFuelUsageFilter reads the fuel usage filter from the query params.
+// If group_by is not provided defaultGroupBy is used, if bucket is not provided the entries are grouped by day.
+func bindFuelUsageFilter(c echo.Context, defaultGroupBy entity.FuelUsageGroupBy) (filter service.FuelUsageFilter, err error) {
+
+	if filter.Limit, filter.Offset, err = bindLimitOffset(c); err != nil {
+		return filter, err
+	}
+
+	filter.GroupBy = defaultGroupBy
+	if v := c.QueryParam("group_by"); v != "" {
+		filter.GroupBy = entity.FuelUsageGroupBy(v)
+	}
+	if err := filter.GroupBy.Validate(); err != nil {
+		return filter, err
+	}
+
+	if filter.GroupBy == entity.FuelUsageGroupByBucket {
+		filter.Bucket = entity.FuelUsageBucketDay
+		if v := c.QueryParam("bucket"); v != "" {
+			filter.Bucket = entity.FuelUsageBucket(v)
+		}
+		if err := filter.Bucket.Validate(); err != nil {
+			return filter, err
+		}
+	}
+
+	if v := c.QueryParam("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid from")
+		}
+		filter.From = &from
+	}
+
+	if v := c.QueryParam("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, apperr.Errorf(apperr.EINVALID, "invalid to")
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// bindLimitOffset reads the pagination from the limit and offset query params.
+// If limit is not provided DefaultPageLimit is used, it cannot exceed MaxPageLimit.
+func bindLimitOffset(c echo.Context) (limit, offset int, err error) {
+
+	limit = DefaultPageLimit
+
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			return 0, 0, apperr.Errorf(apperr.EINVALID, "invalid limit")
+		}
+	}
+
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	if v := c.QueryParam("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, apperr.Errorf(apperr.EINVALID, "invalid offset")
+		}
+	}
+
+	return limit, offset, nil
+}
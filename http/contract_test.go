@@ -9,12 +9,15 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 
+	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 	"github.com/music-gang/music-gang-api/common"
+	apphttp "github.com/music-gang/music-gang-api/http"
 	"github.com/music-gang/music-gang-api/mock"
 )
 
@@ -546,7 +549,7 @@ func TestContract_ContractUpdateHandler(t *testing.T) {
 	})
 }
 
-func TestContract_ContractHandler(t *testing.T) {
+func TestContract_ContractDeleteHandler(t *testing.T) {
 
 	t.Run("OK", func(t *testing.T) {
 
@@ -593,20 +596,18 @@ func TestContract_ContractHandler(t *testing.T) {
 			},
 		}
 
-		s.ServiceHandler.ContractSearchService = &mock.ContractService{
-			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
-				return &entity.Contract{
-					ID:          1,
-					Name:        "test contract",
-					Description: "test contract",
-					UserID:      1,
-					Visibility:  "public",
-					MaxFuel:     entity.Fuel(testFuel),
-				}, nil
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				DeleteContractFn: func(ctx context.Context, id int64) error {
+					if id != 1 {
+						return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+					}
+					return nil
+				},
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1", nil)
+		req, err := http.NewRequest(http.MethodDelete, s.URL()+"/v1/contract/1", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -621,17 +622,9 @@ func TestContract_ContractHandler(t *testing.T) {
 		if resp.StatusCode != http.StatusOK {
 			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
 		}
-
-		contractData := make(map[string]any)
-
-		if err := json.NewDecoder(resp.Body).Decode(&contractData); err != nil {
-			t.Fatal(err)
-		} else if contractData["contract"] == nil {
-			t.Fatalf("expected contract data, got %v", contractData)
-		}
 	})
 
-	t.Run("InvalidContractID", func(t *testing.T) {
+	t.Run("NotOwned", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -676,7 +669,18 @@ func TestContract_ContractHandler(t *testing.T) {
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/invalid_id", nil)
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				DeleteContractFn: func(ctx context.Context, id int64) error {
+					if id != 1 {
+						return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+					}
+					return nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, s.URL()+"/v1/contract/2", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -688,12 +692,12 @@ func TestContract_ContractHandler(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
 		}
 	})
 
-	t.Run("ErrFindContractByID", func(t *testing.T) {
+	t.Run("InvalidContractID", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -738,13 +742,7 @@ func TestContract_ContractHandler(t *testing.T) {
 			},
 		}
 
-		s.ServiceHandler.ContractSearchService = &mock.ContractService{
-			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
-				return nil, apperr.Errorf(apperr.EINTERNAL, "internal error")
-			},
-		}
-
-		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1", nil)
+		req, err := http.NewRequest(http.MethodDelete, s.URL()+"/v1/contract/abc", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -756,13 +754,13 @@ func TestContract_ContractHandler(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusInternalServerError {
-			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
 		}
 	})
 }
 
-func TestContract_ContractMakeRevision(t *testing.T) {
+func TestContract_ContractRestoreHandler(t *testing.T) {
 
 	t.Run("OK", func(t *testing.T) {
 
@@ -811,49 +809,27 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 
 		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
 			ContractService: &mock.ContractService{
-				MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
-					revision.CreatedAt = common.AppNowUTC()
-					revision.Rev = 1
-					if err := revision.Validate(); err != nil {
-						return err
+				RestoreContractFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "deleted contract not found")
 					}
-					revision.ID = 1
-					return nil
+					return &entity.Contract{
+						ID:         1,
+						Name:       "test contract",
+						UserID:     1,
+						Visibility: entity.VisibilityPublic,
+						MaxFuel:    entity.Fuel(testFuel),
+					}, nil
 				},
 			},
 		}
 
-		var b bytes.Buffer
-		writer := multipart.NewWriter(&b)
-
-		file := mustOpen("revision_example/revision_test.js")
-		defer file.Close()
-
-		part, err := writer.CreateFormFile("compiled_revision", file.Name())
-		if err != nil {
-			t.Fatal(err)
-		}
-		io.Copy(part, file)
-
-		part, err = writer.CreateFormField("revision")
-		if err != nil {
-			t.Fatal(err)
-		}
-		if _, err := part.Write([]byte(revisionRequestBody)); err != nil {
-			t.Fatal(err)
-		}
-
-		if err := writer.Close(); err != nil {
-			t.Fatal(err)
-		}
-
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/restore", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
 		req.Header.Set("Authorization", "Bearer OK")
-		req.Header.Set("Content-Type", writer.FormDataContentType())
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
@@ -864,20 +840,16 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
 		}
 
-		var revisionData revisionResponse
+		contractData := make(map[string]any)
 
-		if err := json.NewDecoder(resp.Body).Decode(&revisionData); err != nil {
+		if err := json.NewDecoder(resp.Body).Decode(&contractData); err != nil {
 			t.Fatal(err)
-		} else if revisionData.Revision == nil {
-			t.Fatalf("expected contract data, got %v", revisionData)
-		} else if revisionData.Revision.ID != 1 {
-			t.Fatalf("expected revision id 1, got %d", revisionData.Revision.ID)
-		} else if revisionData.Revision.ContractID != 1 {
-			t.Fatalf("expected revision contract id 1, got %d", revisionData.Revision.ContractID)
+		} else if contractData["contract"] == nil {
+			t.Fatalf("expected contract data, got %v", contractData)
 		}
 	})
 
-	t.Run("InvalidContractID", func(t *testing.T) {
+	t.Run("NotDeleted", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -922,7 +894,24 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/revision", nil)
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				RestoreContractFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "deleted contract not found")
+					}
+					return &entity.Contract{
+						ID:         1,
+						Name:       "test contract",
+						UserID:     1,
+						Visibility: entity.VisibilityPublic,
+						MaxFuel:    entity.Fuel(testFuel),
+					}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/2/restore", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -934,12 +923,15 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("MissingJsonBody", func(t *testing.T) {
+func TestContract_ContractsHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -984,7 +976,27 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", nil)
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractsFn: func(ctx context.Context, filter service.ContractFilter) (entity.Contracts, int, error) {
+				if filter.VisibleTo == nil || *filter.VisibleTo != 1 {
+					t.Errorf("expected contracts visible to user 1, got %v", filter.VisibleTo)
+				}
+				if filter.Name == nil || *filter.Name != "test" {
+					t.Errorf("expected name filter test, got %v", filter.Name)
+				}
+				if filter.OrderBy != entity.ContractOrderByUpdatedAt || !filter.OrderDesc {
+					t.Errorf("expected order by updated_at desc, got %s desc %v", filter.OrderBy, filter.OrderDesc)
+				}
+				if filter.Limit != 5 || filter.Offset != 10 {
+					t.Errorf("expected limit 5 and offset 10, got %d and %d", filter.Limit, filter.Offset)
+				}
+				return entity.Contracts{
+					{ID: 1, UserID: 2, Name: "test", Visibility: entity.VisibilityPublic},
+				}, 11, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract?name=test&order_by=updated_at&order=desc&limit=5&offset=10", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -996,12 +1008,22 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		contractsResponse := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&contractsResponse); err != nil {
+			t.Fatal(err)
+		} else if contracts, ok := contractsResponse["contracts"].([]any); !ok || len(contracts) != 1 {
+			t.Fatalf("expected 1 contract, got %v", contractsResponse["contracts"])
+		} else if total := contractsResponse["total"]; total != float64(11) {
+			t.Errorf("expected total 11, got %v", total)
 		}
 	})
 
-	t.Run("InvalidJsonBody", func(t *testing.T) {
+	t.Run("InvalidOrderBy", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1046,25 +1068,12 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			},
 		}
 
-		var b bytes.Buffer
-		writer := multipart.NewWriter(&b)
-		part, err := writer.CreateFormField("revision")
-		if err != nil {
-			t.Fatal(err)
-		} else if _, err := io.WriteString(part, "invalid json"); err != nil {
-			t.Fatal(err)
-		}
-		if err := writer.Close(); err != nil {
-			t.Fatal(err)
-		}
-
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract?order_by=name", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
 		req.Header.Set("Authorization", "Bearer OK")
-		req.Header.Set("Content-Type", writer.FormDataContentType())
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
@@ -1075,8 +1084,11 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("MissingFile", func(t *testing.T) {
+func TestContract_ContractHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1121,37 +1133,45 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			},
 		}
 
-		var b bytes.Buffer
-		writer := multipart.NewWriter(&b)
-		part, err := writer.CreateFormField("revision")
-		if err != nil {
-			t.Fatal(err)
-		} else if _, err := io.WriteString(part, revisionRequestBody); err != nil {
-			t.Fatal(err)
-		}
-		if err := writer.Close(); err != nil {
-			t.Fatal(err)
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				return &entity.Contract{
+					ID:          1,
+					Name:        "test contract",
+					Description: "test contract",
+					UserID:      1,
+					Visibility:  "public",
+					MaxFuel:     entity.Fuel(testFuel),
+				}, nil
+			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
 		req.Header.Set("Authorization", "Bearer OK")
-		req.Header.Set("Content-Type", writer.FormDataContentType())
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		contractData := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&contractData); err != nil {
+			t.Fatal(err)
+		} else if contractData["contract"] == nil {
+			t.Fatalf("expected contract data, got %v", contractData)
 		}
 	})
 
-	t.Run("ErrMakeRevision", func(t *testing.T) {
+	t.Run("InvalidContractID", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1196,60 +1216,24 @@ func TestContract_ContractMakeRevision(t *testing.T) {
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ContractService: &mock.ContractService{
-				MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
-					return apperr.Errorf(apperr.EINTERNAL, "internal error")
-				},
-			},
-		}
-
-		var b bytes.Buffer
-		writer := multipart.NewWriter(&b)
-
-		file := mustOpen("revision_example/revision_test.js")
-		defer file.Close()
-
-		part, err := writer.CreateFormFile("compiled_revision", file.Name())
-		if err != nil {
-			t.Fatal(err)
-		}
-		io.Copy(part, file)
-
-		part, err = writer.CreateFormField("revision")
-		if err != nil {
-			t.Fatal(err)
-		}
-		if _, err := part.Write([]byte(revisionRequestBody)); err != nil {
-			t.Fatal(err)
-		}
-
-		if err := writer.Close(); err != nil {
-			t.Fatal(err)
-		}
-
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/invalid_id", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
 		req.Header.Set("Authorization", "Bearer OK")
-		req.Header.Set("Content-Type", writer.FormDataContentType())
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusInternalServerError {
-			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
 		}
 	})
-}
-
-func TestContract_ContractCall(t *testing.T) {
 
-	t.Run("OK", func(t *testing.T) {
+	t.Run("ErrFindContractByID", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1296,30 +1280,4383 @@ func TestContract_ContractCall(t *testing.T) {
 
 		s.ServiceHandler.ContractSearchService = &mock.ContractService{
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
-				if id == 1 {
-					return &entity.Contract{
-						ID:           1,
-						Name:         "contract",
-						Description:  "contract description",
-						LastRevision: &entity.Revision{ID: 1},
-						User: &entity.User{
-							ID: 1,
-						},
-					}, nil
-				}
-				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				return nil, apperr.Errorf(apperr.EINTERNAL, "internal error")
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "OK", nil
-				},
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		}
+	})
+
+	t.Run("Visibility", func(t *testing.T) {
+
+		// the authenticated user is always 1.
+		visibilityCases := []struct {
+			name       string
+			visibility entity.Visibility
+			ownerID    int64
+			statusCode int
+		}{
+			{"PublicOwner", entity.VisibilityPublic, 1, http.StatusOK},
+			{"PublicNotOwner", entity.VisibilityPublic, 2, http.StatusOK},
+			{"PrivateOwner", entity.VisibilityPrivate, 1, http.StatusOK},
+			{"PrivateNotOwner", entity.VisibilityPrivate, 2, http.StatusUnauthorized},
+		}
+
+		for _, tc := range visibilityCases {
+
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+
+				s := MustOpenServerAPI(t)
+				defer MustCloseServerAPI(t, s)
+
+				s.ServiceHandler.JWTService = &mock.JWTService{
+					ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+						if token == "OK" {
+							return &entity.AppClaims{
+								Auth: &entity.Auth{
+									UserID: 1,
+									ID:     1,
+									User:   &entity.User{ID: 1},
+								},
+							}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+					},
+				}
+
+				s.ServiceHandler.UserSearchService = &mock.UserService{
+					FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+						if id == 1 {
+							return &entity.User{ID: 1}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+					},
+				}
+
+				s.ServiceHandler.AuthSearchService = &mock.AuthService{
+					FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+						if id == 1 {
+							return &entity.Auth{
+								UserID: 1,
+								ID:     1,
+								User:   &entity.User{ID: 1},
+							}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+					},
+				}
+
+				s.ServiceHandler.ContractSearchService = &mock.ContractService{
+					FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+						return &entity.Contract{
+							ID:         id,
+							Name:       "test contract",
+							UserID:     tc.ownerID,
+							Visibility: tc.visibility,
+						}, nil
+					},
+				}
+
+				req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				req.Header.Set("Authorization", "Bearer OK")
+
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if resp.StatusCode != tc.statusCode {
+					t.Fatalf("expected status code %d, got %d", tc.statusCode, resp.StatusCode)
+				}
+			})
+		}
+	})
+}
+
+func TestContract_ContractMakeRevision(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
+					revision.CreatedAt = common.AppNowUTC()
+					revision.Rev = 1
+					if err := revision.Validate(); err != nil {
+						return err
+					}
+					revision.ID = 1
+					return nil
+				},
+			},
+		}
+
+		var b bytes.Buffer
+		writer := multipart.NewWriter(&b)
+
+		file := mustOpen("revision_example/revision_test.js")
+		defer file.Close()
+
+		part, err := writer.CreateFormFile("compiled_revision", file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(part, file)
+
+		part, err = writer.CreateFormField("revision")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(revisionRequestBody)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		var revisionData revisionResponse
+
+		if err := json.NewDecoder(resp.Body).Decode(&revisionData); err != nil {
+			t.Fatal(err)
+		} else if revisionData.Revision == nil {
+			t.Fatalf("expected contract data, got %v", revisionData)
+		} else if revisionData.Revision.ID != 1 {
+			t.Fatalf("expected revision id 1, got %d", revisionData.Revision.ID)
+		} else if revisionData.Revision.ContractID != 1 {
+			t.Fatalf("expected revision contract id 1, got %d", revisionData.Revision.ContractID)
+		}
+	})
+
+	t.Run("InvalidContractID", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/revision", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("MissingJsonBody", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidJsonBody", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		var b bytes.Buffer
+		writer := multipart.NewWriter(&b)
+		part, err := writer.CreateFormField("revision")
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := io.WriteString(part, "invalid json"); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		var b bytes.Buffer
+		writer := multipart.NewWriter(&b)
+		part, err := writer.CreateFormField("revision")
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := io.WriteString(part, revisionRequestBody); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("ErrMakeRevision", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
+					return apperr.Errorf(apperr.EINTERNAL, "internal error")
+				},
+			},
+		}
+
+		var b bytes.Buffer
+		writer := multipart.NewWriter(&b)
+
+		file := mustOpen("revision_example/revision_test.js")
+		defer file.Close()
+
+		part, err := writer.CreateFormFile("compiled_revision", file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(part, file)
+
+		part, err = writer.CreateFormField("revision")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(revisionRequestBody)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
+					return apperr.Errorf(apperr.EINVALID, "Syntax error in contract").WithDetails([]*entity.RevisionCodeError{
+						{Line: 2, Column: 22, Message: "Unexpected token ;"},
+					})
+				},
+			},
+		}
+
+		var b bytes.Buffer
+		writer := multipart.NewWriter(&b)
+
+		file := mustOpen("revision_example/revision_test.js")
+		defer file.Close()
+
+		part, err := writer.CreateFormFile("compiled_revision", file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(part, file)
+
+		part, err = writer.CreateFormField("revision")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(revisionRequestBody)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/revision", &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var errorAPI struct {
+			Code    string                      `json:"code"`
+			Details []*entity.RevisionCodeError `json:"details"`
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&errorAPI); err != nil {
+			t.Fatal(err)
+		} else if len(errorAPI.Details) != 1 || errorAPI.Details[0].Line != 2 || errorAPI.Details[0].Column != 22 {
+			t.Fatalf("expected the syntax error at 2:22 in details, got %+v", errorAPI.Details)
+		}
+	})
+}
+
+func TestContract_ContractRevisionsHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revisions?limit=2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		revisionsResponse := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&revisionsResponse); err != nil {
+			t.Fatal(err)
+		} else if revisionsResponse["total"] != float64(2) {
+			t.Fatalf("expected total %d, got %v", 2, revisionsResponse["total"])
+		} else if revisions, ok := revisionsResponse["revisions"].([]any); !ok || len(revisions) != 2 {
+			t.Fatalf("expected 2 revisions, got %v", revisionsResponse["revisions"])
+		}
+	})
+
+	t.Run("PrivateNotOwner", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPrivate}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPrivate},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revisions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revisions?limit=abc", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractRevisionHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		revisionData := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&revisionData); err != nil {
+			t.Fatal(err)
+		} else if revision, ok := revisionData["revision"].(map[string]any); !ok {
+			t.Fatalf("expected revision data, got %v", revisionData)
+		} else if _, ok := revision["compiled_code"]; ok {
+			t.Fatal("expected the code to be hidden")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/3", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("PrivateNotOwner", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPrivate}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPrivate},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractRevisionCodeHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 1, Visibility: entity.VisibilityPrivate}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 1, Visibility: entity.VisibilityPrivate},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/1/code", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		if v := resp.Header.Get("Content-Disposition"); v != `attachment; filename="contract-1-rev-1.js"` {
+			t.Fatalf("unexpected content disposition %s", v)
+		}
+
+		if code, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatal(err)
+		} else if string(code) != "var result = 1" {
+			t.Fatalf("expected code %s, got %s", "var result = 1", code)
+		}
+	})
+
+	t.Run("Chicago", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 1, Visibility: entity.VisibilityPrivate}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.ChicagoVersion,
+					CompiledCode: []byte("\x00asm\x01\x00\x00\x00"),
+					Contract:     &entity.Contract{ID: 1, UserID: 1, Visibility: entity.VisibilityPrivate},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/1/code", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		if v := resp.Header.Get("Content-Disposition"); v != `attachment; filename="contract-1-rev-1.wasm"` {
+			t.Fatalf("unexpected content disposition %s", v)
+		}
+
+		if code, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatal(err)
+		} else if string(code) != "\x00asm\x01\x00\x00\x00" {
+			t.Fatalf("expected the wasm module, got %x", code)
+		}
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				}
+				return &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic}, nil
+			},
+			FindRevisionsFn: func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+				if filter.ContractID != 1 {
+					t.Errorf("expected contract filter %d, got %d", 1, filter.ContractID)
+				}
+				return entity.Revisions{
+					{ID: 2, Rev: 2, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "second"},
+					{ID: 1, Rev: 1, ContractID: 1, Version: entity.CurrentRevisionVersion, Notes: "first"},
+				}, 2, nil
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID != 1 || rev != 1 {
+					return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+				}
+				return &entity.Revision{
+					ID:           1,
+					Rev:          1,
+					ContractID:   1,
+					Version:      entity.CurrentRevisionVersion,
+					CompiledCode: []byte("var result = 1"),
+					Contract:     &entity.Contract{ID: 1, UserID: 2, Visibility: entity.VisibilityPublic},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/revision/1/code", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractActiveRevisionHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				SetActiveRevisionFn: func(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+					if rev != 2 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: rev, PreviousActiveRev: 3}, nil
+				},
+				RollbackActiveRevisionFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ECONFLICT, "no revision to roll back to")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: 2, PreviousActiveRev: 3}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPut, s.URL()+"/v1/contract/1/active-revision", bytes.NewBufferString(`{"rev": 2}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		contractData := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&contractData); err != nil {
+			t.Fatal(err)
+		} else if contract, ok := contractData["contract"].(map[string]any); !ok {
+			t.Fatalf("expected contract data, got %v", contractData)
+		} else if contract["active_rev"] != float64(2) {
+			t.Fatalf("expected active revision %d, got %v", 2, contract["active_rev"])
+		}
+	})
+
+	t.Run("RevisionNotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				SetActiveRevisionFn: func(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+					if rev != 2 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: rev, PreviousActiveRev: 3}, nil
+				},
+				RollbackActiveRevisionFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ECONFLICT, "no revision to roll back to")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: 2, PreviousActiveRev: 3}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPut, s.URL()+"/v1/contract/1/active-revision", bytes.NewBufferString(`{"rev": 9}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidJsonBody", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPut, s.URL()+"/v1/contract/1/active-revision", bytes.NewBufferString(`{"rev": "abc"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractActiveRevisionRollbackHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				SetActiveRevisionFn: func(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+					if rev != 2 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: rev, PreviousActiveRev: 3}, nil
+				},
+				RollbackActiveRevisionFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ECONFLICT, "no revision to roll back to")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: 2, PreviousActiveRev: 3}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/active-revision/rollback", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		contractData := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&contractData); err != nil {
+			t.Fatal(err)
+		} else if contract, ok := contractData["contract"].(map[string]any); !ok {
+			t.Fatalf("expected contract data, got %v", contractData)
+		} else if contract["active_rev"] != float64(2) {
+			t.Fatalf("expected active revision %d, got %v", 2, contract["active_rev"])
+		}
+	})
+
+	t.Run("NothingToRollback", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractService: &mock.ContractService{
+				SetActiveRevisionFn: func(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+					if rev != 2 {
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: rev, PreviousActiveRev: 3}, nil
+				},
+				RollbackActiveRevisionFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+					if id != 1 {
+						return nil, apperr.Errorf(apperr.ECONFLICT, "no revision to roll back to")
+					}
+					return &entity.Contract{ID: id, UserID: 1, ActiveRev: 2, PreviousActiveRev: 3}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/2/active-revision/rollback", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusConflict {
+			t.Fatalf("expected status code %d, got %d", http.StatusConflict, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractAliasesHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id, UserID: 1}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{
+			FindContractAliasesFn: func(ctx context.Context, contractID int64) (entity.ContractAliases, error) {
+				return entity.ContractAliases{
+					{ID: 1, ContractID: contractID, Name: "canary", Rev: 1, SplitRev: 2, SplitWeight: 10},
+					{ID: 2, ContractID: contractID, Name: "stable", Rev: 1},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/aliases", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result struct {
+			Aliases entity.ContractAliases `json:"aliases"`
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		} else if len(result.Aliases) != 2 {
+			t.Fatalf("expected %d aliases, got %d", 2, len(result.Aliases))
+		} else if result.Aliases[0].SplitWeight != 10 {
+			t.Fatalf("expected split weight %d, got %d", 10, result.Aliases[0].SplitWeight)
+		}
+	})
+
+	t.Run("ContractNotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id, UserID: 1}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/2/aliases", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id, UserID: 1}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/aliases", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractAliasSetHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractAliasService: &mock.ContractAliasService{
+				SetContractAliasFn: func(ctx context.Context, alias *entity.ContractAlias) error {
+					if alias.ContractID != 1 || alias.Name != "canary" || alias.Rev != 1 || alias.SplitRev != 2 || alias.SplitWeight != 10 {
+						t.Errorf("unexpected alias %+v", alias)
+					}
+					alias.ID = 1
+					return nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPut, s.URL()+"/v1/contract/1/alias/canary", bytes.NewBufferString(`{"rev": 1, "split_rev": 2, "split_weight": 10}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result struct {
+			Alias entity.ContractAlias `json:"alias"`
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		} else if result.Alias.ID != 1 {
+			t.Fatalf("expected alias id %d, got %d", 1, result.Alias.ID)
+		}
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractAliasService: &mock.ContractAliasService{
+				SetContractAliasFn: func(ctx context.Context, alias *entity.ContractAlias) error {
+					return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPut, s.URL()+"/v1/contract/1/alias/canary", bytes.NewBufferString(`{"rev": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractAliasDeleteHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractAliasService: &mock.ContractAliasService{
+				DeleteContractAliasFn: func(ctx context.Context, contractID int64, name string) error {
+					if contractID != 1 || name != "canary" {
+						t.Errorf("unexpected alias %d %s", contractID, name)
+					}
+					return nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, s.URL()+"/v1/contract/1/alias/canary", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ContractAliasService: &mock.ContractAliasService{
+				DeleteContractAliasFn: func(ctx context.Context, contractID int64, name string) error {
+					return apperr.Errorf(apperr.ENOTFOUND, "alias not found")
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, s.URL()+"/v1/contract/1/alias/canary", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractCall(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["result"] != "OK" {
+			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		}
+	})
+
+	t.Run("ActiveRevision", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				return &entity.Contract{
+					ID:             id,
+					UserID:         1,
+					ActiveRev:      1,
+					LastRevision:   &entity.Revision{ID: 2, Rev: 2, ContractID: id},
+					ActiveRevision: &entity.Revision{ID: 1, Rev: 1, ContractID: id},
+				}, nil
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if opt.RevisionRef.Rev != 1 {
+						t.Errorf("expected the active revision %d to be called, got %d", 1, opt.RevisionRef.Rev)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("Debug", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					opt.LogRef.Append(entity.LogLevelInfo, "hello")
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call?debug=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["result"] != "OK" {
+			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		} else if logs, ok := ContractCallResult["logs"].([]any); !ok || len(logs) != 1 {
+			t.Fatalf("expected 1 log, got %v", ContractCallResult["logs"])
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						UserID:       1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if opt.DryRunRef == nil {
+						t.Fatal("expected a dry run")
+					}
+					opt.DryRunRef.StateDiff = entity.StateDiff{"count": {Before: float64(1), After: float64(2)}}
+					opt.DryRunRef.Fuel = entity.Fuel(10)
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call?dry_run=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["result"] != "OK" {
+			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		} else if diff, ok := ContractCallResult["state_diff"].(map[string]any); !ok || len(diff) != 1 {
+			t.Fatalf("expected 1 state change, got %v", ContractCallResult["state_diff"])
+		} else if fuel := ContractCallResult["fuel"]; fuel != float64(10) {
+			t.Fatalf("expected fuel 10, got %v", fuel)
+		}
+	})
+
+	t.Run("DryRunNotOwner", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						UserID:       2,
+						Visibility:   entity.VisibilityPublic,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					t.Error("expected the contract not to be executed")
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call?dry_run=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("Input", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if input, ok := opt.Input.(map[string]any); !ok || input["a"] != float64(1) {
+						return nil, apperr.Errorf(apperr.EINVALID, "unexpected input %v", opt.Input)
+					}
+					return map[string]any{"sum": float64(3), "ok": true}, nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", bytes.NewBufferString(`{"a": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(ContractCallResult["result"], map[string]any{"sum": float64(3), "ok": true}) {
+			t.Fatalf("expected structured result, got %v", ContractCallResult["result"])
+		}
+	})
+
+	t.Run("InvalidInput", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", bytes.NewBufferString(`{"a": `))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidContractID", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/call", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("ContractNotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("ErrExecContract", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "", apperr.Errorf(apperr.EINTERNAL, "internal error")
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		}
+	})
+
+	t.Run("Visibility", func(t *testing.T) {
+
+		// the authenticated user is always 1.
+		visibilityCases := []struct {
+			name       string
+			visibility entity.Visibility
+			ownerID    int64
+			statusCode int
+		}{
+			{"PublicOwner", entity.VisibilityPublic, 1, http.StatusOK},
+			{"PublicNotOwner", entity.VisibilityPublic, 2, http.StatusOK},
+			{"PrivateOwner", entity.VisibilityPrivate, 1, http.StatusOK},
+			{"PrivateNotOwner", entity.VisibilityPrivate, 2, http.StatusUnauthorized},
+		}
+
+		for _, tc := range visibilityCases {
+
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+
+				s := MustOpenServerAPI(t)
+				defer MustCloseServerAPI(t, s)
+
+				s.ServiceHandler.JWTService = &mock.JWTService{
+					ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+						if token == "OK" {
+							return &entity.AppClaims{
+								Auth: &entity.Auth{
+									UserID: 1,
+									ID:     1,
+									User:   &entity.User{ID: 1},
+								},
+							}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+					},
+				}
+
+				s.ServiceHandler.UserSearchService = &mock.UserService{
+					FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+						if id == 1 {
+							return &entity.User{ID: 1}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+					},
+				}
+
+				s.ServiceHandler.AuthSearchService = &mock.AuthService{
+					FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+						if id == 1 {
+							return &entity.Auth{
+								UserID: 1,
+								ID:     1,
+								User:   &entity.User{ID: 1},
+							}, nil
+						}
+
+						return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+					},
+				}
+
+				s.ServiceHandler.ContractSearchService = &mock.ContractService{
+					FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+						return &entity.Contract{
+							ID:           id,
+							Name:         "test contract",
+							UserID:       tc.ownerID,
+							Visibility:   tc.visibility,
+							LastRevision: &entity.Revision{ID: 1, ContractID: id},
+						}, nil
+					},
+				}
+
+				s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+					ExecutorService: &mock.ExecutorService{
+						ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+							if _, err := opt.Contract(); err != nil {
+								t.Errorf("expected the contract to be attached to the call, got %v", err)
+							}
+							return "OK", nil
+						},
+					},
+				}
+
+				req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				req.Header.Set("Authorization", "Bearer OK")
+
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if resp.StatusCode != tc.statusCode {
+					t.Fatalf("expected status code %d, got %d", tc.statusCode, resp.StatusCode)
+				}
+			})
+		}
+	})
+}
+
+func TestContract_PublicContractCall(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						UserID:       2,
+						Visibility:   entity.VisibilityPublic,
+						LastRevision: &entity.Revision{ID: 1, ContractID: 1},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if user := app.UserFromContext(ctx); user != nil {
+						t.Errorf("expected anonymous call, got user %d", user.ID)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/public/contract/1/call?debug=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		callResult := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&callResult); err != nil {
+			t.Fatal(err)
+		} else if callResult["result"] != "OK" {
+			t.Fatalf("expected result %s, got %v", "OK", callResult["result"])
+		} else if _, ok := callResult["logs"]; ok {
+			t.Error("expected no logs for an anonymous call")
+		}
+	})
+
+	t.Run("BodyTooLarge", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.CallBodyLimit = 16
+
+		// the input is rejected before the contract is searched and called.
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{}
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/public/contract/1/call", bytes.NewBufferString(`{"value": "larger than the limit"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected status code %d, got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+		}
+	})
+
+	t.Run("PrivateContract", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						UserID:       2,
+						Visibility:   entity.VisibilityPrivate,
+						LastRevision: &entity.Revision{ID: 1, ContractID: 1},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if user := app.UserFromContext(ctx); user != nil {
+						t.Errorf("expected anonymous call, got user %d", user.ID)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/public/contract/1/call?debug=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("StatefulContract", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						UserID:       2,
+						Visibility:   entity.VisibilityPublic,
+						Stateful:     true,
+						LastRevision: &entity.Revision{ID: 1, ContractID: 1},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if user := app.UserFromContext(ctx); user != nil {
+						t.Errorf("expected anonymous call, got user %d", user.ID)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/public/contract/1/call?debug=true", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected status code %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+}
+
+func TestContract_ContractCallRev(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID == 1 && rev == 1 {
+					return &entity.Revision{
+						ID:         1,
+						Rev:        1,
+						Version:    entity.CurrentRevisionVersion,
+						ContractID: contractID,
+						Contract:   &entity.Contract{ID: contractID},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["result"] != "OK" {
+			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		}
+	})
+
+	t.Run("InvalidContractID", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/call/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidContractRev", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:           1,
+						Name:         "contract",
+						Description:  "contract description",
+						LastRevision: &entity.Revision{ID: 1},
+						User: &entity.User{
+							ID: 1,
+						},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/invalid", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("ContractRevNotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("AliasSplitRevision", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID == 1 && (rev == 1 || rev == 2) {
+					return &entity.Revision{
+						ID:         int64(rev),
+						Rev:        rev,
+						Version:    entity.CurrentRevisionVersion,
+						ContractID: contractID,
+						Contract:   &entity.Contract{ID: contractID},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{
+			FindContractAliasByNameFn: func(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error) {
+				if contractID == 1 && name == "canary" {
+					return &entity.ContractAlias{ContractID: contractID, Name: name, Rev: 1, SplitRev: 2, SplitWeight: 100}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "alias not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if opt.AliasRef != "canary" {
+						t.Errorf("expected alias %s, got %s", "canary", opt.AliasRef)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@canary", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["rev"] != float64(2) {
+			t.Fatalf("expected rev %d, got %v", 2, ContractCallResult["rev"])
+		}
+	})
+
+	t.Run("AliasRevision", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID == 1 && (rev == 1 || rev == 2) {
+					return &entity.Revision{
+						ID:         int64(rev),
+						Rev:        rev,
+						Version:    entity.CurrentRevisionVersion,
+						ContractID: contractID,
+						Contract:   &entity.Contract{ID: contractID},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
+			},
+		}
+
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{
+			FindContractAliasByNameFn: func(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error) {
+				if contractID == 1 && name == "canary" {
+					return &entity.ContractAlias{ContractID: contractID, Name: name, Rev: 1, SplitRev: 2, SplitWeight: 0}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "alias not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if opt.AliasRef != "canary" {
+						t.Errorf("expected alias %s, got %s", "canary", opt.AliasRef)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@canary", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ContractCallResult := make(map[string]interface{})
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
+			t.Fatal(err)
+		} else if ContractCallResult["rev"] != float64(1) {
+			t.Fatalf("expected rev %d, got %v", 1, ContractCallResult["rev"])
+		}
+	})
+
+	t.Run("InvalidAlias", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@Canary!", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("AliasNotFound", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{ID: id}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID == 1 && (rev == 1 || rev == 2) {
+					return &entity.Revision{
+						ID:         int64(rev),
+						Rev:        rev,
+						Version:    entity.CurrentRevisionVersion,
+						ContractID: contractID,
+						Contract:   &entity.Contract{ID: contractID},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{
+			FindContractAliasByNameFn: func(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error) {
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "alias not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@canary", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1331,18 +5668,12 @@ func TestContract_ContractCall(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		ContractCallResult := make(map[string]interface{})
-
-		if resp.StatusCode != http.StatusOK {
-			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
-		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
-			t.Fatal(err)
-		} else if ContractCallResult["result"] != "OK" {
-			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
 		}
 	})
 
-	t.Run("InvalidContractID", func(t *testing.T) {
+	t.Run("AliasNotEnabled", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1390,29 +5721,25 @@ func TestContract_ContractCall(t *testing.T) {
 		s.ServiceHandler.ContractSearchService = &mock.ContractService{
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
 				if id == 1 {
-					return &entity.Contract{
-						ID:           1,
-						Name:         "contract",
-						Description:  "contract description",
-						LastRevision: &entity.Revision{ID: 1},
-						User: &entity.User{
-							ID: 1,
-						},
-					}, nil
+					return &entity.Contract{ID: id}, nil
 				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
-		}
-
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "OK", nil
-				},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				if contractID == 1 && (rev == 1 || rev == 2) {
+					return &entity.Revision{
+						ID:         int64(rev),
+						Rev:        rev,
+						Version:    entity.CurrentRevisionVersion,
+						ContractID: contractID,
+						Contract:   &entity.Contract{ID: contractID},
+					}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "revision not found")
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/call", nil)
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@canary", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1424,12 +5751,12 @@ func TestContract_ContractCall(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, resp.StatusCode)
 		}
 	})
 
-	t.Run("ContractNotFound", func(t *testing.T) {
+	t.Run("AliasPrivateContract", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1476,11 +5803,14 @@ func TestContract_ContractCall(t *testing.T) {
 
 		s.ServiceHandler.ContractSearchService = &mock.ContractService{
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
-				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
+				return &entity.Contract{ID: id, UserID: 2, Visibility: entity.VisibilityPrivate}, nil
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		// the alias must not be looked up, the caller cannot tell if it exists.
+		s.ServiceHandler.ContractAliasService = &mock.ContractAliasService{}
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/@canary", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1492,12 +5822,23 @@ func TestContract_ContractCall(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusNotFound {
-			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("ErrExecContract", func(t *testing.T) {
+func mustOpen(f string) *os.File {
+	r, err := os.Open(f)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func TestContract_ContractLogsHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1546,28 +5887,23 @@ func TestContract_ContractCall(t *testing.T) {
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
 				if id == 1 {
 					return &entity.Contract{
-						ID:           1,
-						Name:         "contract",
-						Description:  "contract description",
-						LastRevision: &entity.Revision{ID: 1},
-						User: &entity.User{
-							ID: 1,
-						},
+						ID:     1,
+						UserID: 1,
 					}, nil
 				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "", apperr.Errorf(apperr.EINTERNAL, "internal error")
-				},
+		s.ServiceHandler.ContractLogService = &mock.ContractLogService{
+			FindLogsByContractIDFn: func(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+				return entity.ContractLogs{
+					{ContractID: contractID, Level: entity.LogLevelInfo, Message: "hello"},
+				}, nil
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call", nil)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/logs", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1579,15 +5915,20 @@ func TestContract_ContractCall(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusInternalServerError {
-			t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
 		}
-	})
-}
 
-func TestContract_ContractCallRev(t *testing.T) {
+		logsResponse := make(map[string]any)
 
-	t.Run("OK", func(t *testing.T) {
+		if err := json.NewDecoder(resp.Body).Decode(&logsResponse); err != nil {
+			t.Fatal(err)
+		} else if logs, ok := logsResponse["logs"].([]any); !ok || len(logs) != 1 {
+			t.Fatalf("expected 1 log, got %v", logsResponse["logs"])
+		}
+	})
+
+	t.Run("NotOwner", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1633,28 +5974,26 @@ func TestContract_ContractCallRev(t *testing.T) {
 		}
 
 		s.ServiceHandler.ContractSearchService = &mock.ContractService{
-			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
-				if contractID == 1 && rev == 1 {
-					return &entity.Revision{
-						ID:         1,
-						Rev:        1,
-						Version:    entity.CurrentRevisionVersion,
-						ContractID: contractID,
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:     1,
+						UserID: 2,
 					}, nil
 				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "OK", nil
-				},
+		s.ServiceHandler.ContractLogService = &mock.ContractLogService{
+			FindLogsByContractIDFn: func(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+				return entity.ContractLogs{
+					{ContractID: contractID, Level: entity.LogLevelInfo, Message: "hello"},
+				}, nil
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/1", nil)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/logs", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1666,18 +6005,15 @@ func TestContract_ContractCallRev(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		ContractCallResult := make(map[string]interface{})
-
-		if resp.StatusCode != http.StatusOK {
-			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
-		} else if err := json.NewDecoder(resp.Body).Decode(&ContractCallResult); err != nil {
-			t.Fatal(err)
-		} else if ContractCallResult["result"] != "OK" {
-			t.Fatalf("expected result %s, got %s", "OK", ContractCallResult["result"])
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("InvalidContractID", func(t *testing.T) {
+func TestContract_ContractExecutionsHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1726,28 +6062,32 @@ func TestContract_ContractCallRev(t *testing.T) {
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
 				if id == 1 {
 					return &entity.Contract{
-						ID:           1,
-						Name:         "contract",
-						Description:  "contract description",
-						LastRevision: &entity.Revision{ID: 1},
-						User: &entity.User{
-							ID: 1,
-						},
+						ID:     1,
+						UserID: 1,
 					}, nil
 				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "OK", nil
-				},
+		s.ServiceHandler.ExecutionSearchService = &mock.ExecutionService{
+			FindExecutionsFn: func(ctx context.Context, filter service.ExecutionFilter) (entity.Executions, int, error) {
+				if filter.ContractID == nil || *filter.ContractID != 1 {
+					t.Errorf("expected contract filter 1, got %v", filter.ContractID)
+				}
+				if filter.Outcome == nil || *filter.Outcome != entity.ExecutionOutcomeError {
+					t.Errorf("expected outcome filter %s, got %v", entity.ExecutionOutcomeError, filter.Outcome)
+				}
+				if filter.Limit != 5 || filter.Offset != 10 {
+					t.Errorf("expected limit 5 and offset 10, got %d and %d", filter.Limit, filter.Offset)
+				}
+				return entity.Executions{
+					{ID: 1, ContractID: 1, Outcome: entity.ExecutionOutcomeError},
+				}, 11, nil
 			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/invalid/call/1", nil)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/executions?outcome=error&limit=5&offset=10", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1759,12 +6099,22 @@ func TestContract_ContractCallRev(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if resp.StatusCode != http.StatusBadRequest {
-			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		executionsResponse := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&executionsResponse); err != nil {
+			t.Fatal(err)
+		} else if executions, ok := executionsResponse["executions"].([]any); !ok || len(executions) != 1 {
+			t.Fatalf("expected 1 execution, got %v", executionsResponse["executions"])
+		} else if total := executionsResponse["total"]; total != float64(11) {
+			t.Errorf("expected total 11, got %v", total)
 		}
 	})
 
-	t.Run("InvalidContractRev", func(t *testing.T) {
+	t.Run("InvalidOutcome", func(t *testing.T) {
 
 		s := MustOpenServerAPI(t)
 		defer MustCloseServerAPI(t, s)
@@ -1813,28 +6163,15 @@ func TestContract_ContractCallRev(t *testing.T) {
 			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
 				if id == 1 {
 					return &entity.Contract{
-						ID:           1,
-						Name:         "contract",
-						Description:  "contract description",
-						LastRevision: &entity.Revision{ID: 1},
-						User: &entity.User{
-							ID: 1,
-						},
+						ID:     1,
+						UserID: 1,
 					}, nil
 				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
 		}
 
-		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
-			ExecutorService: &mock.ExecutorService{
-				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
-					return "OK", nil
-				},
-			},
-		}
-
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/invalid", nil)
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/contract/1/executions?outcome=unknown", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1850,11 +6187,13 @@ func TestContract_ContractCallRev(t *testing.T) {
 			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("ContractRevNotFound", func(t *testing.T) {
+func TestContract_ContractExecutionReplayHandler(t *testing.T) {
+
+	mustOpenReplayServerAPI := func(t *testing.T) *apphttp.ServerAPI {
 
 		s := MustOpenServerAPI(t)
-		defer MustCloseServerAPI(t, s)
 
 		s.ServiceHandler.JWTService = &mock.JWTService{
 			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
@@ -1897,12 +6236,92 @@ func TestContract_ContractCallRev(t *testing.T) {
 		}
 
 		s.ServiceHandler.ContractSearchService = &mock.ContractService{
-			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				if id == 1 {
+					return &entity.Contract{
+						ID:     1,
+						UserID: 1,
+					}, nil
+				}
 				return nil, apperr.Errorf(apperr.ENOTFOUND, "contract not found")
 			},
+			FindRevisionByContractAndRevFn: func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error) {
+				return &entity.Revision{
+					ID:         int64(rev) * 10,
+					Rev:        rev,
+					ContractID: contractID,
+					Contract:   &entity.Contract{ID: contractID, UserID: 1},
+				}, nil
+			},
 		}
 
-		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/call/1", nil)
+		s.ServiceHandler.ExecutionSearchService = &mock.ExecutionService{
+			FindExecutionByIDFn: func(ctx context.Context, id int64) (*entity.Execution, error) {
+				switch id {
+				case 1:
+					return &entity.Execution{ID: 1, ContractID: 1, Rev: 2, Snapshot: &entity.ExecutionSnapshot{Result: "old"}}, nil
+				case 2:
+					return &entity.Execution{ID: 2, ContractID: 2, Rev: 2}, nil
+				}
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "execution not found")
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutionReplayService: &mock.ExecutionReplayService{
+				ReplayExecutionFn: func(ctx context.Context, execution *entity.Execution, revision *entity.Revision) (*entity.ExecutionReplay, error) {
+					return entity.NewExecutionReplay(execution, revision, "new", nil), nil
+				},
+			},
+		}
+
+		return s
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := mustOpenReplayServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/executions/1/replay?rev=3", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		replayResponse := make(map[string]map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&replayResponse); err != nil {
+			t.Fatal(err)
+		}
+
+		replay := replayResponse["replay"]
+
+		if replay["rev"] != float64(3) || replay["original_rev"] != float64(2) {
+			t.Errorf("expected rev 3 and original rev 2, got %v and %v", replay["rev"], replay["original_rev"])
+		}
+
+		if replay["result"] != "new" || replay["original_result"] != "old" || replay["result_changed"] != true {
+			t.Errorf("unexpected result comparison %v", replay)
+		}
+	})
+
+	t.Run("ExecutionOfOtherContract", func(t *testing.T) {
+
+		s := mustOpenReplayServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/executions/2/replay", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1918,12 +6337,26 @@ func TestContract_ContractCallRev(t *testing.T) {
 			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, resp.StatusCode)
 		}
 	})
-}
 
-func mustOpen(f string) *os.File {
-	r, err := os.Open(f)
-	if err != nil {
-		panic(err)
-	}
-	return r
+	t.Run("InvalidRev", func(t *testing.T) {
+
+		s := mustOpenReplayServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/contract/1/executions/1/replay?rev=abc", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
 }
@@ -21,11 +21,22 @@ var codes = map[string]int{
 	apperr.EUNAUTHORIZED:   http.StatusUnauthorized,
 	apperr.EINTERNAL:       http.StatusInternalServerError,
 	apperr.EUNKNOWN:        http.StatusInternalServerError,
+	apperr.ERATELIMIT:      http.StatusTooManyRequests,
+	apperr.ETOOLARGE:       http.StatusRequestEntityTooLarge,
 
 	apperr.EMGVM:         http.StatusInternalServerError,
 	apperr.EMGVM_LOWFUEL: http.StatusInsufficientStorage,
 
+	apperr.EMGVM_USER_LOWFUEL: http.StatusTooManyRequests,
+
+	// the limits of the contract are exceeded, the call can be processed only by a different revision.
+	apperr.EMGVM_MEMORY_LIMIT:     http.StatusUnprocessableEntity,
+	apperr.EMGVM_OUTPUT_TOO_LARGE: http.StatusUnprocessableEntity,
+	apperr.EMGVM_STATE_TOO_LARGE:  http.StatusUnprocessableEntity,
+
 	apperr.EANCHORAGE: http.StatusInternalServerError,
+	apperr.EBOSTON:    http.StatusInternalServerError,
+	apperr.ECHICAGO:   http.StatusInternalServerError,
 }
 
 // MessageFromErr returns the message for the given app error.
@@ -62,7 +73,11 @@ type ErrorAPI struct {
 }
 
 // NewErrorAPI returns an ErrorAPI instance.
+// If no details are passed, the details attached to the app error are returned.
 func NewErrorAPI(err error, details interface{}) *ErrorAPI {
+	if details == nil {
+		details = apperr.ErrorDetails(err)
+	}
 	e := &ErrorAPI{
 		Code:    apperr.ErrorCode(err),
 		Message: MessageFromErr(err),
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"crypto/subtle"
+
 	"github.com/labstack/echo/v4"
 	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
@@ -40,6 +42,43 @@ func (s *ServerAPI) JWTVerifyMiddleware(next echo.HandlerFunc) echo.HandlerFunc
 	}
 }
 
+// PublicRateLimitMiddleware is the middleware for limiting the requests of the anonymous callers.
+// The callers are identified by their IP, if no limiter is set every request is allowed.
+func (s *ServerAPI) PublicRateLimitMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		if s.PublicRateLimiter == nil {
+			return next(c)
+		}
+
+		if allow, err := s.PublicRateLimiter.Allow(c.RealIP()); err != nil {
+			return ErrorResponseJSON(c, apperr.Errorf(apperr.EINTERNAL, "failed to check rate limit: %v", err), nil)
+		} else if !allow {
+			return ErrorResponseJSON(c, apperr.Errorf(apperr.ERATELIMIT, "too many requests, retry later"), nil)
+		}
+
+		return next(c)
+	}
+}
+
+// OperatorTokenMiddleware is the middleware for the routes reserved to the operators of the application.
+// The request must carry the operator token in the X-Operator-Token header, if no token is set the routes are disabled.
+func (s *ServerAPI) OperatorTokenMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		if s.OperatorToken == "" {
+			return ErrorResponseJSON(c, apperr.Errorf(apperr.ENOTIMPLEMENTED, "operator routes are not enabled"), nil)
+		}
+
+		token := c.Request().Header.Get("X-Operator-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.OperatorToken)) != 1 {
+			return ErrorResponseJSON(c, apperr.Errorf(apperr.EUNAUTHORIZED, "invalid operator token"), nil)
+		}
+
+		return next(c)
+	}
+}
+
 // RecoverPanicMiddleware is the middleware for handling panics.
 func (s *ServerAPI) RecoverPanicMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
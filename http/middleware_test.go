@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
 	"github.com/music-gang/music-gang-api/mock"
 )
 
@@ -218,3 +221,56 @@ func TestMiddleware_ReportPanic(t *testing.T) {
 		}
 	})
 }
+
+func TestMiddleware_PublicRateLimit(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		// the burst allows only one request, the rate is too low to allow another one during the test.
+		s.PublicRateLimiter = middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:  0.001,
+			Burst: 1,
+		})
+
+		s.ServiceHandler.ContractSearchService = &mock.ContractService{
+			FindContractByIDFn: func(ctx context.Context, id int64) (*entity.Contract, error) {
+				return &entity.Contract{
+					ID:           id,
+					Visibility:   entity.VisibilityPublic,
+					LastRevision: &entity.Revision{ID: 1, ContractID: id},
+				}, nil
+			},
+		}
+
+		s.ServiceHandler.VmCallableService = &mock.VmCallableService{
+			ExecutorService: &mock.ExecutorService{
+				ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+					if user := app.UserFromContext(ctx); user != nil {
+						t.Errorf("expected anonymous call, got user %d", user.ID)
+					}
+					return "OK", nil
+				},
+			},
+		}
+
+		for i, statusCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+
+			req, err := http.NewRequest(http.MethodPost, s.URL()+"/v1/public/contract/1/call", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != statusCode {
+				t.Fatalf("request %d, expected %d, got %d", i, statusCode, resp.StatusCode)
+			}
+		}
+	})
+}
@@ -19,6 +19,13 @@ import (
 // ShutdownTimeout is the time given for outstanding requests to finish before shutdown.
 const ShutdownTimeout = 1 * time.Second
 
+const (
+	// DefaultPageLimit is the number of items returned by list APIs when limit is not provided.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the max number of items returned by list APIs.
+	MaxPageLimit = 100
+)
+
 // ServerAPI is the main server for the API
 type ServerAPI struct {
 	ln net.Listener
@@ -42,6 +49,16 @@ type ServerAPI struct {
 
 	// loggin service used by HTTP Server.
 	LogService log.Logger
+
+	// PublicRateLimiter limits the requests of the anonymous callers, identified by their IP.
+	// Can be nil if the public routes must not be rate limited.
+	PublicRateLimiter middleware.RateLimiterStore
+
+	// CallBodyLimit is the max size, in bytes, of the input of the contract calls, 0 disables the limit.
+	CallBodyLimit int64
+
+	// OperatorToken is the token required by the operator routes, if empty the operator routes are disabled.
+	OperatorToken string
 }
 
 // NewServerAPI creates a new API server.
@@ -154,6 +171,9 @@ func (s *ServerAPI) registerRoutes(g *echo.Group) {
 
 	contractGroup := g.Group("/contract", s.JWTVerifyMiddleware)
 	s.registerContractRoutes(contractGroup)
+
+	publicGroup := g.Group("/public", s.PublicRateLimitMiddleware)
+	s.registerPublicRoutes(publicGroup)
 }
 
 // registerAuthRoutes registers all routes for the API group auth.
@@ -169,23 +189,47 @@ func (s *ServerAPI) registerAuthRoutes(g *echo.Group) {
 
 // registerContractRoutes registers all routes for the API group contract.
 func (s *ServerAPI) registerContractRoutes(g *echo.Group) {
+	g.GET("", s.ContractsHandler)
 	g.POST("", s.ContractCreateHandler)
 	g.PUT("/:id", s.ContractUpdateHandler)
 	g.GET("/:id", s.ContractHandler)
+	g.DELETE("/:id", s.ContractDeleteHandler)
+	g.POST("/:id/restore", s.ContractRestoreHandler)
 	g.POST("/:id/revision", s.ContractMakeRevisionHandler)
-	g.POST("/:id/call", s.ContractCallHandler)         // latest revision
-	g.POST("/:id/call/:rev", s.ContractCallRevHandler) // specific revision
+	g.GET("/:id/revisions", s.ContractRevisionsHandler)
+	g.GET("/:id/revision/:rev", s.ContractRevisionHandler)
+	g.GET("/:id/revision/:rev/code", s.ContractRevisionCodeHandler)
+	g.PUT("/:id/active-revision", s.ContractActiveRevisionHandler)
+	g.POST("/:id/active-revision/rollback", s.ContractActiveRevisionRollbackHandler)
+	g.GET("/:id/aliases", s.ContractAliasesHandler)
+	g.PUT("/:id/alias/:name", s.ContractAliasSetHandler)
+	g.DELETE("/:id/alias/:name", s.ContractAliasDeleteHandler)
+	g.GET("/:id/logs", s.ContractLogsHandler)
+	g.GET("/:id/executions", s.ContractExecutionsHandler)
+	g.POST("/:id/executions/:execID/replay", s.ContractExecutionReplayHandler)
+	g.GET("/:id/fuel/usage", s.ContractFuelUsageHandler)
+	g.POST("/:id/call", s.ContractCallHandler)         // active revision
+	g.POST("/:id/call/:rev", s.ContractCallRevHandler) // specific revision or @alias
+}
+
+// registerPublicRoutes registers all routes for the API group public, they do not require authentication.
+func (s *ServerAPI) registerPublicRoutes(g *echo.Group) {
+	g.POST("/contract/:id/call", s.PublicContractCallHandler)         // active revision
+	g.POST("/contract/:id/call/:rev", s.PublicContractCallRevHandler) // specific revision or @alias
 }
 
 // registerUserRoutes register all routes for the API group user.
 func (s *ServerAPI) registerUserRoutes(g *echo.Group) {
 	g.GET("", s.UserHandler)
 	g.PUT("", s.UserUpdateHandler)
+	g.GET("/fuel", s.UserFuelHandler)
+	g.GET("/fuel/usage", s.UserFuelUsageHandler)
 }
 
 // registerVmRoutes registers all routes for the API group vm.
 func (s *ServerAPI) registerVmRoutes(g *echo.Group) {
 	g.GET("/stats", s.VmStatsHandler)
+	g.GET("/fuel/usage", s.VmFuelUsageHandler, s.OperatorTokenMiddleware)
 }
 
 // SuccessResponseJSON returns a JSON response with the given status code and data.
@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 )
 
@@ -21,6 +22,38 @@ func (s *ServerAPI) UserHandler(c echo.Context) error {
 	})
 }
 
+// UserFuelHandler is the handler for the /user/fuel API.
+func (s *ServerAPI) UserFuelHandler(c echo.Context) error {
+
+	wallet, err := s.ServiceHandler.UserFuel(c.Request().Context())
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+		"fuel": wallet,
+	})
+}
+
+// UserFuelUsageHandler is the handler for the /user/fuel/usage API.
+// Supported query params are limit, offset, group_by (contract or bucket), bucket, from and to (RFC3339).
+func (s *ServerAPI) UserFuelUsageHandler(c echo.Context) error {
+
+	filter, err := bindFuelUsageFilter(c, entity.FuelUsageGroupByContract)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	usages, err := s.ServiceHandler.UserFuelUsage(c.Request().Context(), filter)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+		"usages": usages,
+	})
+}
+
 // UserUpdateHandler is the handler for the /user update API.
 func (s *ServerAPI) UserUpdateHandler(c echo.Context) error {
 
@@ -257,3 +257,309 @@ func TestUser_Update(t *testing.T) {
 		}
 	})
 }
+
+func TestUser_FuelHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.FuelWalletService = &mock.FuelWalletService{
+			FuelWalletFn: func(ctx context.Context, userID int64) (*entity.FuelWallet, error) {
+				return &entity.FuelWallet{
+					UserID:       userID,
+					FuelCapacity: entity.Fuel(1000),
+					FuelUsed:     entity.Fuel(100),
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/user/fuel", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		var fuelData map[string]interface{}
+
+		if err := json.NewDecoder(resp.Body).Decode(&fuelData); err != nil {
+			t.Fatal(err)
+		}
+
+		if f, ok := fuelData["fuel"]; !ok {
+			t.Error("expected fuel key in fuelData")
+		} else if wallet, ok := f.(map[string]interface{}); !ok {
+			t.Error("expected fuel to be a map")
+		} else if userID := wallet["user_id"]; userID != float64(1) {
+			t.Errorf("expected user_id %f, got %v", float64(1), userID)
+		} else if used := wallet["fuel_used"]; used != float64(100) {
+			t.Errorf("expected fuel_used %f, got %v", float64(100), used)
+		}
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/user/fuel", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, resp.StatusCode)
+		}
+	})
+}
+
+func TestUser_FuelUsageHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.FuelLedgerSearchService = &mock.FuelLedgerService{
+			FindFuelUsagesFn: func(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+				if filter.UserID == nil || *filter.UserID != 1 {
+					t.Errorf("expected user filter 1, got %v", filter.UserID)
+				}
+				if filter.GroupBy != entity.FuelUsageGroupByBucket || filter.Bucket != entity.FuelUsageBucketHour {
+					t.Errorf("expected group by hour bucket, got %s %s", filter.GroupBy, filter.Bucket)
+				}
+				return entity.FuelUsages{
+					{Burned: 100, Refunded: 40, Consumed: 60},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/user/fuel/usage?group_by=bucket&bucket=hour", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		usagesResponse := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&usagesResponse); err != nil {
+			t.Fatal(err)
+		} else if usages, ok := usagesResponse["usages"].([]any); !ok || len(usages) != 1 {
+			t.Fatalf("expected 1 usage, got %v", usagesResponse["usages"])
+		} else if consumed := usages[0].(map[string]any)["consumed"]; consumed != float64(60) {
+			t.Errorf("expected consumed 60, got %v", consumed)
+		}
+	})
+
+	t.Run("InvalidBucket", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.JWTService = &mock.JWTService{
+			ParseFn: func(ctx context.Context, token string) (*entity.AppClaims, error) {
+				if token == "OK" {
+					return &entity.AppClaims{
+						Auth: &entity.Auth{
+							UserID: 1,
+							ID:     1,
+							User:   &entity.User{ID: 1},
+						},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "unauthorized")
+			},
+		}
+
+		s.ServiceHandler.UserSearchService = &mock.UserService{
+			FindUserByIDFn: func(ctx context.Context, id int64) (*entity.User, error) {
+				if id == 1 {
+					return &entity.User{ID: 1}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "user not found")
+			},
+		}
+
+		s.ServiceHandler.AuthSearchService = &mock.AuthService{
+			FindAuthByIDFn: func(ctx context.Context, id int64) (*entity.Auth, error) {
+				if id == 1 {
+					return &entity.Auth{
+						UserID: 1,
+						ID:     1,
+						User:   &entity.User{ID: 1},
+					}, nil
+				}
+
+				return nil, apperr.Errorf(apperr.ENOTFOUND, "auth not found")
+			},
+		}
+
+		s.ServiceHandler.FuelLedgerSearchService = &mock.FuelLedgerService{}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/user/fuel/usage?group_by=bucket&bucket=year", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer OK")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/music-gang/music-gang-api/app/entity"
 )
 
 func (s *ServerAPI) VmStatsHandler(c echo.Context) error {
@@ -15,3 +16,22 @@ func (s *ServerAPI) VmStatsHandler(c echo.Context) error {
 		})
 	}
 }
+
+// VmFuelUsageHandler is the handler for the /vm/fuel/usage API, reserved to the operators.
+// Supported query params are limit, offset, group_by (user, contract or bucket), bucket, from and to (RFC3339).
+// The usages are grouped by user if group_by is not provided.
+func (s *ServerAPI) VmFuelUsageHandler(c echo.Context) error {
+
+	filter, err := bindFuelUsageFilter(c, entity.FuelUsageGroupByUser)
+	if err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	}
+
+	if usages, err := s.ServiceHandler.FuelUsageVM(c.Request().Context(), filter); err != nil {
+		return ErrorResponseJSON(c, err, nil)
+	} else {
+		return SuccessResponseJSON(c, http.StatusOK, echo.Map{
+			"usages": usages,
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/mock"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestVm_FuelUsageHandler(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.OperatorToken = "operator"
+
+		s.ServiceHandler.FuelLedgerSearchService = &mock.FuelLedgerService{
+			FindFuelUsagesFn: func(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+				if filter.UserID != nil || filter.ContractID != nil {
+					t.Errorf("expected no user and contract filter, got %v %v", filter.UserID, filter.ContractID)
+				}
+				if filter.GroupBy != entity.FuelUsageGroupByUser {
+					t.Errorf("expected group by user, got %s", filter.GroupBy)
+				}
+				return entity.FuelUsages{
+					{UserID: null.IntFrom(1), Burned: 100, Refunded: 40, Consumed: 60},
+					{UserID: null.IntFrom(2), Burned: 500, Consumed: 500},
+				}, nil
+			},
+		}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/vm/fuel/usage", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Operator-Token", "operator")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		usagesResponse := make(map[string]any)
+
+		if err := json.NewDecoder(resp.Body).Decode(&usagesResponse); err != nil {
+			t.Fatal(err)
+		} else if usages, ok := usagesResponse["usages"].([]any); !ok || len(usages) != 2 {
+			t.Fatalf("expected 2 usages, got %v", usagesResponse["usages"])
+		} else if userID := usages[1].(map[string]any)["user_id"]; userID != float64(2) {
+			t.Errorf("expected user 2, got %v", userID)
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.OperatorToken = "operator"
+
+		s.ServiceHandler.FuelLedgerSearchService = &mock.FuelLedgerService{}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/vm/fuel/usage", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("X-Operator-Token", "user")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+
+		s := MustOpenServerAPI(t)
+		defer MustCloseServerAPI(t, s)
+
+		s.ServiceHandler.FuelLedgerSearchService = &mock.FuelLedgerService{}
+
+		req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/vm/fuel/usage", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotImplemented, resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+const (
+	ContractLogsKeyTemplate = "contract-%d-logs"
+)
+
+// ContractLogsMaxEntries is the maximum number of log entries kept for each contract.
+var ContractLogsMaxEntries = 500
+
+// ContractLogsRetention is the period after the last write before the logs of a contract expire.
+var ContractLogsRetention = 24 * time.Hour
+
+var _ service.ContractLogService = (*ContractLogService)(nil)
+
+// ContractLogService implements the ContractLogService in memory.
+// Logs are stored in a capped list for each contract.
+type ContractLogService struct {
+	db *DB
+}
+
+// NewContractLogService creates a new ContractLogService.
+func NewContractLogService(db *DB) *ContractLogService {
+	return &ContractLogService{db: db}
+}
+
+// AppendLogs stores the given logs for the contract, then trims the list to the max entries.
+func (s *ContractLogService) AppendLogs(ctx context.Context, contractID int64, logs entity.ContractLogs) error {
+
+	if contractID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "contractID is 0")
+	}
+
+	if len(logs) == 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf(ContractLogsKeyTemplate, contractID)
+
+	return s.db.update(func() error {
+
+		var stored entity.ContractLogs
+		if v, ok := s.db.get(key); ok {
+			stored = v.(entity.ContractLogs)
+		}
+
+		// a new slice is always allocated, so the logs returned by FindLogsByContractID are never modified.
+		merged := make(entity.ContractLogs, 0, len(stored)+len(logs))
+		merged = append(merged, stored...)
+		for _, l := range logs {
+			copied := *l
+			merged = append(merged, &copied)
+		}
+
+		if len(merged) > ContractLogsMaxEntries {
+			merged = merged[len(merged)-ContractLogsMaxEntries:]
+		}
+
+		s.db.set(key, merged, ContractLogsRetention)
+
+		return nil
+	})
+}
+
+// FindLogsByContractID returns the most recent logs of the contract, oldest first.
+// If no logs are found, an empty list is returned.
+func (s *ContractLogService) FindLogsByContractID(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+
+	if contractID == 0 {
+		return nil, apperr.Errorf(apperr.EINVALID, "contractID is 0")
+	}
+
+	logs := make(entity.ContractLogs, 0)
+
+	s.db.update(func() error {
+		if v, ok := s.db.get(fmt.Sprintf(ContractLogsKeyTemplate, contractID)); ok {
+			for _, l := range v.(entity.ContractLogs) {
+				copied := *l
+				logs = append(logs, &copied)
+			}
+		}
+		return nil
+	})
+
+	return logs, nil
+}
@@ -0,0 +1,54 @@
+package inmem_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestContractLog_AppendLogs(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		defaultMaxEntries := inmem.ContractLogsMaxEntries
+		inmem.ContractLogsMaxEntries = 3
+		defer func() {
+			inmem.ContractLogsMaxEntries = defaultMaxEntries
+		}()
+
+		s := inmem.NewContractLogService(db)
+
+		for i := 0; i < 5; i++ {
+			if err := s.AppendLogs(ctx, 1, entity.ContractLogs{{Message: fmt.Sprintf("log %d", i)}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		logs, err := s.FindLogsByContractID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != 3 {
+			t.Fatalf("got %d logs, want 3", len(logs))
+		}
+
+		if logs[0].Message != "log 2" || logs[2].Message != "log 4" {
+			t.Errorf("unexpected logs order: %s ... %s", logs[0].Message, logs[2].Message)
+		}
+
+		if other, err := s.FindLogsByContractID(ctx, 2); err != nil {
+			t.Fatal(err)
+		} else if len(other) != 0 {
+			t.Errorf("got %d logs, want 0", len(other))
+		}
+	})
+}
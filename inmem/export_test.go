@@ -0,0 +1,8 @@
+package inmem
+
+// Len returns the number of keys stored, including the expired ones not purged yet.
+func (db *DB) Len() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return len(db.items)
+}
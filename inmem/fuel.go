@@ -0,0 +1,106 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.FuelTankService = (*FuelTankService)(nil)
+
+// Defines all keys used by the fuel tank.
+const (
+	FuelUsedKey          = "mgvm_fuel_tank_fuel_used"
+	FuelLastRefillAmount = "mgvm_fuel_tank_fuel_last_refill_amount"
+	FuelLastRefillTime   = "mgvm_fuel_tank_fuel_last_refill_time"
+)
+
+// FuelTankService implements the FuelTankService interface in memory.
+// Burn and refuel are atomic, so no lock service is required.
+type FuelTankService struct {
+	db *DB
+}
+
+// NewFuelTankService creates a new FuelTankService.
+func NewFuelTankService(db *DB) *FuelTankService {
+	return &FuelTankService{db: db}
+}
+
+// Burn consumes the specified amount of fuel.
+// Return ErrFuelTankNotEnough if the fuel used is already greater than the fuel tank capacity.
+func (ft *FuelTankService) Burn(ctx context.Context, fuelUsed entity.Fuel) error {
+	return ft.db.update(func() error {
+
+		used := ft.fuel()
+		if used > entity.FuelTankCapacity {
+			return service.ErrFuelTankNotEnough
+		}
+
+		ft.db.set(FuelUsedKey, used+fuelUsed, 0)
+
+		return nil
+	})
+}
+
+// Fuel returns the current amount of fuel used.
+func (ft *FuelTankService) Fuel(ctx context.Context) (fuel entity.Fuel, err error) {
+	ft.db.update(func() error {
+		fuel = ft.fuel()
+		return nil
+	})
+	return fuel, nil
+}
+
+// Refuel refills the fuel tank by the specified amount.
+// If the passed fuel to refill is greater than actual fuel used, the fuel used is set to 0.
+// It returns the fuel actually refilled.
+func (ft *FuelTankService) Refuel(ctx context.Context, fuelToRefill entity.Fuel) (refilled entity.Fuel, err error) {
+	err = ft.db.update(func() error {
+
+		used := ft.fuel()
+		if fuelToRefill > used {
+			fuelToRefill = used
+		}
+
+		ft.db.set(FuelUsedKey, used-fuelToRefill, 0)
+		ft.db.set(FuelLastRefillAmount, fuelToRefill, 0)
+		ft.db.set(FuelLastRefillTime, time.Now().UTC(), 0)
+
+		refilled = fuelToRefill
+
+		return nil
+	})
+	return refilled, err
+}
+
+// Stats returns the current fuel tank stats.
+func (ft *FuelTankService) Stats(ctx context.Context) (stats *entity.FuelStat, err error) {
+	ft.db.update(func() error {
+
+		stats = &entity.FuelStat{
+			FuelCapacity: entity.FuelTankCapacity,
+			FuelUsed:     ft.fuel(),
+		}
+
+		if v, ok := ft.db.get(FuelLastRefillAmount); ok {
+			stats.LastRefuelAmout = v.(entity.Fuel)
+		}
+		if v, ok := ft.db.get(FuelLastRefillTime); ok {
+			stats.LastRefuelAt = v.(time.Time)
+		}
+
+		return nil
+	})
+	return stats, nil
+}
+
+// fuel returns the current amount of fuel used.
+// It must be called inside update.
+func (ft *FuelTankService) fuel() entity.Fuel {
+	if v, ok := ft.db.get(FuelUsedKey); ok {
+		return v.(entity.Fuel)
+	}
+	return entity.Fuel(0)
+}
@@ -0,0 +1,116 @@
+package inmem_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestFuel_Burn(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		fuelTankService := inmem.NewFuelTankService(db)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fuelTankService.Burn(ctx, entity.Fuel(10)); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if fuel, err := fuelTankService.Fuel(ctx); err != nil {
+			t.Fatal(err)
+		} else if fuel != entity.Fuel(1000) {
+			t.Errorf("got %d, want %d", fuel, entity.Fuel(1000))
+		}
+	})
+
+	t.Run("ErrNotEnough", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		fuelTankService := inmem.NewFuelTankService(db)
+
+		if err := fuelTankService.Burn(ctx, entity.FuelTankCapacity+1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fuelTankService.Burn(ctx, entity.Fuel(100)); err == nil {
+			t.Fatal("got nil, want error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_LOWFUEL {
+			t.Errorf("got %v, want %v", errCode, apperr.EMGVM_LOWFUEL)
+		}
+	})
+}
+
+func TestFuel_Refuel(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		fuelTankService := inmem.NewFuelTankService(db)
+
+		if err := fuelTankService.Burn(ctx, entity.Fuel(100)); err != nil {
+			t.Fatal(err)
+		}
+
+		if refilled, err := fuelTankService.Refuel(ctx, entity.Fuel(30)); err != nil {
+			t.Fatal(err)
+		} else if refilled != entity.Fuel(30) {
+			t.Errorf("got %d, want %d", refilled, entity.Fuel(30))
+		}
+
+		if fuel, err := fuelTankService.Fuel(ctx); err != nil {
+			t.Fatal(err)
+		} else if fuel != entity.Fuel(70) {
+			t.Errorf("got %d, want %d", fuel, entity.Fuel(70))
+		}
+
+		// refuel over the fuel used
+
+		if refilled, err := fuelTankService.Refuel(ctx, entity.Fuel(100)); err != nil {
+			t.Fatal(err)
+		} else if refilled != entity.Fuel(70) {
+			t.Errorf("got %d, want %d", refilled, entity.Fuel(70))
+		}
+
+		stats, err := fuelTankService.Stats(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stats.FuelUsed != 0 {
+			t.Errorf("got %d, want 0", stats.FuelUsed)
+		}
+
+		if stats.LastRefuelAmout != entity.Fuel(70) {
+			t.Errorf("got %d, want %d", stats.LastRefuelAmout, entity.Fuel(70))
+		}
+
+		if stats.LastRefuelAt.IsZero() {
+			t.Error("expected last refuel time to be set")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+const (
+	FuelWalletKeyTemplate = "mgvm_fuel_wallet_%d"
+)
+
+var _ service.FuelWalletService = (*FuelWalletService)(nil)
+
+// FuelWalletService implements the FuelWalletService interface in memory.
+type FuelWalletService struct {
+	db *DB
+}
+
+// NewFuelWalletService creates a new FuelWalletService.
+func NewFuelWalletService(db *DB) *FuelWalletService {
+	return &FuelWalletService{db: db}
+}
+
+// Burn consumes the specified amount of fuel from the user wallet.
+func (s *FuelWalletService) Burn(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	_, err := s.updateFuelWallet(userID, func(w *entity.FuelWallet) error {
+		return w.Burn(fuel)
+	})
+	return err
+}
+
+// FuelWallet returns the fuel wallet of the given user.
+func (s *FuelWalletService) FuelWallet(ctx context.Context, userID int64) (*entity.FuelWallet, error) {
+	return s.updateFuelWallet(userID, func(w *entity.FuelWallet) error {
+		return nil
+	})
+}
+
+// Refuel gives back the specified amount of fuel to the user wallet.
+func (s *FuelWalletService) Refuel(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	_, err := s.updateFuelWallet(userID, func(w *entity.FuelWallet) error {
+		w.Refuel(fuel)
+		return nil
+	})
+	return err
+}
+
+// updateFuelWallet loads the wallet, applies the elapsed refills and the given update, then stores it.
+// A copy of the stored wallet is returned.
+func (s *FuelWalletService) updateFuelWallet(userID int64, fn func(w *entity.FuelWallet) error) (*entity.FuelWallet, error) {
+
+	if userID == 0 {
+		return nil, apperr.Errorf(apperr.EINVALID, "userID is 0")
+	}
+
+	key := fmt.Sprintf(FuelWalletKeyTemplate, userID)
+
+	var wallet entity.FuelWallet
+
+	if err := s.db.update(func() error {
+
+		now := time.Now().UTC()
+
+		w := entity.NewFuelWallet(userID, now)
+		if v, ok := s.db.get(key); ok {
+			stored := v.(entity.FuelWallet)
+			w.FuelUsed = stored.FuelUsed
+			w.LastRefuelAt = stored.LastRefuelAt
+			w.Refill(now)
+		}
+
+		if err := fn(w); err != nil {
+			return err
+		}
+
+		s.db.set(key, *w, 0)
+
+		wallet = *w
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &wallet, nil
+}
@@ -0,0 +1,57 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestFuelWallet_Burn(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		s := inmem.NewFuelWalletService(db)
+
+		if err := s.Burn(ctx, 1, entity.Fuel(100)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Refuel(ctx, 1, entity.Fuel(40)); err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet, err := s.FuelWallet(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if wallet.FuelUsed != entity.Fuel(60) {
+			t.Errorf("got %d, want %d", wallet.FuelUsed, entity.Fuel(60))
+		}
+
+		if other, err := s.FuelWallet(ctx, 2); err != nil {
+			t.Fatal(err)
+		} else if other.FuelUsed != 0 {
+			t.Errorf("expected wallets to be isolated, got %d", other.FuelUsed)
+		}
+	})
+
+	t.Run("ErrNotEnough", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		s := inmem.NewFuelWalletService(db)
+
+		if err := s.Burn(context.Background(), 1, entity.UserFuelCapacity+1); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_USER_LOWFUEL {
+			t.Errorf("got %s, want %s", errCode, apperr.EMGVM_USER_LOWFUEL)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CleanupInterval is the interval between two purges of the expired keys.
+var CleanupInterval = time.Minute
+
+// item is a value stored in the DB with an optional expiration.
+type item struct {
+	value     interface{}
+	expiresAt time.Time // zero means no expiration
+}
+
+// expired reports if the item is expired at the given time.
+func (i *item) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+// lockEntry is a held lock, it is released by its owner or when it expires.
+type lockEntry struct {
+	owner     string
+	expiresAt time.Time
+	released  chan struct{} // closed when the owner releases the lock
+}
+
+// DB is a thread-safe in-memory key-value store with TTL expiry, it replaces redis when a single mgd instance runs.
+// The data is not shared between processes, so it must not be used by multiple instances.
+// Expired keys and locks are never returned, they are purged from memory every CleanupInterval.
+type DB struct {
+	mu    sync.Mutex
+	items map[string]*item
+
+	locksMu sync.Mutex
+	locks   map[string]*lockEntry
+
+	ctx    context.Context
+	cancel func()
+}
+
+// NewDB creates a new in-memory DB.
+func NewDB() *DB {
+	db := &DB{
+		items: make(map[string]*item),
+		locks: make(map[string]*lockEntry),
+	}
+
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+
+	return db
+}
+
+// Open starts the purge of the expired keys.
+func (db *DB) Open() error {
+	go db.cleanup()
+	return nil
+}
+
+// Close stops the purge of the expired keys.
+func (db *DB) Close() error {
+	db.cancel()
+	return nil
+}
+
+// cleanup removes the expired keys every CleanupInterval until the DB is closed.
+func (db *DB) cleanup() {
+
+	ticker := time.NewTicker(CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.ctx.Done():
+			return
+		case now := <-ticker.C:
+			db.mu.Lock()
+			for key, i := range db.items {
+				if i.expired(now) {
+					delete(db.items, key)
+				}
+			}
+			db.mu.Unlock()
+
+			db.locksMu.Lock()
+			for name, l := range db.locks {
+				if !now.Before(l.expiresAt) {
+					delete(db.locks, name)
+				}
+			}
+			db.locksMu.Unlock()
+		}
+	}
+}
+
+// update runs fn holding the DB lock, so get and set inside fn are applied atomically.
+func (db *DB) update(fn func() error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return fn()
+}
+
+// get returns the value stored at key, if it exists and it is not expired.
+// It must be called inside update.
+func (db *DB) get(key string) (interface{}, bool) {
+	i, ok := db.items[key]
+	if !ok {
+		return nil, false
+	}
+	if i.expired(time.Now()) {
+		delete(db.items, key)
+		return nil, false
+	}
+	return i.value, true
+}
+
+// set stores the value at key, if ttl is greater than 0 the key expires after ttl.
+// It must be called inside update.
+func (db *DB) set(key string, value interface{}, ttl time.Duration) {
+	i := &item{value: value}
+	if ttl > 0 {
+		i.expiresAt = time.Now().Add(ttl)
+	}
+	db.items[key] = i
+}
+
+// acquireLock takes the lock with the given name for the owner, if it is free or expired.
+// Otherwise it returns a channel closed when the lock is released and the time the lock expires.
+func (db *DB) acquireLock(name, owner string, ttl time.Duration) (bool, <-chan struct{}, time.Time) {
+	db.locksMu.Lock()
+	defer db.locksMu.Unlock()
+
+	now := time.Now()
+
+	if l, ok := db.locks[name]; ok && now.Before(l.expiresAt) {
+		return false, l.released, l.expiresAt
+	}
+
+	db.locks[name] = &lockEntry{
+		owner:     owner,
+		expiresAt: now.Add(ttl),
+		released:  make(chan struct{}),
+	}
+
+	return true, nil, time.Time{}
+}
+
+// releaseLock releases the lock with the given name, if it is held by the owner and it is not expired.
+func (db *DB) releaseLock(name, owner string) bool {
+	db.locksMu.Lock()
+	defer db.locksMu.Unlock()
+
+	l, ok := db.locks[name]
+	if !ok || l.owner != owner || !time.Now().Before(l.expiresAt) {
+		return false
+	}
+
+	delete(db.locks, name)
+	close(l.released)
+
+	return true
+}
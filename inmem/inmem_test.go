@@ -0,0 +1,76 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestDB_Expiration(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		blacklist := inmem.NewJWTBlacklistService(db)
+
+		if err := blacklist.Invalidate(ctx, "token", 50*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := blacklist.IsBlacklisted(ctx, "token"); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected token to be blacklisted")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		if ok, err := blacklist.IsBlacklisted(ctx, "token"); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Error("expected token to be expired")
+		}
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+
+		defaultInterval := inmem.CleanupInterval
+		inmem.CleanupInterval = 10 * time.Millisecond
+		defer func() {
+			inmem.CleanupInterval = defaultInterval
+		}()
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		blacklist := inmem.NewJWTBlacklistService(db)
+
+		if err := blacklist.Invalidate(context.Background(), "token", 20*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		if n := db.Len(); n != 0 {
+			t.Errorf("expected expired keys to be purged, got %d keys", n)
+		}
+	})
+}
+
+func MustOpenDB(tb testing.TB) *inmem.DB {
+
+	tb.Helper()
+
+	db := inmem.NewDB()
+	if err := db.Open(); err != nil {
+		tb.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,37 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.JWTBlacklistService = (*JWTBlacklistService)(nil)
+
+// JWTBlacklistService is a service for managing black list JWT tokens in memory.
+type JWTBlacklistService struct {
+	db *DB
+}
+
+// NewJWTBlacklistService creates a new JWTBlacklistService.
+func NewJWTBlacklistService(db *DB) *JWTBlacklistService {
+	return &JWTBlacklistService{db: db}
+}
+
+// Invalidate a JWT token until the expiration.
+func (s *JWTBlacklistService) Invalidate(ctx context.Context, token string, expiration time.Duration) error {
+	return s.db.update(func() error {
+		s.db.set(token, true, expiration)
+		return nil
+	})
+}
+
+// IsBlacklisted checks if a token is blacklisted.
+func (s *JWTBlacklistService) IsBlacklisted(ctx context.Context, token string) (blacklisted bool, err error) {
+	s.db.update(func() error {
+		_, blacklisted = s.db.get(token)
+		return nil
+	})
+	return blacklisted, nil
+}
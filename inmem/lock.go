@@ -0,0 +1,65 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.LockService = (*LockService)(nil)
+
+// LockExpiry is how long a lock is held if its owner never releases it, as the expiry of the redis locks.
+var LockExpiry = 8 * time.Second
+
+// LockService implements a lock shared by all the LockService with the same name created from the same DB.
+// Every LockService is a different owner, even if created with the same name.
+type LockService struct {
+	db   *DB
+	name string
+	id   string
+}
+
+// NewLockService creates a new LockService.
+func NewLockService(db *DB, name string) *LockService {
+	return &LockService{db: db, name: name, id: uuid.NewString()}
+}
+
+// LockContext locks the lock, waiting until it is released, it expires or the context is done.
+func (l *LockService) LockContext(ctx context.Context) error {
+
+	for {
+		acquired, released, expiresAt := l.db.acquireLock(l.name, l.id, LockExpiry)
+		if acquired {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(expiresAt))
+
+		select {
+		case <-released:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return apperr.Errorf(apperr.EINTERNAL, "failed to acquire lock: %s", ctx.Err())
+		}
+
+		timer.Stop()
+	}
+}
+
+// Name returns the name of the lock.
+func (l *LockService) Name() string {
+	return l.name
+}
+
+// UnlockContext unlocks the lock.
+// It fails if the lock is not held by this LockService, because it is free, expired or locked by another owner.
+func (l *LockService) UnlockContext(ctx context.Context) (bool, error) {
+	if !l.db.releaseLock(l.name, l.id) {
+		return false, apperr.Errorf(apperr.EINTERNAL, "failed to release lock: lock %s is not held", l.name)
+	}
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestLock_LockContext(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		first := inmem.NewLockService(db, "test")
+		second := inmem.NewLockService(db, "test")
+
+		if err := first.LockContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		locked := make(chan struct{})
+
+		go func() {
+			if err := second.LockContext(ctx); err != nil {
+				t.Error(err)
+			}
+			close(locked)
+		}()
+
+		select {
+		case <-locked:
+			t.Fatal("expected second lock to wait for the first one")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if ok, err := first.UnlockContext(ctx); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected lock to be released")
+		}
+
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Fatal("expected second lock to be acquired")
+		}
+	})
+
+	t.Run("ContextCancel", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		if err := inmem.NewLockService(db, "test").LockContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err := inmem.NewLockService(db, "test").LockContext(ctx); err == nil {
+			t.Fatal("got nil, want error")
+		}
+
+		// locks with a different name are independent
+		if err := inmem.NewLockService(db, "other").LockContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLock_UnlockContext(t *testing.T) {
+
+	t.Run("NotOwner", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		owner := inmem.NewLockService(db, "test")
+		other := inmem.NewLockService(db, "test")
+
+		if err := owner.LockContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := other.UnlockContext(ctx); err == nil {
+			t.Fatal("got nil, want error")
+		} else if ok {
+			t.Error("expected lock not to be released")
+		}
+
+		// the lock is still held by the owner.
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		if err := other.LockContext(timeoutCtx); err == nil {
+			t.Fatal("expected lock to be still held")
+		}
+
+		if ok, err := owner.UnlockContext(ctx); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected lock to be released")
+		}
+	})
+
+	t.Run("NotLocked", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		if ok, err := inmem.NewLockService(db, "test").UnlockContext(context.Background()); err == nil {
+			t.Fatal("got nil, want error")
+		} else if ok {
+			t.Error("expected lock not to be released")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		defer func(expiry time.Duration) { inmem.LockExpiry = expiry }(inmem.LockExpiry)
+		inmem.LockExpiry = 50 * time.Millisecond
+
+		ctx := context.Background()
+
+		owner := inmem.NewLockService(db, "test")
+		other := inmem.NewLockService(db, "test")
+
+		if err := owner.LockContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// the owner never unlocks, the lock is acquired when it expires.
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		if err := other.LockContext(timeoutCtx); err != nil {
+			t.Fatal(err)
+		}
+
+		if ok, err := owner.UnlockContext(ctx); err == nil {
+			t.Fatal("got nil, want error")
+		} else if ok {
+			t.Error("expected lock not to be released")
+		}
+
+		if ok, err := other.UnlockContext(ctx); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Error("expected lock to be released")
+		}
+	})
+}
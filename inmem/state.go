@@ -0,0 +1,91 @@
+package inmem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+const (
+	StateKeyTemplate = "state-user-%d-revision-%d"
+)
+
+var StateCachePeriod = 10 * time.Minute
+
+var _ service.StateCacheService = (*StateService)(nil)
+var _ service.StateSearchService = (*StateService)(nil)
+
+// StateService implements the StateService in memory.
+// States are stored marshaled, so the cached state cannot be modified by the caller.
+type StateService struct {
+	db *DB
+}
+
+// NewStateService creates a new StateService.
+func NewStateService(db *DB) *StateService {
+	return &StateService{db: db}
+}
+
+// CacheState caches a state for StateCachePeriod.
+func (s *StateService) CacheState(ctx context.Context, state *entity.State) error {
+
+	userID := app.UserIDFromContext(ctx)
+	if userID == 0 {
+		return apperr.Errorf(apperr.EUNAUTHORIZED, "user not authorized")
+	}
+
+	if state.RevisionID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "revisionID is 0")
+	}
+
+	rawVal, err := json.Marshal(state)
+	if err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to marshal state: %v", err)
+	}
+
+	return s.db.update(func() error {
+		s.db.set(fmt.Sprintf(StateKeyTemplate, userID, state.RevisionID), rawVal, StateCachePeriod)
+		return nil
+	})
+}
+
+// FindStateByRevisionID finds a state by revision ID and user injected into the context.
+// Return ENOTFOUND if no state is found.
+func (s *StateService) FindStateByRevisionID(ctx context.Context, revisionID int64) (*entity.State, error) {
+
+	userID := app.UserIDFromContext(ctx)
+	if userID == 0 {
+		return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "user not authorized")
+	}
+
+	if revisionID == 0 {
+		return nil, apperr.Errorf(apperr.EINVALID, "revisionID is 0")
+	}
+
+	var rawVal []byte
+
+	s.db.update(func() error {
+		if v, ok := s.db.get(fmt.Sprintf(StateKeyTemplate, userID, revisionID)); ok {
+			rawVal = v.([]byte)
+		}
+		return nil
+	})
+
+	if rawVal == nil {
+		return nil, apperr.Errorf(apperr.ENOTFOUND, "state not found")
+	}
+
+	var state entity.State
+
+	if err := json.Unmarshal(rawVal, &state); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to unmarshal state: %v", err)
+	}
+
+	return &state, nil
+}
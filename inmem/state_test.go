@@ -0,0 +1,68 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/inmem"
+)
+
+func TestState_CacheState(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := app.NewContextWithUser(context.Background(), &entity.User{ID: 1})
+
+		s := inmem.NewStateService(db)
+
+		state := &entity.State{
+			RevisionID: 1,
+			Value:      entity.StateValue{"counter": float64(1)},
+		}
+
+		if err := s.CacheState(ctx, state); err != nil {
+			t.Fatal(err)
+		}
+
+		// the cached state must not change with the original one
+		state.Value["counter"] = float64(2)
+
+		cached, err := s.FindStateByRevisionID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cached.Value["counter"] != float64(1) {
+			t.Errorf("got %v, want %v", cached.Value["counter"], float64(1))
+		}
+
+		// states are cached per user
+		otherCtx := app.NewContextWithUser(context.Background(), &entity.User{ID: 2})
+
+		if _, err := s.FindStateByRevisionID(otherCtx, 1); err == nil {
+			t.Fatal("got nil, want error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ENOTFOUND {
+			t.Errorf("got %v, want %v", errCode, apperr.ENOTFOUND)
+		}
+	})
+
+	t.Run("ErrUnauthorized", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		s := inmem.NewStateService(db)
+
+		if err := s.CacheState(context.Background(), &entity.State{RevisionID: 1}); err == nil {
+			t.Fatal("got nil, want error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EUNAUTHORIZED {
+			t.Errorf("got %v, want %v", errCode, apperr.EUNAUTHORIZED)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package mgvm
 
 import (
 	"context"
+	"io"
 	"sync/atomic"
 
 	"github.com/music-gang/music-gang-api/app/apperr"
@@ -26,6 +27,19 @@ func NewEngine() *Engine {
 	}
 }
 
+// CompileContract validates the code of the revision with the executor of its version.
+// The engine does not need to be running, the code is never executed.
+// Return EINVALID if there is no executor for the revision version.
+func (e *Engine) CompileContract(ctx context.Context, revision *entity.Revision) error {
+
+	executor, err := e.getExecutor(revision.Version)
+	if err != nil {
+		return apperr.Errorf(apperr.EINVALID, "unsupported revision version '%s'", revision.Version)
+	}
+
+	return executor.CompileContract(ctx, revision)
+}
+
 // ExecContract effectively executes the contract and returns the result.
 // If the engine goes into execution timeout, it panics with EngineExecutionTimeoutPanic.
 func (e *Engine) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
@@ -87,6 +101,19 @@ func (e *Engine) Stop() error {
 	return nil
 }
 
+// Close releases the resources of the executors, like their compiled modules.
+// It does not stop the engine, it should be called once the engine is stopped for good.
+func (e *Engine) Close() error {
+	for _, ex := range e.Executors {
+		if closer, ok := ex.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // getExecutor returns the executor for the given revision version.
 func (e *Engine) getExecutor(version entity.RevisionVersion) (service.ContractExecutorService, error) {
 	ex, ok := e.Executors[version]
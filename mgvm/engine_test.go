@@ -161,3 +161,35 @@ func TestEngine_ExecContract(t *testing.T) {
 		}
 	})
 }
+
+func TestEngine_Close(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		engine := mgvm.NewEngine()
+
+		closed := false
+
+		engine.Executors[entity.AnchorageVersion] = &mock.ExecutorService{}
+		engine.Executors[entity.ChicagoVersion] = &closerExecutor{closeFn: func() error {
+			closed = true
+			return nil
+		}}
+
+		if err := engine.Close(); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		} else if !closed {
+			t.Errorf("Expected the executor to be closed")
+		}
+	})
+}
+
+// closerExecutor is an executor that releases its resources on Close.
+type closerExecutor struct {
+	mock.ExecutorService
+	closeFn func() error
+}
+
+func (e *closerExecutor) Close() error {
+	return e.closeFn()
+}
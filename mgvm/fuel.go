@@ -37,7 +37,10 @@ type FuelTank struct {
 	// FuelTankService is the service for managing the fuel tank.
 	// FuelTank delegates to this service to achieve scalability.
 	FuelTankService service.FuelTankService
-	LockService     service.LockService
+	// LockService serializes the operations on the FuelTankService.
+	// Can be nil if the FuelTankService checks the capacity and applies burn and refuel atomically by itself,
+	// like redis.FuelTankService does.
+	LockService service.LockService
 
 	// lastRefuelAmount is the last amount of fuel used to refill the virtual fuel tank.
 	// It should be used only in case the sync is not required.
@@ -66,7 +69,8 @@ func (ft *FuelTank) Fuel(ctx context.Context) (entity.Fuel, error) {
 }
 
 // Refuel refills the virtual fuel tank with the specified amount of fuel.
-func (ft *FuelTank) Refuel(ctx context.Context, fuelToRefill entity.Fuel) error {
+// It returns the fuel actually refilled.
+func (ft *FuelTank) Refuel(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 	return refuel(ctx, ft, fuelToRefill)
 }
 
@@ -86,6 +90,10 @@ func (ft *FuelTank) localFuel() entity.Fuel {
 // It sync the fuel tank between the local counter and the remote service.
 func burn(ctx context.Context, ft *FuelTank, fuel entity.Fuel) error {
 
+	if ft.LockService == nil {
+		return atomicBurn(ctx, ft, fuel)
+	}
+
 	// first, we need to aquire the lock
 
 	if err := ft.LockService.LockContext(ctx); err != nil {
@@ -129,12 +137,17 @@ func fuel(ctx context.Context, ft *FuelTank, local bool) (entity.Fuel, error) {
 // refuel refills the fuel tank by the specified amount.
 // It sync the fuel tank between the local counter and the remote service.
 // If the passed fuel to refill is greater then actual fuel used, it sets fuel used to 0.
-func refuel(ctx context.Context, ft *FuelTank, refillFuel entity.Fuel) error {
+// It returns the fuel actually refilled.
+func refuel(ctx context.Context, ft *FuelTank, refillFuel entity.Fuel) (entity.Fuel, error) {
+
+	if ft.LockService == nil {
+		return atomicRefuel(ctx, ft, refillFuel)
+	}
 
 	// first, we need to aquire the lock
 
 	if err := ft.LockService.LockContext(ctx); err != nil {
-		return err
+		return 0, err
 	}
 	defer ft.LockService.UnlockContext(ctx)
 
@@ -142,7 +155,7 @@ func refuel(ctx context.Context, ft *FuelTank, refillFuel entity.Fuel) error {
 
 	fuelUsed, err := ft.Fuel(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// third, we need to check if the fuel to refill is not greater than fuel used, otherwise 0 is set
@@ -151,25 +164,70 @@ func refuel(ctx context.Context, ft *FuelTank, refillFuel entity.Fuel) error {
 		refillFuel = fuelUsed
 	}
 
-	fuelUsedAfterRefill := fuelUsed - refillFuel
-
-	// fourth, we need to update the synchronized fuel tank
-	if err := ft.FuelTankService.Refuel(ctx, refillFuel); err != nil {
-		return err
+	// fourth, we need to update the synchronized fuel tank, under the lock it refills the whole clamped amount
+	refillFuel, err = ft.FuelTankService.Refuel(ctx, refillFuel)
+	if err != nil {
+		return 0, err
 	}
 
+	fuelUsedAfterRefill := fuelUsed - refillFuel
+
 	// fifth, we need to update the local fuel tank and capacity
 	atomic.StoreUint64((*uint64)(&ft.localFuelUsed), uint64(fuelUsedAfterRefill))
 	atomic.StoreUint64((*uint64)(&ft.lastRefuelAmount), uint64(refillFuel))
 	atomic.StoreInt64((*int64)(&ft.LastRefuelAt), time.Now().UTC().Unix())
 
+	return refillFuel, nil
+}
+
+// atomicBurn consumes the specified amount of fuel without any lock.
+// The capacity check is delegated to the FuelTankService, that must return ErrFuelTankNotEnough when the fuel tank is empty.
+func atomicBurn(ctx context.Context, ft *FuelTank, fuel entity.Fuel) error {
+
+	if err := ft.FuelTankService.Burn(ctx, fuel); err != nil {
+		return err
+	}
+
+	atomic.AddUint64((*uint64)(&ft.localFuelUsed), uint64(fuel))
+
 	return nil
 }
 
+// atomicRefuel refills the fuel tank by the specified amount without any lock.
+// The FuelTankService clamps the refill to the fuel used and returns it, the local counter is clamped in the same way.
+func atomicRefuel(ctx context.Context, ft *FuelTank, refillFuel entity.Fuel) (entity.Fuel, error) {
+
+	refillFuel, err := ft.FuelTankService.Refuel(ctx, refillFuel)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		localFuelUsed := atomic.LoadUint64((*uint64)(&ft.localFuelUsed))
+
+		refilled := uint64(refillFuel)
+		if refilled > localFuelUsed {
+			refilled = localFuelUsed
+		}
+
+		if atomic.CompareAndSwapUint64((*uint64)(&ft.localFuelUsed), localFuelUsed, localFuelUsed-refilled) {
+			atomic.StoreUint64((*uint64)(&ft.lastRefuelAmount), refilled)
+			break
+		}
+	}
+
+	atomic.StoreInt64((*int64)(&ft.LastRefuelAt), time.Now().UTC().Unix())
+
+	return refillFuel, nil
+}
+
 // Stats returns the current amount of fuel used.
 func stats(ctx context.Context, ft *FuelTank, useRemoteFuel bool) (*entity.FuelStat, error) {
 
 	if useRemoteFuel {
+		if ft.LockService == nil {
+			return ft.FuelTankService.Stats(ctx)
+		}
 		if err := ft.LockService.LockContext(ctx); err != nil {
 			return nil, err
 		}
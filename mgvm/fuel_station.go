@@ -21,6 +21,15 @@ type FuelStation struct {
 	FuelTankService service.FuelTankService
 	LogService      log.Logger
 
+	// FuelLedger records every refill of the fuel station.
+	// Can be nil if the refills must not be recorded.
+	FuelLedger service.FuelLedgerRecorderService
+
+	// LeaderService elects the only fuel station that refuels the shared fuel tank,
+	// so the tank is refilled at FuelRefillRate however many instances are running.
+	// Can be nil if only one instance runs the fuel station.
+	LeaderService service.LeaderService
+
 	FuelRefillAmount entity.Fuel
 	FuelRefillRate   time.Duration
 }
@@ -92,9 +101,32 @@ func internalRefueler(ctx context.Context, fs *FuelStation) (err error) {
 		}
 	}()
 
-	if err := fs.FuelTankService.Refuel(ctx, fs.FuelRefillAmount); err != nil {
+	if fs.LeaderService != nil {
+		isLeader, err := fs.LeaderService.IsLeader(ctx)
+		if err != nil {
+			return err
+		}
+		if !isLeader {
+			// another instance is refueling the fuel tank
+			return nil
+		}
+	}
+
+	refilled, err := fs.FuelTankService.Refuel(ctx, fs.FuelRefillAmount)
+	if err != nil {
 		return err
 	}
 
+	// the refill is clamped to the fuel used, a full tank records nothing.
+	if fs.FuelLedger != nil && refilled > 0 {
+		if err := fs.FuelLedger.RecordFuelEntries(ctx, entity.FuelLedgerEntries{{
+			Kind:      entity.FuelLedgerKindRefill,
+			Amount:    refilled,
+			CreatedAt: time.Now().UTC(),
+		}}); err != nil {
+			fs.LogService.Error(apperr.ErrorLog(err))
+		}
+	}
+
 	return nil
 }
@@ -2,6 +2,8 @@ package mgvm_test
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -63,9 +65,9 @@ func TestFuelStation_ResumeRefueling(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return 0, nil
 			},
-			RefuelFn: func(ctx context.Context, amount entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, amount entity.Fuel) (entity.Fuel, error) {
 				errChan <- apperr.Errorf(apperr.EMGVM, "test error")
-				return apperr.Errorf(apperr.EMGVM, "test error")
+				return 0, apperr.Errorf(apperr.EMGVM, "test error")
 			},
 		}
 
@@ -99,7 +101,7 @@ func TestFuelStation_ResumeRefueling(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return 0, nil
 			},
-			RefuelFn: func(ctx context.Context, amount entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, amount entity.Fuel) (entity.Fuel, error) {
 				panic("test panic")
 			},
 		}
@@ -126,8 +128,8 @@ func TestFuelStation_ResumeRefueling(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return 0, nil
 			},
-			RefuelFn: func(ctx context.Context, amount entity.Fuel) error {
-				return nil
+			RefuelFn: func(ctx context.Context, amount entity.Fuel) (entity.Fuel, error) {
+				return amount, nil
 			},
 		}
 
@@ -141,6 +143,152 @@ func TestFuelStation_ResumeRefueling(t *testing.T) {
 			t.Errorf("Expected error, got none")
 		}
 	})
+
+	t.Run("LedgerRecordsRefilled", func(t *testing.T) {
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		defer cancel()
+
+		var mu sync.Mutex
+		var entries entity.FuelLedgerEntries
+		var refuels int64
+
+		fuelStation := mgvm.NewFuelStation()
+		fuelStation.FuelRefillRate = 50 * time.Millisecond
+		fuelStation.FuelRefillAmount = entity.Fuel(100)
+
+		fuelStation.LogService = &mock.LoggerNoOp{}
+		fuelStation.FuelTankService = &mock.FuelTankService{
+			RefuelFn: func(ctx context.Context, amount entity.Fuel) (entity.Fuel, error) {
+				// only 30 of fuel is used, then the tank is full.
+				if atomic.AddInt64(&refuels, 1) == 1 {
+					return 30, nil
+				}
+				return 0, nil
+			},
+		}
+		fuelStation.FuelLedger = &mock.FuelLedgerService{
+			RecordFuelEntriesFn: func(ctx context.Context, e entity.FuelLedgerEntries) error {
+				mu.Lock()
+				defer mu.Unlock()
+				entries = append(entries, e...)
+				return nil
+			},
+		}
+
+		if err := fuelStation.ResumeRefueling(ctx); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+
+		if err := fuelStation.StopRefueling(ctx); err != nil {
+			t.Errorf("Expected nil error, got %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if atomic.LoadInt64(&refuels) < 2 {
+			t.Fatalf("Expected at least 2 refuels, got %d", refuels)
+		} else if len(entries) != 1 {
+			t.Fatalf("Expected 1 ledger entry, got %d", len(entries))
+		} else if entries[0].Kind != entity.FuelLedgerKindRefill || entries[0].Amount != entity.Fuel(30) {
+			t.Errorf("Expected a refill of 30, got %s of %d", entries[0].Kind, entries[0].Amount)
+		}
+	})
+
+	t.Run("MultipleStations", func(t *testing.T) {
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		defer cancel()
+
+		const (
+			refillRate = 50 * time.Millisecond
+			lease      = 3 * refillRate
+		)
+
+		var mu sync.Mutex
+		var leader int
+		var leaseExpiresAt time.Time
+		var refuels int64
+
+		// simulate a lease based election shared by all the stations, like the redis one.
+		newLeaderService := func(id int) *mock.LeaderService {
+			return &mock.LeaderService{
+				IsLeaderFn: func(ctx context.Context) (bool, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					if leader == id || time.Now().After(leaseExpiresAt) {
+						leader = id
+						leaseExpiresAt = time.Now().Add(lease)
+						return true, nil
+					}
+					return false, nil
+				},
+			}
+		}
+
+		// all the stations refuel the same fuel tank
+		fuelTank := &mock.FuelTankService{
+			RefuelFn: func(ctx context.Context, amount entity.Fuel) (entity.Fuel, error) {
+				atomic.AddInt64(&refuels, 1)
+				return amount, nil
+			},
+		}
+
+		stations := make(map[int]*mgvm.FuelStation)
+
+		for id := 1; id <= 3; id++ {
+			fuelStation := mgvm.NewFuelStation()
+			fuelStation.FuelRefillRate = refillRate
+			fuelStation.FuelRefillAmount = entity.Fuel(10)
+			fuelStation.LogService = &mock.LoggerNoOp{}
+			fuelStation.FuelTankService = fuelTank
+			fuelStation.LeaderService = newLeaderService(id)
+
+			if err := fuelStation.ResumeRefueling(ctx); err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+
+			stations[id] = fuelStation
+		}
+
+		time.Sleep(10*refillRate + refillRate/2)
+
+		// a single station would have refueled 10 times
+		if n := atomic.LoadInt64(&refuels); n < 8 || n > 11 {
+			t.Errorf("Expected the tank to be refueled as by a single station, got %d refuels", n)
+		}
+
+		// stop the leader, another station must take over when the lease expires
+
+		mu.Lock()
+		currentLeader := leader
+		mu.Unlock()
+
+		if err := stations[currentLeader].StopRefueling(ctx); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+
+		atomic.StoreInt64(&refuels, 0)
+
+		time.Sleep(10*refillRate + refillRate/2)
+
+		if n := atomic.LoadInt64(&refuels); n < 5 || n > 11 {
+			t.Errorf("Expected another station to take over, got %d refuels", n)
+		}
+
+		mu.Lock()
+		newLeader := leader
+		mu.Unlock()
+
+		if newLeader == currentLeader {
+			t.Errorf("Expected a new leader, got %d", newLeader)
+		}
+	})
 }
 
 func TestFuelStation_StopRefueling(t *testing.T) {
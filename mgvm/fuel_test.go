@@ -177,6 +177,38 @@ func TestFuelTank_Burn(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("WithoutLock", func(t *testing.T) {
+
+		currentFuel := entity.Fuel(0)
+
+		fuelTank := mgvm.NewFuelTank()
+
+		ctx := context.Background()
+
+		// without lock the capacity check is delegated to the fuel tank service.
+		fuelTank.FuelTankService = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				if currentFuel > entity.FuelTankCapacity {
+					return service.ErrFuelTankNotEnough
+				}
+				currentFuel += fuel
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				t.Error("fuel must not be read before the burn without lock")
+				return currentFuel, nil
+			},
+		}
+
+		if err := fuelTank.Burn(ctx, entity.FuelTankCapacity+1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := fuelTank.Burn(ctx, entity.Fuel(10)); err != service.ErrFuelTankNotEnough {
+			t.Fatalf("unexpected error, got: %v, want: %v", err, service.ErrFuelTankNotEnough)
+		}
+	})
 }
 
 func TestFuelTank_Fuel(t *testing.T) {
@@ -273,9 +305,9 @@ func TestFuelTank_Refuel(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return currentFuel, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				currentFuel -= fuelToRefill
-				return nil
+				return fuelToRefill, nil
 			},
 		}
 
@@ -299,7 +331,7 @@ func TestFuelTank_Refuel(t *testing.T) {
 			t.Fatalf("unexpected currentFuel, got: %v, want: %v", cf, currentFuel)
 		}
 
-		if err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
+		if _, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
@@ -311,8 +343,10 @@ func TestFuelTank_Refuel(t *testing.T) {
 
 		// refuel over the limit
 
-		if err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
+		if refilled, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
 			t.Fatalf("unexpected error: %v", err)
+		} else if refilled != 20 {
+			t.Fatalf("unexpected refilled fuel, got: %v, want: %v", refilled, 20)
 		} else if cf, err := fuelTank.Fuel(ctx); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		} else if cf != 0 {
@@ -330,7 +364,7 @@ func TestFuelTank_Refuel(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		} else if cf != 50 {
 			t.Fatalf("unexpected currentFuel, got: %v, want: %v", cf, currentFuel)
-		} else if err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
+		} else if _, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		} else if cf, err := fuelTank.Fuel(ctx); err != nil {
 			t.Fatalf("unexpected error: %v", err)
@@ -369,8 +403,8 @@ func TestFuelTank_Refuel(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return currentFuel, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				return apperr.Errorf(apperr.EINTERNAL, "refuel-mock")
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return 0, apperr.Errorf(apperr.EINTERNAL, "refuel-mock")
 			},
 		}
 
@@ -394,7 +428,7 @@ func TestFuelTank_Refuel(t *testing.T) {
 			t.Fatalf("unexpected currentFuel, got: %v, want: %v", cf, currentFuel)
 		}
 
-		if err := fuelTank.Refuel(ctx, entity.Fuel(30)); err == nil {
+		if _, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err == nil {
 			t.Fatalf("expected error")
 		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINTERNAL {
 			t.Fatalf("unexpected error code, got: %v, want: %v", errCode, apperr.EINTERNAL)
@@ -419,9 +453,9 @@ func TestFuelTank_Refuel(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return currentFuel, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				currentFuel -= fuelToRefill
-				return nil
+				return fuelToRefill, nil
 			},
 		}
 
@@ -453,13 +487,13 @@ func TestFuelTank_Refuel(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return currentFuel, apperr.Errorf(apperr.EINTERNAL, "fuel-mock")
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				currentFuel -= fuelToRefill
-				return nil
+				return fuelToRefill, nil
 			},
 		}
 
-		if err := fuelTank.Refuel(ctx, entity.Fuel(30)); err == nil {
+		if _, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err == nil {
 			t.Fatalf("expected error, got: %v", err)
 		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINTERNAL {
 			t.Fatalf("unexpected error code, got: %v, want: %v", errCode, apperr.EINTERNAL)
@@ -467,6 +501,57 @@ func TestFuelTank_Refuel(t *testing.T) {
 			t.Fatalf("unexpected error message, got: %v, want: %v", errMsg, "fuel-mock")
 		}
 	})
+
+	t.Run("WithoutLock", func(t *testing.T) {
+
+		currentFuel := entity.Fuel(0)
+
+		fuelTank := mgvm.NewFuelTank()
+
+		ctx := context.Background()
+
+		fuelTank.FuelTankService = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				currentFuel += fuel
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				return currentFuel, nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				if fuelToRefill > currentFuel {
+					fuelToRefill = currentFuel
+				}
+				currentFuel -= fuelToRefill
+				return fuelToRefill, nil
+			},
+		}
+
+		mgvm.SwitchToLocalFuel()
+		defer mgvm.SwitchToRemoteFuel()
+
+		if err := fuelTank.Burn(ctx, entity.Fuel(50)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if _, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if cf, err := fuelTank.Fuel(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if cf != 20 {
+			t.Fatalf("unexpected currentFuel, got: %v, want: %v", cf, 20)
+		}
+
+		// refuel over the limit, the local counter is clamped like the remote one
+
+		if refilled, err := fuelTank.Refuel(ctx, entity.Fuel(30)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if refilled != 20 {
+			t.Fatalf("unexpected refilled fuel, got: %v, want: %v", refilled, 20)
+		} else if cf, err := fuelTank.Fuel(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if cf != 0 || currentFuel != 0 {
+			t.Fatalf("unexpected currentFuel, got: %v/%v, want: 0", cf, currentFuel)
+		}
+	})
 }
 
 func TestFuelTank_Stats(t *testing.T) {
@@ -491,9 +576,9 @@ func TestFuelTank_Stats(t *testing.T) {
 				currentFuel += fuel
 				return nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				currentFuel -= fuelToRefill
-				return nil
+				return fuelToRefill, nil
 			},
 			StatsFn: func(ctx context.Context) (*entity.FuelStat, error) {
 				return &entity.FuelStat{
@@ -519,7 +604,7 @@ func TestFuelTank_Stats(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if err := fuelTank.Refuel(ctx, entity.Fuel(5)); err != nil {
+		if _, err := fuelTank.Refuel(ctx, entity.Fuel(5)); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 
@@ -568,9 +653,9 @@ func TestFuelTank_Concurrency(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return currentFuel, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				currentFuel -= fuelToRefill
-				return nil
+				return fuelToRefill, nil
 			},
 		}
 
@@ -618,7 +703,7 @@ func TestFuelTank_Concurrency(t *testing.T) {
 
 				time.Sleep(time.Millisecond * 500)
 
-				if err := tank.Refuel(ctx, entity.Fuel(50)); err != nil {
+				if _, err := tank.Refuel(ctx, entity.Fuel(50)); err != nil {
 					tb.Fatalf("unexpected error: %v", err)
 				}
 
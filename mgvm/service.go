@@ -2,6 +2,7 @@ package mgvm
 
 import (
 	"context"
+	"time"
 
 	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
@@ -146,6 +147,29 @@ func (vm *MusicGangVM) DeleteContract(ctx context.Context, id int64) error {
 	return err
 }
 
+// DeleteContractAlias deletes the alias of the contract under a vm operation.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) DeleteContractAlias(ctx context.Context, contractID int64, name string) error {
+
+	user := app.UserFromContext(ctx)
+
+	opMaxFuel := entity.VmOperationCost(entity.VmOperationDeleteContractAlias)
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:          user,
+		CustomMaxFuel: &opMaxFuel,
+		IgnoreRefuel:  true,
+		VmOperation:   entity.VmOperationDeleteContractAlias,
+	})
+
+	_, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return nil, vm.ContractAliasManagmentService.DeleteContractAlias(ctx, contractID, name)
+	})
+
+	return err
+}
+
 // DeleteUser deletes the user.
 // This call consumes fuel.
 // No check on authorization is performed.
@@ -169,8 +193,16 @@ func (vm *MusicGangVM) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+// CompileContract validates the code of the revision.
+// Delegates to the engine service.
+func (vm *MusicGangVM) CompileContract(ctx context.Context, revision *entity.Revision) error {
+	return vm.EngineService.CompileContract(ctx, revision)
+}
+
 // ExecContract executes the contract.
 // This func is a wrapper for the Engine.ExecContract.
+// If the options hold a dry run the state left by the contract is not stored and the call is not recorded as an execution,
+// but the call consumes fuel as any other.
 func (vm *MusicGangVM) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
 
 	user := app.UserFromContext(ctx)
@@ -185,15 +217,36 @@ func (vm *MusicGangVM) ExecContract(ctx context.Context, opt service.ContractCal
 		return nil, err
 	}
 
+	// every execution runs in a sandbox, so it can be replayed with the same seed and time.
+	if opt.SandboxRef == nil {
+		opt.SandboxRef = entity.NewSandbox()
+	}
+
+	// the call is recorded with the execution, so it can be replayed.
+	snapshot := &entity.ExecutionSnapshot{Input: opt.Input}
+
+	// the dry runs are not recorded as executions.
+	operation := entity.VmOperationExecuteContract
+	if opt.DryRunRef != nil {
+		operation = entity.VmOperationDryRunContract
+	}
+
 	call := service.NewVmCallWithConfig(service.VmCallOpt{
 		User:        user,
 		RevisionRef: revision,
-		VmOperation: entity.VmOperationExecuteContract,
+		AliasRef:    opt.AliasRef,
+		SandboxRef:  opt.SandboxRef,
+		SnapshotRef: snapshot,
+		VmOperation: operation,
 		ContractRef: contract,
 	})
 
+	meterContractCall(call, &opt)
+
 	return vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
 
+		startTime := time.Now()
+
 		if ref.Contract().Stateful {
 
 			needToCreateZeroState := false
@@ -213,9 +266,29 @@ func (vm *MusicGangVM) ExecContract(ctx context.Context, opt service.ContractCal
 					Value:      make(entity.StateValue),
 				}
 
-				if err := vm.StateService.CreateState(ctx, state); err != nil {
+				if opt.DryRunRef == nil {
+					if err := vm.StateService.CreateState(ctx, state); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			// the contract changes the state in place, the snapshot keeps a copy.
+			stateBefore, err := state.Value.Clone()
+			if err != nil {
+				return nil, err
+			}
+			snapshot.StateBefore = stateBefore
+
+			// the dry run works on a copy, the loaded state may be shared with the cache.
+			if opt.DryRunRef != nil {
+				value, err := state.Value.Clone()
+				if err != nil {
 					return nil, err
 				}
+				dryState := *state
+				dryState.Value = value
+				state = &dryState
 			}
 
 			opt.StateRef = state
@@ -226,6 +299,31 @@ func (vm *MusicGangVM) ExecContract(ctx context.Context, opt service.ContractCal
 			return nil, err
 		}
 
+		if ref.Contract().Stateful {
+			// the state left by the contract is discarded if it is too large to be stored.
+			size, err := opt.StateRef.Value.Size()
+			if err != nil {
+				return nil, err
+			}
+			if size > entity.MaxContractStateSize {
+				return nil, apperr.Errorf(apperr.EMGVM_STATE_TOO_LARGE, "Contract state is %d bytes, max state size is %d bytes", size, entity.MaxContractStateSize)
+			}
+		}
+
+		if opt.DryRunRef != nil {
+			var stateAfter entity.StateValue
+			if ref.Contract().Stateful {
+				stateAfter = opt.StateRef.Value
+			}
+			opt.DryRunRef.StateDiff = snapshot.StateBefore.Diff(stateAfter)
+			// the fuel is estimated as the vm charges it, on the work done by the metered versions and on the time by the others.
+			opt.DryRunRef.Fuel = entity.FuelAmount(time.Since(startTime))
+			if opt.MeterRef != nil {
+				opt.DryRunRef.Fuel = opt.MeterRef.Charged()
+			}
+			return res, nil
+		}
+
 		if ref.Contract().Stateful {
 			if _, err := vm.StateService.UpdateState(ctx, ref.Revision().ID, opt.StateRef.Value); err != nil {
 				return nil, err
@@ -233,13 +331,25 @@ func (vm *MusicGangVM) ExecContract(ctx context.Context, opt service.ContractCal
 			if err := vm.CacheStateService.CacheState(ctx, opt.StateRef); err != nil {
 				return nil, err
 			}
+			snapshot.StateAfter = opt.StateRef.Value
 		}
 
+		snapshot.Result = res
+
 		return res, nil
 	})
 }
 
-// MakeRevision makes a revision under a vm operation.
+// meterContractCall attaches a fuel meter to the contract call.
+// The versions with a price are charged on the work done by the contract, the others on the execution time.
+func meterContractCall(call *service.VmCall, opt *service.ContractCallOpt) {
+	if price, ok := entity.FuelPrices[call.RevisionRef.Version]; ok {
+		opt.MeterRef = entity.NewFuelMeter(call.Fuel(), price)
+		call.MeterRef = opt.MeterRef
+	}
+}
+
+// MakeRevision makes a revision under a vm operation, the code is validated before the revision is stored.
 // This call consumes fuel.
 // No check on authorization is performed.
 func (vm *MusicGangVM) MakeRevision(ctx context.Context, revision *entity.Revision) (err error) {
@@ -257,12 +367,191 @@ func (vm *MusicGangVM) MakeRevision(ctx context.Context, revision *entity.Revisi
 	})
 
 	_, err = vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		// the code is validated before it is stored, so the syntax errors never show up at call time.
+		if err := vm.EngineService.CompileContract(ctx, ref.Revision()); err != nil {
+			return nil, err
+		}
 		return nil, vm.ContractManagmentService.MakeRevision(ctx, ref.Revision())
 	})
 
 	return err
 }
 
+// ReplayExecution runs the recorded call of the execution again on the revision, under a vm operation.
+// The contract runs in the sandbox of the execution, from a copy of the state recorded before the call,
+// so the live state is never read or written and the replay is not recorded as an execution.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) ReplayExecution(ctx context.Context, execution *entity.Execution, revision *entity.Revision) (*entity.ExecutionReplay, error) {
+
+	if execution.Snapshot == nil {
+		return nil, apperr.Errorf(apperr.EINVALID, "execution has no recorded call to replay")
+	}
+
+	contract := revision.Contract
+	if contract == nil {
+		return nil, apperr.Errorf(apperr.EINVALID, "No contract specified")
+	}
+
+	opt := service.ContractCallOpt{
+		ContractRef: contract,
+		RevisionRef: revision,
+		Input:       execution.Snapshot.Input,
+		SandboxRef:  execution.Sandbox(),
+	}
+
+	if contract.Stateful {
+		value, err := execution.Snapshot.StateBefore.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			// the original revision was stateless.
+			value = make(entity.StateValue)
+		}
+		opt.StateRef = &entity.State{RevisionID: revision.ID, Value: value}
+	}
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:        app.UserFromContext(ctx),
+		ContractRef: contract,
+		RevisionRef: revision,
+		VmOperation: entity.VmOperationReplayExecution,
+	})
+
+	meterContractCall(call, &opt)
+
+	res, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return vm.EngineService.ExecContract(ctx, opt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var state entity.StateValue
+	if opt.StateRef != nil {
+		state = opt.StateRef.Value
+	}
+
+	return entity.NewExecutionReplay(execution, revision, res, state), nil
+}
+
+// RestoreContract restores the deleted contract under a vm operation.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) RestoreContract(ctx context.Context, id int64) (*entity.Contract, error) {
+
+	user := app.UserFromContext(ctx)
+
+	opMaxFuel := entity.VmOperationCost(entity.VmOperationRestoreContract)
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:          user,
+		CustomMaxFuel: &opMaxFuel,
+		IgnoreRefuel:  true,
+		VmOperation:   entity.VmOperationRestoreContract,
+	})
+
+	result, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return vm.ContractManagmentService.RestoreContract(ctx, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := result.(*entity.Contract); !ok || v == nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "invalid contract restore result")
+	}
+
+	return result.(*entity.Contract), nil
+}
+
+// RollbackActiveRevision rolls back the active revision of the contract under a vm operation, it is a contract update.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) RollbackActiveRevision(ctx context.Context, id int64) (*entity.Contract, error) {
+
+	user := app.UserFromContext(ctx)
+
+	opMaxFuel := entity.VmOperationCost(entity.VmOperationUpdateContract)
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:          user,
+		CustomMaxFuel: &opMaxFuel,
+		IgnoreRefuel:  true,
+		VmOperation:   entity.VmOperationUpdateContract,
+	})
+
+	result, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return vm.ContractManagmentService.RollbackActiveRevision(ctx, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := result.(*entity.Contract); !ok || v == nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "invalid active revision rollback result")
+	}
+
+	return result.(*entity.Contract), nil
+}
+
+// SetActiveRevision pins the active revision of the contract under a vm operation, it is a contract update.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) SetActiveRevision(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+
+	user := app.UserFromContext(ctx)
+
+	opMaxFuel := entity.VmOperationCost(entity.VmOperationUpdateContract)
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:          user,
+		CustomMaxFuel: &opMaxFuel,
+		IgnoreRefuel:  true,
+		VmOperation:   entity.VmOperationUpdateContract,
+	})
+
+	result, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return vm.ContractManagmentService.SetActiveRevision(ctx, id, rev)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v, ok := result.(*entity.Contract); !ok || v == nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "invalid active revision result")
+	}
+
+	return result.(*entity.Contract), nil
+}
+
+// SetContractAlias creates or moves the alias of the contract under a vm operation.
+// This call consumes fuel.
+// No check on authorization is performed.
+func (vm *MusicGangVM) SetContractAlias(ctx context.Context, alias *entity.ContractAlias) error {
+
+	user := app.UserFromContext(ctx)
+
+	opMaxFuel := entity.VmOperationCost(entity.VmOperationSetContractAlias)
+
+	call := service.NewVmCallWithConfig(service.VmCallOpt{
+		User:          user,
+		CustomMaxFuel: &opMaxFuel,
+		IgnoreRefuel:  true,
+		VmOperation:   entity.VmOperationSetContractAlias,
+	})
+
+	_, err := vm.makeOperation(ctx, call, func(ctx context.Context, ref service.VmCallable) (interface{}, error) {
+		return nil, vm.ContractAliasManagmentService.SetContractAlias(ctx, alias)
+	})
+
+	return err
+}
+
 // Stats returns the stats of fuel tank usage.
 func (vm *MusicGangVM) Stats(ctx context.Context) (*entity.FuelStat, error) {
 
@@ -6,11 +6,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 	"github.com/music-gang/music-gang-api/mgvm"
 	"github.com/music-gang/music-gang-api/mock"
+	"gopkg.in/guregu/null.v4"
 )
 
 func TestVm_ExecContract(t *testing.T) {
@@ -68,10 +70,10 @@ func TestVm_ExecContract(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				atomic.AddUint64((*uint64)(&currentFuel), -uint64(fuelToRefill))
 				refuelCalled = true
-				return nil
+				return fuelToRefill, nil
 			},
 		}
 		vm.EngineService = &mock.EngineService{
@@ -143,10 +145,10 @@ func TestVm_ExecContract(t *testing.T) {
 				FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 					return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 				},
-				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 					atomic.AddUint64((*uint64)(&currentFuel), -uint64(fuelToRefill))
 					refuelCalled = true
-					return nil
+					return fuelToRefill, nil
 				},
 			}
 			vm.EngineService = &mock.EngineService{
@@ -241,10 +243,10 @@ func TestVm_ExecContract(t *testing.T) {
 				FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 					return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 				},
-				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 					atomic.AddUint64((*uint64)(&currentFuel), -uint64(fuelToRefill))
 					refuelCalled = true
-					return nil
+					return fuelToRefill, nil
 				},
 			}
 			vm.EngineService = &mock.EngineService{
@@ -741,8 +743,8 @@ func TestVm_ExecContract(t *testing.T) {
 			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
 				return nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				return apperr.Errorf(apperr.EMGVM, "test")
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return 0, apperr.Errorf(apperr.EMGVM, "test")
 			},
 		}
 		vm.EngineService = &mock.EngineService{
@@ -918,166 +920,333 @@ func TestVm_ExecContract(t *testing.T) {
 	})
 }
 
-// All tests cases for the ExecContract method cover all possible scenarios inside makeOperations.
-// So for other vm services I think it's not necessary repeat all tests cases for the ExecContract method.
+func TestVm_ExecContract_Execution(t *testing.T) {
 
-func TestVm_CreateContract(t *testing.T) {
+	contract := &entity.Contract{
+		ID:      1,
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
 
 	t.Run("OK", func(t *testing.T) {
 
 		vm := mgvm.NewMusicGangVM()
 
 		currentState := entity.StateInitializing
-		currentFuel := entity.Fuel(0)
+
+		var execution *entity.Execution
 
 		vm.LogService = &mock.LoggerNoOp{}
 		vm.FuelTank = &mock.FuelTankService{
 			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
-				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
 				return nil
 			},
-			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
-				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				panic("should not be called")
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
 			},
 		}
 		vm.EngineService = &mock.EngineService{
 			IsRunningFn: func() bool {
 				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
 			},
-			PauseFn: func() error {
-				return nil
-			},
 			ResumeFn: func() error {
 				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
 				return nil
 			},
-			StateFn: func() entity.VmState {
-				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return map[string]any{"ok": true}, nil
 			},
-			StopFn: func() error {
+		}
+
+		vm.EngineService.Resume()
+
+		vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeOK {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeOK)
+		}
+
+		if execution.ContractID != contract.ID || execution.RevisionID != contract.LastRevision.ID {
+			t.Errorf("Unexpected contract or revision, got: %d/%d", execution.ContractID, execution.RevisionID)
+		}
+
+		if execution.UserID.Int64 != user.ID {
+			t.Errorf("Unexpected caller, got: %d, want: %d", execution.UserID.Int64, user.ID)
+		}
+
+		if execution.FuelReserved != contract.LastRevision.MaxFuel {
+			t.Errorf("Unexpected reserved fuel, got: %d, want: %d", execution.FuelReserved, contract.LastRevision.MaxFuel)
+		}
+
+		if execution.FuelCharged == 0 {
+			t.Errorf("Expected charged fuel to be set")
+		}
+
+		if execution.ResultSize != len(`{"ok":true}`) {
+			t.Errorf("Unexpected result size, got: %d", execution.ResultSize)
+		}
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+		var sandbox *entity.Sandbox
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
 				return nil
 			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
 		}
-		vm.ContractManagmentService = &mock.ContractService{
-			CreateContractFn: func(ctx context.Context, contract *entity.Contract) error {
-				contract.ID = 1
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
 				return nil
 			},
 		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				sandbox = opt.SandboxRef
+				return true, nil
+			},
+		}
 
-		go func() {
-
-			// simulate late start to mock the gorutine waiting for the engine to be running
+		vm.EngineService.Resume()
 
-			time.Sleep(time.Second)
+		vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
 
-			if err := vm.Resume(); err != nil {
-				t.Errorf("Unexpected error: %s", err.Error())
-			}
-		}()
+		if sandbox == nil {
+			t.Fatal("Expected the contract to run in a sandbox")
+		}
 
-		contract := &entity.Contract{
-			Name:    "test",
-			MaxFuel: entity.FuelLongActionAmount,
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
 		}
 
-		err := vm.CreateContract(context.Background(), contract)
-		if err != nil {
-			t.Errorf("Unexpected error: %s", err.Error())
+		if execution.Seed != sandbox.Seed {
+			t.Errorf("Unexpected seed, got: %d, want: %d", execution.Seed, sandbox.Seed)
 		}
 
-		if contract.ID != 1 {
-			t.Errorf("Unexpected contract ID: %d", contract.ID)
+		if !execution.FrozenAt.Valid || !execution.FrozenAt.Time.Equal(sandbox.Now) {
+			t.Errorf("Unexpected frozen time, got: %v, want: %v", execution.FrozenAt, sandbox.Now)
 		}
 	})
-}
-
-func TestVm_DeleteContract(t *testing.T) {
 
-	t.Run("OK", func(t *testing.T) {
+	t.Run("ErrExec", func(t *testing.T) {
 
 		vm := mgvm.NewMusicGangVM()
 
 		currentState := entity.StateInitializing
-		currentFuel := entity.Fuel(0)
+
+		var execution *entity.Execution
 
 		vm.LogService = &mock.LoggerNoOp{}
 		vm.FuelTank = &mock.FuelTankService{
 			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
-				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
 				return nil
 			},
-			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
-				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				panic("should not be called")
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
 			},
 		}
 		vm.EngineService = &mock.EngineService{
 			IsRunningFn: func() bool {
 				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
 			},
-			PauseFn: func() error {
-				return nil
-			},
 			ResumeFn: func() error {
 				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
 				return nil
 			},
-			StateFn: func() entity.VmState {
-				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return nil, apperr.Errorf(apperr.EANCHORAGE, "test")
 			},
-			StopFn: func() error {
+		}
+
+		vm.EngineService.Resume()
+
+		vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeError {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeError)
+		}
+
+		if execution.ContractID != contract.ID || execution.RevisionID != contract.LastRevision.ID {
+			t.Errorf("Unexpected contract or revision, got: %d/%d", execution.ContractID, execution.RevisionID)
+		}
+
+		if execution.UserID.Int64 != user.ID {
+			t.Errorf("Unexpected caller, got: %d, want: %d", execution.UserID.Int64, user.ID)
+		}
+
+		if execution.FuelReserved != contract.LastRevision.MaxFuel {
+			t.Errorf("Unexpected reserved fuel, got: %d, want: %d", execution.FuelReserved, contract.LastRevision.MaxFuel)
+		}
+
+		if execution.ErrorCode != apperr.EANCHORAGE {
+			t.Errorf("Unexpected error code, got: %s, want: %s", execution.ErrorCode, apperr.EANCHORAGE)
+		}
+
+		if execution.FuelCharged != execution.FuelReserved {
+			t.Errorf("Expected all reserved fuel to be charged, got: %d", execution.FuelCharged)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
 				return nil
 			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
 		}
-		vm.ContractManagmentService = &mock.ContractService{
-			DeleteContractFn: func(ctx context.Context, id int64) error {
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
 				return nil
 			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				panic(service.EngineExecutionTimeoutPanic)
+			},
 		}
 
-		go func() {
+		vm.EngineService.Resume()
 
-			// simulate late start to mock the gorutine waiting for the engine to be running
+		vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		})
 
-			time.Sleep(time.Second)
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
 
-			if err := vm.Resume(); err != nil {
-				t.Errorf("Unexpected error: %s", err.Error())
-			}
-		}()
+		if execution.Outcome != entity.ExecutionOutcomeTimeout {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeTimeout)
+		}
 
-		if err := vm.DeleteContract(context.Background(), 1); err != nil {
-			t.Errorf("Unexpected error: %s", err.Error())
+		if execution.ContractID != contract.ID || execution.RevisionID != contract.LastRevision.ID {
+			t.Errorf("Unexpected contract or revision, got: %d/%d", execution.ContractID, execution.RevisionID)
+		}
+
+		if execution.UserID.Int64 != user.ID {
+			t.Errorf("Unexpected caller, got: %d, want: %d", execution.UserID.Int64, user.ID)
+		}
+
+		if execution.FuelReserved != contract.LastRevision.MaxFuel {
+			t.Errorf("Unexpected reserved fuel, got: %d, want: %d", execution.FuelReserved, contract.LastRevision.MaxFuel)
 		}
 	})
 }
 
-func TestVm_MakeRevision(t *testing.T) {
+func TestVm_ExecContract_FuelWallet(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:      1,
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
 
 	t.Run("OK", func(t *testing.T) {
 
 		vm := mgvm.NewMusicGangVM()
 
 		currentState := entity.StateInitializing
-		currentFuel := entity.Fuel(0)
+
+		walletUsed := entity.Fuel(0)
 
 		vm.LogService = &mock.LoggerNoOp{}
 		vm.FuelTank = &mock.FuelTankService{
 			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
-				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
 				return nil
 			},
-			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
-				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				panic("should not be called")
+		}
+		vm.FuelWallet = &mock.FuelWalletService{
+			BurnFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				if userID != user.ID {
+					t.Errorf("Unexpected wallet, got: %d, want: %d", userID, user.ID)
+				}
+				walletUsed += fuel
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				walletUsed -= fuel
+				return nil
 			},
 		}
 		vm.EngineService = &mock.EngineService{
@@ -1091,30 +1260,1335 @@ func TestVm_MakeRevision(t *testing.T) {
 				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
 				return nil
 			},
-			StateFn: func() entity.VmState {
-				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return "contract executed", nil
 			},
-			StopFn: func() error {
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if walletUsed == 0 {
+			t.Errorf("Expected wallet to be charged for the effective fuel")
+		}
+	})
+
+	t.Run("ErrUserLowFuel", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				t.Error("Global fuel tank must not be burned when the wallet is empty")
 				return nil
 			},
 		}
-		vm.ContractManagmentService = &mock.ContractService{
-			MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
-				revision.ID = 1
+		vm.FuelWallet = &mock.FuelWalletService{
+			BurnFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				return apperr.Errorf(apperr.EMGVM_USER_LOWFUEL, "your fuel wallet is not enough")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
 				return nil
 			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return "contract executed", nil
+			},
 		}
 
-		go func() {
+		vm.EngineService.Resume()
 
-			// simulate late start to mock the gorutine waiting for the engine to be running
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM_USER_LOWFUEL {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM_USER_LOWFUEL)
+		}
+	})
 
-			time.Sleep(time.Second)
+	t.Run("ErrFuelTankNotEnough", func(t *testing.T) {
 
-			if err := vm.Resume(); err != nil {
-				t.Errorf("Unexpected error: %s", err.Error())
-			}
-		}()
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		walletUsed := entity.Fuel(0)
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return service.ErrFuelTankNotEnough
+			},
+		}
+		vm.FuelWallet = &mock.FuelWalletService{
+			BurnFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				walletUsed += fuel
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				walletUsed -= fuel
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return "contract executed", nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM_LOWFUEL {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM_LOWFUEL)
+		}
+
+		if walletUsed != 0 {
+			t.Errorf("Expected wallet to be refunded, got: %d", walletUsed)
+		}
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.FuelWallet = &mock.FuelWalletService{
+			BurnFn: func(ctx context.Context, userID int64, fuel entity.Fuel) error {
+				if userID != entity.AnonymousFuelWalletID {
+					t.Errorf("Unexpected wallet, got: %d, want: %d", userID, entity.AnonymousFuelWalletID)
+				}
+				return apperr.Errorf(apperr.EMGVM_USER_LOWFUEL, "anonymous wallet is empty")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				t.Error("Contract executed with an empty anonymous wallet")
+				return nil, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(context.Background(), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_USER_LOWFUEL {
+			t.Errorf("Unexpected error code, got: %s, want: %s", errCode, apperr.EMGVM_USER_LOWFUEL)
+		}
+	})
+}
+
+func TestVm_ExecContract_FuelLedger(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:      1,
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var entries entity.FuelLedgerEntries
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.FuelLedger = &mock.FuelLedgerService{
+			RecordFuelEntriesFn: func(ctx context.Context, e entity.FuelLedgerEntries) error {
+				entries = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return "contract executed", nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		// the refund is recorded only if some fuel is given back, it depends on the charged fuel.
+		if len(entries) == 0 || len(entries) > 2 {
+			t.Fatalf("Expected burn and refund entries, got: %d", len(entries))
+		}
+
+		if burn := entries[0]; burn.Kind != entity.FuelLedgerKindBurn || burn.Amount != contract.LastRevision.MaxFuel {
+			t.Errorf("Unexpected burn entry, got: %s %d", burn.Kind, burn.Amount)
+		}
+
+		if len(entries) == 2 && entries[1].Kind != entity.FuelLedgerKindRefund {
+			t.Errorf("Unexpected refund entry, got: %s", entries[1].Kind)
+		}
+
+		for _, entry := range entries {
+			if entry.Operation != entity.VmOperationExecuteContract {
+				t.Errorf("Unexpected operation, got: %s", entry.Operation)
+			}
+			if entry.UserID.Int64 != user.ID || entry.ContractID.Int64 != contract.ID || entry.RevisionID.Int64 != contract.LastRevision.ID {
+				t.Errorf("Unexpected references, got: %d/%d/%d", entry.UserID.Int64, entry.ContractID.Int64, entry.RevisionID.Int64)
+			}
+		}
+	})
+
+	t.Run("ErrFuelTankNotEnough", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return service.ErrFuelTankNotEnough
+			},
+		}
+		vm.FuelLedger = &mock.FuelLedgerService{
+			RecordFuelEntriesFn: func(ctx context.Context, e entity.FuelLedgerEntries) error {
+				t.Error("Nothing must be recorded when the fuel tank is not burned")
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+// All tests cases for the ExecContract method cover all possible scenarios inside makeOperations.
+// So for other vm services I think it's not necessary repeat all tests cases for the ExecContract method.
+
+func TestVm_ExecContract_Meter(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:      1,
+		MaxFuel: entity.FuelLongActionAmount,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			Version:      entity.AnchorageVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+		var refueled entity.Fuel
+
+		price := entity.FuelPrices[entity.AnchorageVersion]
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				refueled = fuelToRefill
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				if opt.MeterRef == nil {
+					t.Fatal("Expected the call to be metered")
+				}
+				// the work is slow, but only the units are charged.
+				opt.MeterRef.Consume(uint64(price) * 100)
+				time.Sleep(150 * time.Millisecond)
+				return true, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if refueled != contract.LastRevision.MaxFuel-100 {
+			t.Errorf("Unexpected refuel, got: %d, want: %d", refueled, contract.LastRevision.MaxFuel-100)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		} else if execution.FuelCharged != 100 {
+			t.Errorf("Unexpected charged fuel, got: %d, want: %d", execution.FuelCharged, 100)
+		}
+	})
+
+	t.Run("MinimumCharge", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+		var refueled entity.Fuel
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				refueled = fuelToRefill
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				// a one-statement contract does a single work unit.
+				opt.MeterRef.Consume(1)
+				return true, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if refueled != contract.LastRevision.MaxFuel-entity.FuelInstantActionAmount {
+			t.Errorf("Unexpected refuel, got: %d, want: %d", refueled, contract.LastRevision.MaxFuel-entity.FuelInstantActionAmount)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		} else if execution.FuelCharged != entity.FuelInstantActionAmount {
+			t.Errorf("Unexpected charged fuel, got: %d, want: %d", execution.FuelCharged, entity.FuelInstantActionAmount)
+		}
+	})
+
+	t.Run("NoPrice", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				if opt.MeterRef != nil {
+					t.Error("Expected the call to be charged on the execution time")
+				}
+				return true, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		revision := *contract.LastRevision
+		revision.Version = "Unpriced"
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: &revision,
+		}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+	})
+
+	t.Run("FuelExhausted", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				t.Error("Expected no refuel, the whole fuel is used")
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				panic(service.EngineFuelExhaustedPanic)
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeOutOfFuel {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeOutOfFuel)
+		}
+
+		if execution.FuelCharged != execution.FuelReserved {
+			t.Errorf("Expected all reserved fuel to be charged, got: %d", execution.FuelCharged)
+		}
+	})
+}
+
+func TestVm_ExecContract_Limits(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:       1,
+		MaxFuel:  entity.FuelLongActionAmount,
+		Stateful: true,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			Version:      entity.AnchorageVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			MaxMemory:    1 << 20,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
+
+	t.Run("MemoryLimit", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.StateService = &mock.StateService{
+			FindStateByRevisionIDFn: func(ctx context.Context, revisionID int64) (*entity.State, error) {
+				return &entity.State{RevisionID: revisionID, Value: make(entity.StateValue)}, nil
+			},
+			UpdateStateFn: func(ctx context.Context, revisionID int64, value entity.StateValue) (*entity.State, error) {
+				t.Error("Expected the state not to be stored")
+				return nil, nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				panic(service.EngineMemoryLimitPanic)
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM_MEMORY_LIMIT {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM_MEMORY_LIMIT)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeMemoryLimit {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeMemoryLimit)
+		} else if execution.ErrorCode != apperr.EMGVM_MEMORY_LIMIT {
+			t.Errorf("Unexpected error code, got: %s, want: %s", execution.ErrorCode, apperr.EMGVM_MEMORY_LIMIT)
+		}
+	})
+
+	t.Run("StateTooLarge", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.StateService = &mock.StateService{
+			FindStateByRevisionIDFn: func(ctx context.Context, revisionID int64) (*entity.State, error) {
+				return &entity.State{RevisionID: revisionID, Value: make(entity.StateValue)}, nil
+			},
+			UpdateStateFn: func(ctx context.Context, revisionID int64, value entity.StateValue) (*entity.State, error) {
+				t.Error("Expected the state not to be stored")
+				return nil, nil
+			},
+		}
+		vm.CacheStateService = &mock.StateCacheService{
+			CacheStateFn: func(ctx context.Context, state *entity.State) error {
+				t.Error("Expected the state not to be cached")
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				opt.StateRef.Value["key"] = string(make([]byte, entity.MaxContractStateSize))
+				return true, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM_STATE_TOO_LARGE {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM_STATE_TOO_LARGE)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeStateTooLarge {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeStateTooLarge)
+		}
+	})
+
+	t.Run("OutputTooLarge", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		var execution *entity.Execution
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				execution = e
+				return nil
+			},
+		}
+		vm.StateService = &mock.StateService{
+			FindStateByRevisionIDFn: func(ctx context.Context, revisionID int64) (*entity.State, error) {
+				return &entity.State{RevisionID: revisionID, Value: make(entity.StateValue)}, nil
+			},
+			UpdateStateFn: func(ctx context.Context, revisionID int64, value entity.StateValue) (*entity.State, error) {
+				t.Error("Expected the state not to be stored")
+				return nil, nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				return nil, apperr.Errorf(apperr.EMGVM_OUTPUT_TOO_LARGE, "Contract result is too large")
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+		}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EMGVM_OUTPUT_TOO_LARGE {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EMGVM_OUTPUT_TOO_LARGE)
+		}
+
+		if execution == nil {
+			t.Fatal("Expected execution to be recorded")
+		}
+
+		if execution.Outcome != entity.ExecutionOutcomeOutputTooLarge {
+			t.Errorf("Unexpected outcome, got: %s, want: %s", execution.Outcome, entity.ExecutionOutcomeOutputTooLarge)
+		}
+	})
+}
+
+func TestVm_ExecContract_DryRun(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:       1,
+		MaxFuel:  entity.FuelLongActionAmount,
+		Stateful: true,
+		LastRevision: &entity.Revision{
+			ID:           2,
+			Version:      entity.AnchorageVersion,
+			MaxFuel:      entity.FuelLongActionAmount,
+			CompiledCode: []byte(`var result = true;`),
+		},
+	}
+
+	user := &entity.User{ID: 3}
+
+	mustOpenDryRunVm := func(t *testing.T, findState func(ctx context.Context, revisionID int64) (*entity.State, error), burned *entity.Fuel) *mgvm.MusicGangVM {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				*burned += fuel
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				*burned -= fuelToRefill
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				t.Error("Expected the dry run not to be recorded")
+				return nil
+			},
+		}
+		vm.StateService = &mock.StateService{
+			FindStateByRevisionIDFn: findState,
+			CreateStateFn: func(ctx context.Context, state *entity.State) error {
+				t.Error("Expected the state not to be created")
+				return nil
+			},
+			UpdateStateFn: func(ctx context.Context, revisionID int64, value entity.StateValue) (*entity.State, error) {
+				t.Error("Expected the state not to be stored")
+				return nil, nil
+			},
+		}
+		vm.CacheStateService = &mock.StateCacheService{
+			CacheStateFn: func(ctx context.Context, state *entity.State) error {
+				t.Error("Expected the state not to be cached")
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				if opt.MeterRef == nil {
+					t.Fatal("Expected the dry run to be metered")
+				}
+				opt.MeterRef.Consume(100 * uint64(entity.FuelPrices[entity.AnchorageVersion]))
+				opt.StateRef.Value["count"] = float64(2)
+				return true, nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		return vm
+	}
+
+	t.Run("OK", func(t *testing.T) {
+
+		var burned entity.Fuel
+
+		liveState := &entity.State{RevisionID: 2, Value: entity.StateValue{"count": float64(1)}}
+
+		vm := mustOpenDryRunVm(t, func(ctx context.Context, revisionID int64) (*entity.State, error) {
+			return liveState, nil
+		}, &burned)
+
+		dryRun := &entity.DryRun{}
+
+		if res, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			DryRunRef:   dryRun,
+		}); err != nil {
+			t.Fatal(err)
+		} else if res != true {
+			t.Errorf("Unexpected result, got: %v, want: %v", res, true)
+		}
+
+		if change, ok := dryRun.StateDiff["count"]; !ok || change.Before != float64(1) || change.After != float64(2) {
+			t.Errorf("Unexpected state diff, got: %v", dryRun.StateDiff)
+		}
+
+		if liveState.Value["count"] != float64(1) {
+			t.Errorf("Expected the live state not to change, got: %v", liveState.Value)
+		}
+
+		if burned != 100 {
+			t.Errorf("Expected the dry run to burn fuel, got: %d, want: %d", burned, 100)
+		} else if burned != dryRun.Fuel {
+			t.Errorf("Unexpected fuel estimate, got: %d, want: %d", dryRun.Fuel, burned)
+		}
+	})
+
+	t.Run("NoState", func(t *testing.T) {
+
+		var burned entity.Fuel
+
+		vm := mustOpenDryRunVm(t, func(ctx context.Context, revisionID int64) (*entity.State, error) {
+			return nil, apperr.Errorf(apperr.ENOTFOUND, "state not found")
+		}, &burned)
+
+		dryRun := &entity.DryRun{}
+
+		if _, err := vm.ExecContract(app.NewContextWithUser(context.Background(), user), service.ContractCallOpt{
+			ContractRef: contract,
+			RevisionRef: contract.LastRevision,
+			DryRunRef:   dryRun,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if change, ok := dryRun.StateDiff["count"]; !ok || change.Before != nil || change.After != float64(2) {
+			t.Errorf("Unexpected state diff, got: %v", dryRun.StateDiff)
+		}
+	})
+}
+
+func TestVm_ReplayExecution(t *testing.T) {
+
+	contract := &entity.Contract{
+		ID:       1,
+		MaxFuel:  entity.FuelLongActionAmount,
+		Stateful: true,
+	}
+
+	revision := &entity.Revision{
+		ID:           3,
+		Rev:          2,
+		ContractID:   contract.ID,
+		Contract:     contract,
+		Version:      entity.AnchorageVersion,
+		MaxFuel:      entity.FuelLongActionAmount,
+		CompiledCode: []byte(`var result = true;`),
+	}
+
+	user := &entity.User{ID: 3}
+
+	frozenAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		execution := &entity.Execution{
+			ID:         4,
+			ContractID: contract.ID,
+			Rev:        1,
+			Seed:       42,
+			FrozenAt:   null.TimeFrom(frozenAt),
+			Snapshot: &entity.ExecutionSnapshot{
+				Input:       map[string]any{"name": "bob"},
+				StateBefore: entity.StateValue{"count": float64(1)},
+				Result:      float64(2),
+				StateAfter:  entity.StateValue{"count": float64(2)},
+			},
+		}
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
+			},
+		}
+		vm.ExecutionService = &mock.ExecutionService{
+			CreateExecutionFn: func(ctx context.Context, e *entity.Execution) error {
+				t.Error("Expected the replay not to be recorded")
+				return nil
+			},
+		}
+		vm.StateService = &mock.StateService{
+			FindStateByRevisionIDFn: func(ctx context.Context, revisionID int64) (*entity.State, error) {
+				t.Error("Expected the live state not to be read")
+				return nil, nil
+			},
+			UpdateStateFn: func(ctx context.Context, revisionID int64, value entity.StateValue) (*entity.State, error) {
+				t.Error("Expected the live state not to be stored")
+				return nil, nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			ExecContractFn: func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
+				if input, ok := opt.Input.(map[string]any); !ok || input["name"] != "bob" {
+					t.Errorf("Unexpected input, got: %v", opt.Input)
+				}
+				if opt.SandboxRef == nil || opt.SandboxRef.Seed != 42 || !opt.SandboxRef.Now.Equal(frozenAt) {
+					t.Errorf("Unexpected sandbox, got: %v", opt.SandboxRef)
+				}
+				if opt.StateRef == nil || opt.StateRef.Value["count"] != float64(1) {
+					t.Fatalf("Unexpected state, got: %v", opt.StateRef)
+				}
+				opt.StateRef.Value["count"] = float64(3)
+				return float64(3), nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		replay, err := vm.ReplayExecution(app.NewContextWithUser(context.Background(), user), execution, revision)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if replay.ExecutionID != execution.ID || replay.Rev != revision.Rev || replay.OriginalRev != execution.Rev {
+			t.Errorf("Unexpected replay references, got: %v", replay)
+		}
+
+		if !replay.ResultChanged || replay.Result != float64(3) || replay.OriginalResult != float64(2) {
+			t.Errorf("Unexpected result, got: %v, original: %v", replay.Result, replay.OriginalResult)
+		}
+
+		if change, ok := replay.StateDiff["count"]; !ok || change.Before != float64(2) || change.After != float64(3) {
+			t.Errorf("Unexpected state diff, got: %v", replay.StateDiff)
+		}
+
+		if execution.Snapshot.StateBefore["count"] != float64(1) {
+			t.Errorf("Expected the recorded state not to change, got: %v", execution.Snapshot.StateBefore)
+		}
+	})
+
+	t.Run("NoSnapshot", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		if _, err := vm.ReplayExecution(app.NewContextWithUser(context.Background(), user), &entity.Execution{ID: 4}, revision); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+			t.Errorf("Unexpected error, got: %v, want: %v", code, apperr.EINVALID)
+		}
+	})
+}
+
+func TestVm_CreateContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			StateFn: func() entity.VmState {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			},
+			StopFn: func() error {
+				return nil
+			},
+		}
+		vm.ContractManagmentService = &mock.ContractService{
+			CreateContractFn: func(ctx context.Context, contract *entity.Contract) error {
+				contract.ID = 1
+				return nil
+			},
+		}
+
+		go func() {
+
+			// simulate late start to mock the gorutine waiting for the engine to be running
+
+			time.Sleep(time.Second)
+
+			if err := vm.Resume(); err != nil {
+				t.Errorf("Unexpected error: %s", err.Error())
+			}
+		}()
+
+		contract := &entity.Contract{
+			Name:    "test",
+			MaxFuel: entity.FuelLongActionAmount,
+		}
+
+		err := vm.CreateContract(context.Background(), contract)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+
+		if contract.ID != 1 {
+			t.Errorf("Unexpected contract ID: %d", contract.ID)
+		}
+	})
+}
+
+func TestVm_DeleteContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			StateFn: func() entity.VmState {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			},
+			StopFn: func() error {
+				return nil
+			},
+		}
+		vm.ContractManagmentService = &mock.ContractService{
+			DeleteContractFn: func(ctx context.Context, id int64) error {
+				return nil
+			},
+		}
+
+		go func() {
+
+			// simulate late start to mock the gorutine waiting for the engine to be running
+
+			time.Sleep(time.Second)
+
+			if err := vm.Resume(); err != nil {
+				t.Errorf("Unexpected error: %s", err.Error())
+			}
+		}()
+
+		if err := vm.DeleteContract(context.Background(), 1); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+	})
+}
+
+func TestVm_DeleteContractAlias(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		var entries entity.FuelLedgerEntries
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.FuelLedger = &mock.FuelLedgerService{
+			RecordFuelEntriesFn: func(ctx context.Context, e entity.FuelLedgerEntries) error {
+				entries = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+		}
+		vm.ContractAliasManagmentService = &mock.ContractAliasService{
+			DeleteContractAliasFn: func(ctx context.Context, contractID int64, name string) error {
+				if contractID != 1 || name != "canary" {
+					t.Errorf("Unexpected alias, got: %d %s", contractID, name)
+				}
+				return nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if err := vm.DeleteContractAlias(context.Background(), 1, "canary"); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if fuel := entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))); fuel != entity.VmOperationCost(entity.VmOperationDeleteContractAlias) {
+			t.Errorf("Unexpected fuel burned, got: %d, want: %d", fuel, entity.VmOperationCost(entity.VmOperationDeleteContractAlias))
+		}
+
+		if len(entries) != 1 {
+			t.Fatalf("Expected a burn entry, got: %d", len(entries))
+		} else if entries[0].Kind != entity.FuelLedgerKindBurn || entries[0].Operation != entity.VmOperationDeleteContractAlias {
+			t.Errorf("Unexpected burn entry, got: %s %s", entries[0].Kind, entries[0].Operation)
+		}
+	})
+
+	t.Run("Err", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+		}
+		vm.ContractAliasManagmentService = &mock.ContractAliasService{
+			DeleteContractAliasFn: func(ctx context.Context, contractID int64, name string) error {
+				return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if err := vm.DeleteContractAlias(context.Background(), 1, "canary"); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EUNAUTHORIZED {
+			t.Errorf("Unexpected error code, got: %s, want: %s", errCode, apperr.EUNAUTHORIZED)
+		}
+	})
+}
+
+func TestVm_MakeRevision(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			CompileContractFn: func(ctx context.Context, revision *entity.Revision) error {
+				return nil
+			},
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			StateFn: func() entity.VmState {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			},
+			StopFn: func() error {
+				return nil
+			},
+		}
+		vm.ContractManagmentService = &mock.ContractService{
+			MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
+				revision.ID = 1
+				return nil
+			},
+		}
+
+		go func() {
+
+			// simulate late start to mock the gorutine waiting for the engine to be running
+
+			time.Sleep(time.Second)
+
+			if err := vm.Resume(); err != nil {
+				t.Errorf("Unexpected error: %s", err.Error())
+			}
+		}()
 
 		code := `
 			function sum(a, b) {
@@ -1138,6 +2612,184 @@ func TestVm_MakeRevision(t *testing.T) {
 			t.Errorf("Unexpected revision ID: %d", contract.LastRevision.ID)
 		}
 	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
+				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			CompileContractFn: func(ctx context.Context, revision *entity.Revision) error {
+				return apperr.Errorf(apperr.EINVALID, "Syntax error in contract")
+			},
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			PauseFn: func() error {
+				return nil
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+			StateFn: func() entity.VmState {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState)))
+			},
+			StopFn: func() error {
+				return nil
+			},
+		}
+		vm.ContractManagmentService = &mock.ContractService{
+			MakeRevisionFn: func(ctx context.Context, revision *entity.Revision) error {
+				panic("should not be called")
+			},
+		}
+
+		go func() {
+
+			// simulate late start to mock the gorutine waiting for the engine to be running
+
+			time.Sleep(time.Second)
+
+			if err := vm.Resume(); err != nil {
+				t.Errorf("Unexpected error: %s", err.Error())
+			}
+		}()
+
+		code := `
+			function sum(a, b) {
+				return a+b;
+			}
+			var result = sum(1, 2
+		`
+
+		contract := &entity.Contract{
+			MaxFuel: entity.FuelLongActionAmount,
+			LastRevision: &entity.Revision{
+				CompiledCode: []byte(code),
+			},
+		}
+
+		if err := vm.MakeRevision(context.Background(), contract.LastRevision); err == nil {
+			t.Error("Expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("Unexpected error code: %s", errCode)
+		}
+	})
+}
+
+func TestVm_SetContractAlias(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+		currentFuel := entity.Fuel(0)
+
+		var entries entity.FuelLedgerEntries
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				atomic.StoreUint64((*uint64)(&currentFuel), uint64(fuel))
+				return nil
+			},
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				panic("should not be called")
+			},
+		}
+		vm.FuelLedger = &mock.FuelLedgerService{
+			RecordFuelEntriesFn: func(ctx context.Context, e entity.FuelLedgerEntries) error {
+				entries = e
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+		}
+		vm.ContractAliasManagmentService = &mock.ContractAliasService{
+			SetContractAliasFn: func(ctx context.Context, alias *entity.ContractAlias) error {
+				if alias.ContractID != 1 || alias.Name != "canary" || alias.Rev != 2 {
+					t.Errorf("Unexpected alias, got: %+v", alias)
+				}
+				return nil
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if err := vm.SetContractAlias(context.Background(), &entity.ContractAlias{ContractID: 1, Name: "canary", Rev: 2}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if fuel := entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))); fuel != entity.VmOperationCost(entity.VmOperationSetContractAlias) {
+			t.Errorf("Unexpected fuel burned, got: %d, want: %d", fuel, entity.VmOperationCost(entity.VmOperationSetContractAlias))
+		}
+
+		if len(entries) != 1 {
+			t.Fatalf("Expected a burn entry, got: %d", len(entries))
+		} else if entries[0].Kind != entity.FuelLedgerKindBurn || entries[0].Operation != entity.VmOperationSetContractAlias {
+			t.Errorf("Unexpected burn entry, got: %s %s", entries[0].Kind, entries[0].Operation)
+		}
+	})
+
+	t.Run("Err", func(t *testing.T) {
+
+		vm := mgvm.NewMusicGangVM()
+
+		currentState := entity.StateInitializing
+
+		vm.LogService = &mock.LoggerNoOp{}
+		vm.FuelTank = &mock.FuelTankService{
+			BurnFn: func(ctx context.Context, fuel entity.Fuel) error {
+				return nil
+			},
+		}
+		vm.EngineService = &mock.EngineService{
+			IsRunningFn: func() bool {
+				return entity.VmState(atomic.LoadInt32((*int32)(&currentState))) == entity.StateRunning
+			},
+			ResumeFn: func() error {
+				atomic.StoreInt32((*int32)(&currentState), int32(entity.StateRunning))
+				return nil
+			},
+		}
+		vm.ContractAliasManagmentService = &mock.ContractAliasService{
+			SetContractAliasFn: func(ctx context.Context, alias *entity.ContractAlias) error {
+				return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+			},
+		}
+
+		vm.EngineService.Resume()
+
+		if err := vm.SetContractAlias(context.Background(), &entity.ContractAlias{ContractID: 1, Name: "canary", Rev: 2}); err == nil {
+			t.Fatal("Expected error, got nil")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EUNAUTHORIZED {
+			t.Errorf("Unexpected error code, got: %s, want: %s", errCode, apperr.EUNAUTHORIZED)
+		}
+	})
 }
 
 func TestVm_Stats(t *testing.T) {
@@ -1309,7 +2961,7 @@ func TestVm_UpdateContract(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1399,7 +3051,7 @@ func TestVm_UpdateContract(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1459,7 +3111,7 @@ func TestVm_UpdateContract(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1538,7 +3190,7 @@ func TestVm_Auhenticate(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1602,7 +3254,7 @@ func TestVm_Auhenticate(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1658,7 +3310,7 @@ func TestVm_Auhenticate(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1718,7 +3370,7 @@ func TestVm_CreateAuth(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1781,7 +3433,7 @@ func TestVm_CreateAuth(t *testing.T) {
 				FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 					return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 				},
-				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+				RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 					panic("should not be called")
 				},
 			}
@@ -1846,7 +3498,7 @@ func TestVm_CreateUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1905,7 +3557,7 @@ func TestVm_CreateUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -1967,7 +3619,7 @@ func TestVm_DeleteAuth(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2020,7 +3672,7 @@ func TestVm_DeleteAuth(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2078,7 +3730,7 @@ func TestVm_DeleteUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2131,7 +3783,7 @@ func TestVm_DeleteUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2192,7 +3844,7 @@ func TestVm_UpdateUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2253,7 +3905,7 @@ func TestVm_UpdateUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2314,7 +3966,7 @@ func TestVm_UpdateUser(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
@@ -2,6 +2,7 @@ package mgvm
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 	"github.com/music-gang/music-gang-api/event"
+	"gopkg.in/guregu/null.v4"
 )
 
 var _ service.VmService = (*MusicGangVM)(nil)
@@ -39,11 +41,24 @@ type MusicGangVM struct {
 	FuelMonitor     service.FuelMonitorService
 	CPUsPoolService service.CPUsPoolService
 
-	AuthManagmentService     service.AuthManagmentService
-	ContractManagmentService service.ContractManagmentService
-	UserManagmentService     service.UserManagmentService
-	StateService             service.StateService
-	CacheStateService        service.StateCacheService
+	// FuelLedger records every fuel burned and refunded by the operations.
+	// Can be nil if the fuel movements must not be recorded.
+	FuelLedger service.FuelLedgerRecorderService
+
+	// FuelWallet limits the fuel burned by every single user.
+	// Can be nil if only the global fuel tank is used.
+	FuelWallet service.FuelWalletService
+
+	// ExecutionService records every contract execution.
+	// Can be nil if the executions must not be recorded.
+	ExecutionService service.ExecutionManagementService
+
+	AuthManagmentService          service.AuthManagmentService
+	ContractAliasManagmentService service.ContractAliasManagmentService
+	ContractManagmentService      service.ContractManagmentService
+	UserManagmentService          service.UserManagmentService
+	StateService                  service.StateService
+	CacheStateService             service.StateCacheService
 }
 
 // MusicGangVM creates a new MusicGangVM.
@@ -180,13 +195,53 @@ func (vm *MusicGangVM) makeOperation(ctx context.Context, ref service.VmCallable
 		}
 	}
 
+	// execution is the record of the operation, it stays nil if the operation must not be recorded.
+	var execution *entity.Execution
+
+	// fuelEntries are the fuel movements of the operation, appended to the fuel ledger when the operation ends.
+	var fuelEntries entity.FuelLedgerEntries
+
+	defer func() {
+		// this defer is registered before the recover one, so it sees the final error.
+		if execution != nil {
+			vm.recordExecution(execution, res, err)
+		}
+		if len(fuelEntries) > 0 {
+			vm.recordFuelEntries(fuelEntries)
+		}
+	}()
+
 	defer func() {
 		// handle engine timeout or panic
 		if r := recover(); r != nil {
 			if r == service.EngineExecutionTimeoutPanic {
+				if execution != nil {
+					execution.Outcome = entity.ExecutionOutcomeTimeout
+				}
 				err = apperr.Errorf(apperr.EMGVM, "Timeout while executing operation")
 				return
 			}
+			if r == service.EngineFuelExhaustedPanic {
+				if execution != nil {
+					execution.Outcome = entity.ExecutionOutcomeOutOfFuel
+				}
+				err = apperr.Errorf(apperr.EMGVM, "Fuel exhausted while executing operation, max fuel is %d vFuel", ref.Fuel())
+				return
+			}
+			if r == service.EngineMemoryLimitPanic {
+				if execution != nil {
+					execution.Outcome = entity.ExecutionOutcomeMemoryLimit
+				}
+				maxMemory := entity.DefaultContractMemory
+				if revision := ref.Revision(); revision != nil {
+					maxMemory = revision.UnwrapMaxMemory()
+				}
+				err = apperr.Errorf(apperr.EMGVM_MEMORY_LIMIT, "Memory limit exceeded while executing operation, max memory is %d bytes", maxMemory)
+				return
+			}
+			if execution != nil {
+				execution.Outcome = entity.ExecutionOutcomePanic
+			}
 			err = apperr.Errorf(apperr.EMGVM, "Panic while executing operation %v", r)
 		}
 	}()
@@ -204,17 +259,48 @@ func (vm *MusicGangVM) makeOperation(ctx context.Context, ref service.VmCallable
 		defer release()
 	}
 
+	// burn the max fuel from the caller wallet before the global fuel tank, so a user over budget cannot drain it.
+	walletUserID := int64(0)
+	if vm.FuelWallet != nil {
+		if caller := ref.Caller(); caller != nil {
+			walletUserID = caller.ID
+		} else if ref.Operation() == entity.VmOperationExecuteContract {
+			// the anonymous callers of public contracts share their own wallet.
+			walletUserID = entity.AnonymousFuelWalletID
+		}
+	}
+
+	if walletUserID != 0 {
+		if err := vm.FuelWallet.Burn(vm.ctx, walletUserID, ref.MaxFuel()); err != nil {
+			return nil, err
+		}
+	}
+
 	// burn the max fuel consumed by the operation.
 	if err := vm.FuelTank.Burn(vm.ctx, ref.MaxFuel()); err != nil {
 		if err == service.ErrFuelTankNotEnough {
 			vm.LogService.Info("Not enough fuel to execute operation, pause engine")
 			vm.Pause()
 		}
+		if walletUserID != 0 {
+			// the operation is not executed, give back the fuel to the caller.
+			if err := vm.FuelWallet.Refuel(vm.ctx, walletUserID, ref.MaxFuel()); err != nil {
+				vm.LogService.Error(apperr.ErrorLog(err))
+			}
+		}
 		return nil, err
 	}
 
+	if vm.FuelLedger != nil {
+		fuelEntries = append(fuelEntries, newFuelLedgerEntry(ref, entity.FuelLedgerKindBurn, ref.MaxFuel()))
+	}
+
 	startOpTime := time.Now()
 
+	if vm.ExecutionService != nil && ref.Operation() == entity.VmOperationExecuteContract {
+		execution = newExecution(ref, startOpTime)
+	}
+
 	res, err = fn(ctx, ref)
 	if err != nil {
 		vm.LogService.Error(apperr.ErrorLog(err))
@@ -229,16 +315,131 @@ func (vm *MusicGangVM) makeOperation(ctx context.Context, ref service.VmCallable
 		// calculate the fuel consumed effectively.
 		effectiveFuelAmount := entity.FuelAmount(elapsed)
 
+		// the metered operations are charged on the work done, so the refund does not depend on the machine load.
+		if meter := ref.Meter(); meter != nil {
+			effectiveFuelAmount = meter.Charged()
+		}
+
 		// calculate the fuel saved.
 		fuelRecovered := ref.MaxFuel() - effectiveFuelAmount
 
 		// if fuel saved is greater than 0, refuel the tank.
 		if fuelRecovered > 0 {
-			if err := vm.FuelTank.Refuel(vm.ctx, fuelRecovered); err != nil {
+			if _, err := vm.FuelTank.Refuel(vm.ctx, fuelRecovered); err != nil {
 				return nil, err
 			}
+			if walletUserID != 0 {
+				if err := vm.FuelWallet.Refuel(vm.ctx, walletUserID, fuelRecovered); err != nil {
+					return nil, err
+				}
+			}
+			if execution != nil {
+				execution.FuelCharged = effectiveFuelAmount
+			}
+			if vm.FuelLedger != nil {
+				fuelEntries = append(fuelEntries, newFuelLedgerEntry(ref, entity.FuelLedgerKindRefund, fuelRecovered))
+			}
 		}
 	}
 
 	return res, nil
 }
+
+// newExecution creates the record of the given contract call, started at the given time.
+// Until the operation ends the whole max fuel is considered charged.
+// Returns nil if the call does not reference a contract revision.
+func newExecution(ref service.VmCallable, startedAt time.Time) *entity.Execution {
+
+	contract, revision := ref.Contract(), ref.Revision()
+	if contract == nil || revision == nil {
+		return nil
+	}
+
+	execution := &entity.Execution{
+		ContractID:   contract.ID,
+		RevisionID:   revision.ID,
+		Rev:          revision.Rev,
+		Alias:        ref.Alias(),
+		StartedAt:    startedAt.UTC(),
+		FuelReserved: ref.MaxFuel(),
+		FuelCharged:  ref.MaxFuel(),
+	}
+
+	if caller := ref.Caller(); caller != nil && caller.ID != 0 {
+		execution.UserID = null.IntFrom(caller.ID)
+	}
+
+	if sandbox := ref.Sandbox(); sandbox != nil {
+		execution.Seed = sandbox.Seed
+		execution.FrozenAt = null.TimeFrom(sandbox.Now)
+	}
+
+	execution.Snapshot = ref.Snapshot()
+
+	return execution
+}
+
+// recordExecution completes the execution with the operation result and stores it.
+// Failures are only logged, the record must never affect the result of the call.
+func (vm *MusicGangVM) recordExecution(execution *entity.Execution, res interface{}, opErr error) {
+
+	execution.EndedAt = time.Now().UTC()
+
+	if opErr != nil {
+		execution.ErrorCode = apperr.ErrorCode(opErr)
+		if execution.Outcome == "" {
+			switch execution.ErrorCode {
+			case apperr.EMGVM_MEMORY_LIMIT:
+				execution.Outcome = entity.ExecutionOutcomeMemoryLimit
+			case apperr.EMGVM_OUTPUT_TOO_LARGE:
+				execution.Outcome = entity.ExecutionOutcomeOutputTooLarge
+			case apperr.EMGVM_STATE_TOO_LARGE:
+				execution.Outcome = entity.ExecutionOutcomeStateTooLarge
+			default:
+				execution.Outcome = entity.ExecutionOutcomeError
+			}
+		}
+	} else {
+		execution.Outcome = entity.ExecutionOutcomeOK
+		if res != nil {
+			if b, err := json.Marshal(res); err == nil {
+				execution.ResultSize = len(b)
+			}
+		}
+	}
+
+	if err := vm.ExecutionService.CreateExecution(vm.ctx, execution); err != nil {
+		vm.LogService.Error(apperr.ErrorLog(err))
+	}
+}
+
+// newFuelLedgerEntry creates a fuel ledger entry for the given operation.
+func newFuelLedgerEntry(ref service.VmCallable, kind entity.FuelLedgerKind, amount entity.Fuel) *entity.FuelLedgerEntry {
+
+	entry := &entity.FuelLedgerEntry{
+		Kind:      kind,
+		Operation: ref.Operation(),
+		Amount:    amount,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if caller := ref.Caller(); caller != nil && caller.ID != 0 {
+		entry.UserID = null.IntFrom(caller.ID)
+	}
+	if contract := ref.Contract(); contract != nil && contract.ID != 0 {
+		entry.ContractID = null.IntFrom(contract.ID)
+	}
+	if revision := ref.Revision(); revision != nil && revision.ID != 0 {
+		entry.RevisionID = null.IntFrom(revision.ID)
+	}
+
+	return entry
+}
+
+// recordFuelEntries appends the fuel movements of an operation to the fuel ledger.
+// Failures are only logged, the ledger must never affect the result of the call.
+func (vm *MusicGangVM) recordFuelEntries(entries entity.FuelLedgerEntries) {
+	if err := vm.FuelLedger.RecordFuelEntries(vm.ctx, entries); err != nil {
+		vm.LogService.Error(apperr.ErrorLog(err))
+	}
+}
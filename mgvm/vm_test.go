@@ -391,8 +391,8 @@ func TestVm_MakeOperation(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
-				return nil
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+				return fuelToRefill, nil
 			},
 		}
 		vm.EngineService = &mock.EngineService{
@@ -515,7 +515,7 @@ func TestVm_MakeOperation(t *testing.T) {
 			FuelFn: func(ctx context.Context) (entity.Fuel, error) {
 				return entity.Fuel(atomic.LoadUint64((*uint64)(&currentFuel))), nil
 			},
-			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) error {
+			RefuelFn: func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 				panic("should not be called")
 			},
 		}
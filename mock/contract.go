@@ -2,20 +2,27 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 )
 
 var _ service.ContractService = (*ContractService)(nil)
+var _ service.ContractPurgeService = (*ContractService)(nil)
 
 type ContractService struct {
 	FindContractByIDFn             func(ctx context.Context, id int64) (*entity.Contract, error)
 	FindContractsFn                func(ctx context.Context, filter service.ContractFilter) (entity.Contracts, int, error)
 	FindRevisionByContractAndRevFn func(ctx context.Context, contractID int64, rev entity.RevisionNumber) (*entity.Revision, error)
+	FindRevisionsFn                func(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error)
 	CreateContractFn               func(ctx context.Context, contract *entity.Contract) error
 	DeleteContractFn               func(ctx context.Context, id int64) error
 	MakeRevisionFn                 func(ctx context.Context, revision *entity.Revision) error
+	PurgeContractsFn               func(ctx context.Context, deletedBefore time.Time) (int, error)
+	RestoreContractFn              func(ctx context.Context, id int64) (*entity.Contract, error)
+	RollbackActiveRevisionFn       func(ctx context.Context, id int64) (*entity.Contract, error)
+	SetActiveRevisionFn            func(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error)
 	UpdateContractFn               func(ctx context.Context, id int64, contract service.ContractUpdate) (*entity.Contract, error)
 }
 
@@ -40,6 +47,13 @@ func (c *ContractService) FindRevisionByContractAndRev(ctx context.Context, cont
 	return c.FindRevisionByContractAndRevFn(ctx, contractID, rev)
 }
 
+func (c *ContractService) FindRevisions(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+	if c.FindRevisionsFn == nil {
+		panic("FindRevisionsFn is not defined")
+	}
+	return c.FindRevisionsFn(ctx, filter)
+}
+
 func (c *ContractService) CreateContract(ctx context.Context, contract *entity.Contract) error {
 	if c.CreateContractFn == nil {
 		panic("CreateContractFn is not defined")
@@ -61,6 +75,34 @@ func (c *ContractService) MakeRevision(ctx context.Context, revision *entity.Rev
 	return c.MakeRevisionFn(ctx, revision)
 }
 
+func (c *ContractService) PurgeContracts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if c.PurgeContractsFn == nil {
+		panic("PurgeContractsFn is not defined")
+	}
+	return c.PurgeContractsFn(ctx, deletedBefore)
+}
+
+func (c *ContractService) RestoreContract(ctx context.Context, id int64) (*entity.Contract, error) {
+	if c.RestoreContractFn == nil {
+		panic("RestoreContractFn is not defined")
+	}
+	return c.RestoreContractFn(ctx, id)
+}
+
+func (c *ContractService) RollbackActiveRevision(ctx context.Context, id int64) (*entity.Contract, error) {
+	if c.RollbackActiveRevisionFn == nil {
+		panic("RollbackActiveRevisionFn is not defined")
+	}
+	return c.RollbackActiveRevisionFn(ctx, id)
+}
+
+func (c *ContractService) SetActiveRevision(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+	if c.SetActiveRevisionFn == nil {
+		panic("SetActiveRevisionFn is not defined")
+	}
+	return c.SetActiveRevisionFn(ctx, id, rev)
+}
+
 func (c *ContractService) UpdateContract(ctx context.Context, id int64, contract service.ContractUpdate) (*entity.Contract, error) {
 	if c.UpdateContractFn == nil {
 		panic("UpdateContractFn is not defined")
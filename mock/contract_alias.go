@@ -0,0 +1,45 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.ContractAliasService = (*ContractAliasService)(nil)
+
+type ContractAliasService struct {
+	DeleteContractAliasFn     func(ctx context.Context, contractID int64, name string) error
+	FindContractAliasByNameFn func(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error)
+	FindContractAliasesFn     func(ctx context.Context, contractID int64) (entity.ContractAliases, error)
+	SetContractAliasFn        func(ctx context.Context, alias *entity.ContractAlias) error
+}
+
+func (c *ContractAliasService) DeleteContractAlias(ctx context.Context, contractID int64, name string) error {
+	if c.DeleteContractAliasFn == nil {
+		panic("DeleteContractAliasFn is not defined")
+	}
+	return c.DeleteContractAliasFn(ctx, contractID, name)
+}
+
+func (c *ContractAliasService) FindContractAliasByName(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error) {
+	if c.FindContractAliasByNameFn == nil {
+		panic("FindContractAliasByNameFn is not defined")
+	}
+	return c.FindContractAliasByNameFn(ctx, contractID, name)
+}
+
+func (c *ContractAliasService) FindContractAliases(ctx context.Context, contractID int64) (entity.ContractAliases, error) {
+	if c.FindContractAliasesFn == nil {
+		panic("FindContractAliasesFn is not defined")
+	}
+	return c.FindContractAliasesFn(ctx, contractID)
+}
+
+func (c *ContractAliasService) SetContractAlias(ctx context.Context, alias *entity.ContractAlias) error {
+	if c.SetContractAliasFn == nil {
+		panic("SetContractAliasFn is not defined")
+	}
+	return c.SetContractAliasFn(ctx, alias)
+}
@@ -0,0 +1,29 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.ContractLogService = (*ContractLogService)(nil)
+
+type ContractLogService struct {
+	AppendLogsFn           func(ctx context.Context, contractID int64, logs entity.ContractLogs) error
+	FindLogsByContractIDFn func(ctx context.Context, contractID int64) (entity.ContractLogs, error)
+}
+
+func (s *ContractLogService) AppendLogs(ctx context.Context, contractID int64, logs entity.ContractLogs) error {
+	if s.AppendLogsFn == nil {
+		panic("AppendLogsFn is not defined")
+	}
+	return s.AppendLogsFn(ctx, contractID, logs)
+}
+
+func (s *ContractLogService) FindLogsByContractID(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+	if s.FindLogsByContractIDFn == nil {
+		panic("FindLogsByContractIDFn is not defined")
+	}
+	return s.FindLogsByContractIDFn(ctx, contractID)
+}
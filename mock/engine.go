@@ -10,12 +10,20 @@ import (
 var _ service.EngineService = (*EngineService)(nil)
 
 type EngineService struct {
-	ExecContractFn func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error)
-	IsRunningFn    func() bool
-	PauseFn        func() error
-	ResumeFn       func() error
-	StateFn        func() entity.VmState
-	StopFn         func() error
+	CompileContractFn func(ctx context.Context, revision *entity.Revision) error
+	ExecContractFn    func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error)
+	IsRunningFn       func() bool
+	PauseFn           func() error
+	ResumeFn          func() error
+	StateFn           func() entity.VmState
+	StopFn            func() error
+}
+
+func (e *EngineService) CompileContract(ctx context.Context, revision *entity.Revision) error {
+	if e.CompileContractFn == nil {
+		panic("CompileContractFn is not defined")
+	}
+	return e.CompileContractFn(ctx, revision)
 }
 
 func (e *EngineService) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
@@ -0,0 +1,60 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.ExecutionService = (*ExecutionService)(nil)
+var _ service.ExecutionSnapshotPurgeService = (*ExecutionService)(nil)
+
+type ExecutionService struct {
+	CreateExecutionFn         func(ctx context.Context, execution *entity.Execution) error
+	FindExecutionByIDFn       func(ctx context.Context, id int64) (*entity.Execution, error)
+	FindExecutionsFn          func(ctx context.Context, filter service.ExecutionFilter) (entity.Executions, int, error)
+	PurgeExecutionSnapshotsFn func(ctx context.Context, startedBefore time.Time) (int, error)
+}
+
+func (s *ExecutionService) CreateExecution(ctx context.Context, execution *entity.Execution) error {
+	if s.CreateExecutionFn == nil {
+		panic("CreateExecution not defined")
+	}
+	return s.CreateExecutionFn(ctx, execution)
+}
+
+func (s *ExecutionService) FindExecutionByID(ctx context.Context, id int64) (*entity.Execution, error) {
+	if s.FindExecutionByIDFn == nil {
+		panic("FindExecutionByID not defined")
+	}
+	return s.FindExecutionByIDFn(ctx, id)
+}
+
+func (s *ExecutionService) FindExecutions(ctx context.Context, filter service.ExecutionFilter) (entity.Executions, int, error) {
+	if s.FindExecutionsFn == nil {
+		panic("FindExecutions not defined")
+	}
+	return s.FindExecutionsFn(ctx, filter)
+}
+
+func (s *ExecutionService) PurgeExecutionSnapshots(ctx context.Context, startedBefore time.Time) (int, error) {
+	if s.PurgeExecutionSnapshotsFn == nil {
+		panic("PurgeExecutionSnapshots not defined")
+	}
+	return s.PurgeExecutionSnapshotsFn(ctx, startedBefore)
+}
+
+var _ service.ExecutionReplayService = (*ExecutionReplayService)(nil)
+
+type ExecutionReplayService struct {
+	ReplayExecutionFn func(ctx context.Context, execution *entity.Execution, revision *entity.Revision) (*entity.ExecutionReplay, error)
+}
+
+func (s *ExecutionReplayService) ReplayExecution(ctx context.Context, execution *entity.Execution, revision *entity.Revision) (*entity.ExecutionReplay, error) {
+	if s.ReplayExecutionFn == nil {
+		panic("ReplayExecution not defined")
+	}
+	return s.ReplayExecutionFn(ctx, execution, revision)
+}
@@ -3,13 +3,22 @@ package mock
 import (
 	"context"
 
+	"github.com/music-gang/music-gang-api/app/entity"
 	"github.com/music-gang/music-gang-api/app/service"
 )
 
 var _ service.ContractExecutorService = (*ExecutorService)(nil)
 
 type ExecutorService struct {
-	ExecContractFn func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error)
+	CompileContractFn func(ctx context.Context, revision *entity.Revision) error
+	ExecContractFn    func(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error)
+}
+
+func (e *ExecutorService) CompileContract(ctx context.Context, revision *entity.Revision) error {
+	if e.CompileContractFn == nil {
+		panic("CompileContractFn is not defined")
+	}
+	return e.CompileContractFn(ctx, revision)
 }
 
 func (e *ExecutorService) ExecContract(ctx context.Context, opt service.ContractCallOpt) (res interface{}, err error) {
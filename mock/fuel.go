@@ -14,7 +14,7 @@ type FuelTankService struct {
 
 	FuelFn func(ctx context.Context) (entity.Fuel, error)
 
-	RefuelFn func(ctx context.Context, fuelToRefill entity.Fuel) error
+	RefuelFn func(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error)
 
 	StatsFn func(ctx context.Context) (*entity.FuelStat, error)
 }
@@ -33,7 +33,7 @@ func (ft *FuelTankService) Fuel(ctx context.Context) (entity.Fuel, error) {
 	return ft.FuelFn(ctx)
 }
 
-func (ft *FuelTankService) Refuel(ctx context.Context, fuelToRefill entity.Fuel) error {
+func (ft *FuelTankService) Refuel(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 	if ft.RefuelFn == nil {
 		panic("RefuelFn is not defined")
 	}
@@ -57,8 +57,8 @@ func (ft *FuelTankServiceNoOp) Fuel(ctx context.Context) (entity.Fuel, error) {
 	return entity.Fuel(0), nil
 }
 
-func (ft *FuelTankServiceNoOp) Refuel(ctx context.Context, fuelToRefill entity.Fuel) error {
-	return nil
+func (ft *FuelTankServiceNoOp) Refuel(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
+	return entity.Fuel(0), nil
 }
 
 func (ft *FuelTankServiceNoOp) Stats(ctx context.Context) (*entity.FuelStat, error) {
@@ -135,3 +135,34 @@ func (fm *FuelMonitorServiceNoOp) StopMonitoring(ctx context.Context) error {
 	}
 	return nil
 }
+
+var _ service.FuelWalletService = (*FuelWalletService)(nil)
+
+type FuelWalletService struct {
+	BurnFn func(ctx context.Context, userID int64, fuel entity.Fuel) error
+
+	FuelWalletFn func(ctx context.Context, userID int64) (*entity.FuelWallet, error)
+
+	RefuelFn func(ctx context.Context, userID int64, fuel entity.Fuel) error
+}
+
+func (fw *FuelWalletService) Burn(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	if fw.BurnFn == nil {
+		panic("BurnFn is not defined")
+	}
+	return fw.BurnFn(ctx, userID, fuel)
+}
+
+func (fw *FuelWalletService) FuelWallet(ctx context.Context, userID int64) (*entity.FuelWallet, error) {
+	if fw.FuelWalletFn == nil {
+		panic("FuelWalletFn is not defined")
+	}
+	return fw.FuelWalletFn(ctx, userID)
+}
+
+func (fw *FuelWalletService) Refuel(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	if fw.RefuelFn == nil {
+		panic("RefuelFn is not defined")
+	}
+	return fw.RefuelFn(ctx, userID, fuel)
+}
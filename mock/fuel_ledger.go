@@ -0,0 +1,29 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.FuelLedgerService = (*FuelLedgerService)(nil)
+
+type FuelLedgerService struct {
+	FindFuelUsagesFn    func(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error)
+	RecordFuelEntriesFn func(ctx context.Context, entries entity.FuelLedgerEntries) error
+}
+
+func (s *FuelLedgerService) FindFuelUsages(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+	if s.FindFuelUsagesFn == nil {
+		panic("FindFuelUsages not defined")
+	}
+	return s.FindFuelUsagesFn(ctx, filter)
+}
+
+func (s *FuelLedgerService) RecordFuelEntries(ctx context.Context, entries entity.FuelLedgerEntries) error {
+	if s.RecordFuelEntriesFn == nil {
+		panic("RecordFuelEntries not defined")
+	}
+	return s.RecordFuelEntriesFn(ctx, entries)
+}
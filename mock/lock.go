@@ -34,3 +34,24 @@ func (ls *LockService) UnlockContext(ctx context.Context) (bool, error) {
 	}
 	return ls.UnlockContextFn(ctx)
 }
+
+var _ service.LeaderService = (*LeaderService)(nil)
+
+type LeaderService struct {
+	IsLeaderFn func(ctx context.Context) (bool, error)
+	NameFn     func() string
+}
+
+func (ls *LeaderService) IsLeader(ctx context.Context) (bool, error) {
+	if ls.IsLeaderFn == nil {
+		panic("IsLeader is not defined")
+	}
+	return ls.IsLeaderFn(ctx)
+}
+
+func (ls *LeaderService) Name() string {
+	if ls.NameFn == nil {
+		panic("Name is not defined")
+	}
+	return ls.NameFn()
+}
@@ -11,5 +11,7 @@ type VmCallableService struct {
 	*AuthService
 	*FuelTankService
 	*ContractService
+	*ContractAliasService
 	*ExecutorService
+	*ExecutionReplayService
 }
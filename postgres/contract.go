@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/music-gang/music-gang-api/app"
 	"github.com/music-gang/music-gang-api/app/apperr"
@@ -12,6 +13,7 @@ import (
 )
 
 var _ service.ContractService = (*ContractService)(nil)
+var _ service.ContractPurgeService = (*ContractService)(nil)
 
 // ContractService is the postgres implementation of the contract service.
 type ContractService struct {
@@ -47,10 +49,10 @@ func (cs *ContractService) CreateContract(ctx context.Context, contract *entity.
 	return nil
 }
 
-// DeleteContract deletes the contract with the given id.
+// DeleteContract soft deletes the contract with the given id.
 // Return EUNAUTHORIZED if the contract is not the same as the authenticated user.
 // Return ENOTFOUND if the contract does not exist.
-// This service also deletes the revisions of the contract.
+// The revisions and the states of the contract are kept until the contract is purged.
 func (cs *ContractService) DeleteContract(ctx context.Context, id int64) error {
 
 	tx, err := cs.db.BeginTx(ctx, nil)
@@ -132,10 +134,24 @@ func (cs *ContractService) FindRevisionByContractAndRev(ctx context.Context, con
 	return revision, nil
 }
 
+// FindRevisions returns a list of revisions filtered by the given options, the newest first.
+// Also returns the total count of revisions.
+func (cs *ContractService) FindRevisions(ctx context.Context, filter service.RevisionFilter) (entity.Revisions, int, error) {
+
+	tx, err := cs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findRevisions(ctx, tx, filter)
+}
+
 // MakeRevision creates a new revision of the contract.
+// The revision number is assigned in the transaction, it follows the last revision of the contract.
 // Return ENOTFOUND if the contract does not exist.
 // Return EINVALID if the revision is invalid.
-// It shouldn't return ECONFLICT because there's a UNIQUE constraint on the revision number and the Contract ID.
+// Return ECONFLICT if the compiled code is identical to the last revision.
 func (cs *ContractService) MakeRevision(ctx context.Context, revision *entity.Revision) error {
 
 	tx, err := cs.db.BeginTx(ctx, nil)
@@ -144,41 +160,112 @@ func (cs *ContractService) MakeRevision(ctx context.Context, revision *entity.Re
 	}
 	defer tx.Rollback()
 
-	if revision.ContractID == 0 {
-		return apperr.Errorf(apperr.EINVALID, "contract id is required")
+	if err := makeRevision(ctx, tx, revision); err != nil {
+		return err
 	}
 
-	contract, err := cs.FindContractByID(ctx, revision.ContractID)
-	if err != nil {
+	if err := attachRevisionAssociations(ctx, tx, revision); err != nil {
 		return err
-	} else if contract.UserID != app.UserIDFromContext(ctx) {
-		return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
 	}
 
-	var newRevNumber uint = 1
+	if err := tx.Commit(); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
 
-	if lastRevision, err := contract.UnwrapRevision(); err == nil {
-		newRevNumber = uint(lastRevision.Rev) + 1
+	return nil
+}
+
+// PurgeContracts deletes for good the contracts deleted before the given time, together with their revisions and states.
+// Returns the number of purged contracts.
+func (cs *ContractService) PurgeContracts(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	tx, err := cs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	if revision.MaxFuel == 0 {
-		revision.MaxFuel = contract.MaxFuel
+	n, err := purgeContracts(ctx, tx, deletedBefore)
+	if err != nil {
+		return 0, err
 	}
-	revision.Rev = entity.RevisionNumber(newRevNumber)
 
-	if err := makeRevision(ctx, tx, revision); err != nil {
-		return err
+	if err := tx.Commit(); err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
 	}
 
-	if err := attachRevisionAssociations(ctx, tx, revision); err != nil {
-		return err
+	return n, nil
+}
+
+// RollbackActiveRevision pins again the revision active before the last change of the active revision.
+// If no change is recorded, the revision preceding the active one is pinned.
+// Return ENOTFOUND if the contract does not exist.
+// Return ECONFLICT if there is no revision to roll back to.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func (cs *ContractService) RollbackActiveRevision(ctx context.Context, id int64) (*entity.Contract, error) {
+
+	tx, err := cs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	if err := tx.Commit(); err != nil {
-		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	contract, err := rollbackActiveRevision(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachContractAssociations(ctx, tx, contract); err != nil {
+		return nil, err
+	} else if err := tx.Commit(); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
 	}
 
-	return nil
+	return contract, nil
+}
+
+// RestoreContract restores the soft deleted contract with the given id.
+// Return ENOTFOUND if the contract does not exist or it is not deleted.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func (cs *ContractService) RestoreContract(ctx context.Context, id int64) (*entity.Contract, error) {
+
+	tx, err := cs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	contract, err := restoreContract(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachContractAssociations(ctx, tx, contract); err != nil {
+		return nil, err
+	} else if err := tx.Commit(); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return contract, nil
+}
+
+// SetActiveRevision pins the revision run by the calls without a revision number, rev 0 unpins it so the last revision is run.
+// Return ENOTFOUND if the contract or the revision does not exist.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func (cs *ContractService) SetActiveRevision(ctx context.Context, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+
+	tx, err := cs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	contract, err := setActiveRevision(ctx, tx, id, rev)
+	if err != nil {
+		return nil, err
+	} else if err := attachContractAssociations(ctx, tx, contract); err != nil {
+		return nil, err
+	} else if err := tx.Commit(); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return contract, nil
 }
 
 // UpdateContract updates the given contract.
@@ -217,6 +304,12 @@ func attachContractAssociations(ctx context.Context, tx *Tx, contract *entity.Co
 
 	contract.LastRevision = lastContractRevision
 
+	if contract.ActiveRev != 0 {
+		if contract.ActiveRevision, err = findRevisionByContractAndRev(ctx, tx, contract.ID, contract.ActiveRev); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -259,7 +352,7 @@ func createContract(ctx context.Context, tx *Tx, contract *entity.Contract) erro
 	return nil
 }
 
-// deleteContract deletes the contract with the given id.
+// deleteContract soft deletes the contract with the given id.
 // Return EFORBIDDEN if the user is not allowed to delete the contract.
 // Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
 func deleteContract(ctx context.Context, tx *Tx, id int64) error {
@@ -270,7 +363,7 @@ func deleteContract(ctx context.Context, tx *Tx, id int64) error {
 		return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
 	}
 
-	if _, err := tx.ExecContext(ctx, query.DeleteContractQuery(), id); err != nil {
+	if _, err := tx.ExecContext(ctx, query.DeleteContractQuery(), tx.now, id); err != nil {
 		return apperr.Errorf(apperr.EINTERNAL, "failed to delete contract: %v", err)
 	}
 
@@ -308,8 +401,33 @@ func findContracts(ctx context.Context, tx *Tx, filter service.ContractFilter) (
 		args = append(args, *v)
 		counterParameter++
 	}
+	if v := filter.Description; v != nil {
+		where = append(where, fmt.Sprintf("description LIKE '%%' || $%d || '%%'", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.UserID; v != nil {
+		where = append(where, fmt.Sprintf("user_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.VisibleTo; v != nil {
+		where = append(where, fmt.Sprintf("(visibility = $%d OR user_id = $%d)", counterParameter, counterParameter+1))
+		args = append(args, entity.VisibilityPublic, *v)
+		counterParameter += 2
+	}
+	if filter.Deleted {
+		where = append(where, "deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "deleted_at IS NULL")
+	}
+
+	orderBy, err := contractsOrderBy(filter)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	rows, err := tx.QueryContext(ctx, query.SelectContractsQuery(where, filter.Limit, filter.Offset), args...)
+	rows, err := tx.QueryContext(ctx, query.SelectContractsQuery(where, orderBy, filter.Limit, filter.Offset), args...)
 
 	if err != nil {
 		return nil, 0, apperr.Errorf(apperr.EINTERNAL, "failed to query contracts: %v", err)
@@ -330,6 +448,8 @@ func findContracts(ctx context.Context, tx *Tx, filter service.ContractFilter) (
 			&contract.Visibility,
 			&contract.MaxFuel,
 			&contract.Stateful,
+			&contract.ActiveRev,
+			&contract.PreviousActiveRev,
 			&contract.CreatedAt,
 			&contract.UpdatedAt,
 			&n,
@@ -346,6 +466,32 @@ func findContracts(ctx context.Context, tx *Tx, filter service.ContractFilter) (
 	return contracts, n, nil
 }
 
+// contractsOrderBy returns the ORDER BY clause for the given filter.
+// The id is always appended to keep the pagination stable between contracts with the same time.
+func contractsOrderBy(filter service.ContractFilter) (string, error) {
+
+	orderBy := filter.OrderBy
+	if orderBy == "" {
+		orderBy = entity.ContractOrderByID
+	}
+
+	// the order by is validated against a fixed list, so it is safe to use it inside the query.
+	if err := orderBy.Validate(); err != nil {
+		return "", err
+	}
+
+	direction := "ASC"
+	if filter.OrderDesc {
+		direction = "DESC"
+	}
+
+	if orderBy == entity.ContractOrderByID {
+		return fmt.Sprintf("id %s", direction), nil
+	}
+
+	return fmt.Sprintf("%s %s, id %s", orderBy, direction, direction), nil
+}
+
 // findRevisionByContractAndRev returns the revision filtered by the contract and revision number.
 // If rev is eq to 0, the latest revision is returned.
 // Return ENOTFOUND if the revision is not found.
@@ -405,7 +551,9 @@ func findRevisions(ctx context.Context, tx *Tx, filter service.RevisionFilter) (
 			&revision.ContractID,
 			&revision.Notes,
 			&revision.CompiledCode,
+			&revision.CodeHash,
 			&revision.MaxFuel,
+			&revision.MaxMemory,
 			&revision.CreatedAt,
 			&n,
 		); err != nil {
@@ -421,11 +569,40 @@ func findRevisions(ctx context.Context, tx *Tx, filter service.RevisionFilter) (
 	return revisions, n, nil
 }
 
-// makeRevision creates a new revision for the contract passed in.
+// makeRevision creates a new revision for the contract passed in, numbered after the last revision of the contract.
+// The contract row is locked first, so the concurrent revisions of the same contract never get the same number.
+// Return ECONFLICT if the compiled code is identical to the last revision.
 func makeRevision(ctx context.Context, tx *Tx, revision *entity.Revision) error {
 
+	if revision.ContractID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "contract id is required")
+	}
+
 	revision.CreatedAt = tx.now
 
+	if _, err := tx.ExecContext(ctx, query.LockContractQuery(), revision.ContractID); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to lock contract: %v", err)
+	}
+
+	contract, err := findOwnedContract(ctx, tx, revision.ContractID)
+	if err != nil {
+		return err
+	}
+
+	revision.Rev = 1
+	revision.CodeHash = entity.HashRevisionCode(revision.CompiledCode)
+
+	if lastRevision, err := contract.UnwrapRevision(); err == nil {
+		if lastRevision.UnwrapCodeHash() == revision.CodeHash {
+			return apperr.Errorf(apperr.ECONFLICT, "compiled code is identical to the revision %d", lastRevision.Rev)
+		}
+		revision.Rev = lastRevision.Rev + 1
+	}
+
+	if revision.MaxFuel == 0 {
+		revision.MaxFuel = contract.MaxFuel
+	}
+
 	if err := revision.Validate(); err != nil {
 		return err
 	}
@@ -436,7 +613,9 @@ func makeRevision(ctx context.Context, tx *Tx, revision *entity.Revision) error
 		revision.ContractID,
 		revision.Notes,
 		revision.CompiledCode,
+		revision.CodeHash,
 		revision.MaxFuel,
+		revision.MaxMemory,
 		revision.CreatedAt).Scan(&revision.ID); err != nil {
 		return apperr.Errorf(apperr.EINTERNAL, "failed to insert revision: %v", err)
 	}
@@ -444,6 +623,132 @@ func makeRevision(ctx context.Context, tx *Tx, revision *entity.Revision) error
 	return nil
 }
 
+// purgeContracts deletes the contracts deleted before the given time.
+// The revisions, the states and the executions of the contracts are deleted in cascade.
+func purgeContracts(ctx context.Context, tx *Tx, deletedBefore time.Time) (int, error) {
+
+	res, err := tx.ExecContext(ctx, query.PurgeContractsQuery(), deletedBefore)
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to purge contracts: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to count purged contracts: %v", err)
+	}
+
+	return int(n), nil
+}
+
+// restoreContract restores the soft deleted contract with the given id.
+// Return ENOTFOUND if the contract is not deleted.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func restoreContract(ctx context.Context, tx *Tx, id int64) (*entity.Contract, error) {
+
+	contracts, _, err := findContracts(ctx, tx, service.ContractFilter{ID: &id, Deleted: true})
+	if err != nil {
+		return nil, err
+	} else if len(contracts) == 0 {
+		return nil, apperr.Errorf(apperr.ENOTFOUND, "deleted contract not found")
+	}
+
+	contract := contracts[0]
+
+	if contract.UserID != app.UserIDFromContext(ctx) {
+		return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+	}
+
+	contract.UpdatedAt = tx.now
+
+	if _, err := tx.ExecContext(ctx, query.RestoreContractQuery(), contract.UpdatedAt, id); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to restore contract: %v", err)
+	}
+
+	return contract, nil
+}
+
+// findOwnedContract returns the contract with the given id and its associations.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func findOwnedContract(ctx context.Context, tx *Tx, id int64) (*entity.Contract, error) {
+
+	contract, err := findContractByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if contract.UserID != app.UserIDFromContext(ctx) {
+		return nil, apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+	} else if err := attachContractAssociations(ctx, tx, contract); err != nil {
+		return nil, err
+	}
+
+	return contract, nil
+}
+
+// rollbackActiveRevision pins again the revision active before the last change of the active revision.
+// If no change is recorded, the revision preceding the active one is pinned.
+// Return ECONFLICT if there is no revision to roll back to.
+func rollbackActiveRevision(ctx context.Context, tx *Tx, id int64) (*entity.Contract, error) {
+
+	contract, err := findOwnedContract(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	activeRev := contract.EffectiveActiveRev()
+
+	rev := contract.PreviousActiveRev
+	if rev == 0 || rev == activeRev {
+		if activeRev <= 1 {
+			return nil, apperr.Errorf(apperr.ECONFLICT, "no revision to roll back to")
+		}
+		rev = activeRev - 1
+	}
+
+	if _, err := findRevisionByContractAndRev(ctx, tx, id, rev); err != nil {
+		return nil, err
+	}
+
+	return updateActiveRevision(ctx, tx, contract, rev)
+}
+
+// setActiveRevision pins the given revision of the contract, rev 0 unpins it.
+// Return ENOTFOUND if the revision does not exist.
+func setActiveRevision(ctx context.Context, tx *Tx, id int64, rev entity.RevisionNumber) (*entity.Contract, error) {
+
+	contract, err := findOwnedContract(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if rev != 0 {
+		if _, err := findRevisionByContractAndRev(ctx, tx, id, rev); err != nil {
+			return nil, err
+		}
+	}
+
+	return updateActiveRevision(ctx, tx, contract, rev)
+}
+
+// updateActiveRevision stores the given active revision, the current one is recorded as the rollback target.
+func updateActiveRevision(ctx context.Context, tx *Tx, contract *entity.Contract, rev entity.RevisionNumber) (*entity.Contract, error) {
+
+	contract.PreviousActiveRev = contract.EffectiveActiveRev()
+	contract.ActiveRev = rev
+	contract.UpdatedAt = tx.now
+
+	if _, err := tx.ExecContext(ctx, query.UpdateActiveRevisionQuery(),
+		contract.ActiveRev,
+		contract.PreviousActiveRev,
+		contract.UpdatedAt,
+		contract.ID); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to update active revision: %v", err)
+	}
+
+	// the associations are attached again by the caller.
+	contract.ActiveRevision = nil
+
+	return contract, nil
+}
+
 // updateContract updates the contract with the given id.
 // Return EFORBIDDEN if the user is not allowed to update the contract.
 // Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
@@ -429,6 +429,290 @@ func TestContract_DeleteContract(t *testing.T) {
 	})
 }
 
+func TestContract_RestoreContract(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-contract"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test-contract",
+				Visibility: entity.VisibilityPublic,
+				MaxFuel:    entity.FuelExtremeActionAmount,
+			},
+			Revision: &entity.Revision{
+				Version:      entity.AnchorageVersion,
+				CompiledCode: []byte("var result = 1"),
+			},
+			User: userForContract,
+		})
+
+		cs := postgres.NewContractService(db)
+
+		if err := cs.DeleteContract(ctx, revision.ContractID); err != nil {
+			t.Fatal(err)
+		}
+
+		if contracts, n, err := cs.FindContracts(ctx, service.ContractFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(contracts) != 0 {
+			t.Fatalf("expected no contracts, got %d", n)
+		}
+
+		if _, err := cs.FindRevisionByContractAndRev(ctx, revision.ContractID, revision.Rev); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+
+		contract, err := cs.RestoreContract(ctx, revision.ContractID)
+		if err != nil {
+			t.Fatal(err)
+		} else if contract.LastRevision == nil || contract.LastRevision.ID != revision.ID {
+			t.Fatalf("expected the revisions to be kept, got %v", contract.LastRevision)
+		}
+
+		if _, err := cs.FindContractByID(ctx, revision.ContractID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, userForContract)
+
+		if _, err := postgres.NewContractService(db).RestoreContract(ctx, contract.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, userForContract)
+
+		cs := postgres.NewContractService(db)
+
+		if err := cs.DeleteContract(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-2"})
+
+		if _, err := cs.RestoreContract(ctx1, contract.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+}
+
+func TestContract_PurgeContracts(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		deleted, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract-deleted",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract"})
+
+		live := &entity.Contract{
+			Name:       "test-contract-live",
+			UserID:     deleted.UserID,
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}
+		if err := cs.CreateContract(ctx, live); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cs.DeleteContract(ctx, deleted.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// the contract is deleted after the retention period, nothing to purge.
+		if n, err := cs.PurgeContracts(ctx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("expected 0 purged contracts, got %d", n)
+		}
+
+		if n, err := cs.PurgeContracts(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("expected 1 purged contract, got %d", n)
+		}
+
+		if _, err := cs.RestoreContract(ctx, deleted.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+
+		if _, err := cs.FindContractByID(ctx, live.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestContract_SetActiveRevision(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-active-revision"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 3, userForContract)
+
+		if contract, err := cs.SetActiveRevision(ctx, contractID, 1); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 1 || contract.PreviousActiveRev != 3 {
+			t.Fatalf("expected active revision 1 and previous 3, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		}
+
+		if contract, err := cs.FindContractByID(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if revision, err := contract.UnwrapActiveRevision(); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 1 {
+			t.Fatalf("expected active revision %d, got %d", 1, revision.Rev)
+		} else if contract.LastRevision.Rev != 3 {
+			t.Fatalf("expected last revision %d, got %d", 3, contract.LastRevision.Rev)
+		}
+
+		// unpinned, the calls run again the last revision.
+		if contract, err := cs.SetActiveRevision(ctx, contractID, 0); err != nil {
+			t.Fatal(err)
+		} else if revision, err := contract.UnwrapActiveRevision(); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 3 {
+			t.Fatalf("expected active revision %d, got %d", 3, revision.Rev)
+		}
+	})
+
+	t.Run("RevisionNotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if _, err := postgres.NewContractService(db).SetActiveRevision(ctx, contractID, 2); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, _ := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-active-revision-2"})
+
+		if _, err := postgres.NewContractService(db).SetActiveRevision(ctx1, contractID, 1); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+}
+
+func TestContract_RollbackActiveRevision(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-active-revision"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 3, userForContract)
+
+		// no change recorded, the revision preceding the last one is pinned.
+		if contract, err := cs.RollbackActiveRevision(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 2 || contract.PreviousActiveRev != 3 {
+			t.Fatalf("expected active revision 2 and previous 3, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		} else if contract.ActiveRevision == nil || contract.ActiveRevision.Rev != 2 {
+			t.Fatalf("expected active revision 2 attached, got %v", contract.ActiveRevision)
+		}
+
+		// a second rollback restores the revision active before the first one.
+		if contract, err := cs.RollbackActiveRevision(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 3 || contract.PreviousActiveRev != 2 {
+			t.Fatalf("expected active revision 3 and previous 2, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		}
+	})
+
+	t.Run("NothingToRollback", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if _, err := postgres.NewContractService(db).RollbackActiveRevision(ctx, contractID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ECONFLICT {
+			t.Fatalf("expected %s, got %s", apperr.ECONFLICT, code)
+		}
+	})
+}
+
 func TestContract_UpdateContract(t *testing.T) {
 
 	t.Run("OK", func(t *testing.T) {
@@ -742,6 +1026,85 @@ func TestContract_FindContracts(t *testing.T) {
 			t.Fatal("Expected no contracts")
 		}
 	})
+
+	t.Run("Visibility", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		publicContract, _ := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "public-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract-public"})
+
+		privateContract, _ := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "private-contract",
+			Visibility: entity.VisibilityPrivate,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract-private"})
+
+		anonymousID := int64(0)
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{VisibleTo: &anonymousID}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || contracts[0].ID != publicContract.ID {
+			t.Fatalf("expected only the public contract, got %d contracts", n)
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{VisibleTo: &privateContract.UserID}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(contracts) != 2 {
+			t.Fatalf("expected the owner to see both contracts, got %d", n)
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{UserID: &privateContract.UserID, VisibleTo: &publicContract.UserID}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(contracts) != 0 {
+			t.Fatalf("expected private contracts of other users to be hidden, got %d", n)
+		}
+	})
+
+	t.Run("OrderBy", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		for i := 0; i < 3; i++ {
+			MustCreateContract(t, context.Background(), db, &entity.Contract{
+				Name:       "test-contract-" + strconv.Itoa(i),
+				Visibility: entity.VisibilityPublic,
+				MaxFuel:    entity.FuelExtremeActionAmount,
+			}, &entity.User{Name: "test-contract-" + strconv.Itoa(i)})
+		}
+
+		if contracts, _, err := cs.FindContracts(context.Background(), service.ContractFilter{
+			OrderBy:   entity.ContractOrderByCreatedAt,
+			OrderDesc: true,
+		}); err != nil {
+			t.Fatal(err)
+		} else if len(contracts) != 3 {
+			t.Fatalf("expected 3, got %d", len(contracts))
+		} else if contracts[0].Name != "test-contract-2" || contracts[2].Name != "test-contract-0" {
+			t.Fatalf("expected contracts sorted by creation desc, got %s first", contracts[0].Name)
+		}
+
+		if _, _, err := cs.FindContracts(context.Background(), service.ContractFilter{
+			OrderBy: "name; DROP TABLE contracts; --",
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
 }
 
 func TestContract_MakeRevision(t *testing.T) {
@@ -851,7 +1214,7 @@ func TestContract_MakeRevision(t *testing.T) {
 			t.Fatalf("expected revision rev to be 1, got %d", revision.Rev)
 		}
 
-		newCode := "test-code"
+		newCode := "test-code-2"
 		newNotes := "test-notes"
 
 		newRevision := &entity.Revision{
@@ -866,6 +1229,150 @@ func TestContract_MakeRevision(t *testing.T) {
 			t.Fatal(err)
 		} else if newRevision.Rev != 2 {
 			t.Fatalf("expected revision rev to be 2, got %d", newRevision.Rev)
+		} else if newRevision.CodeHash != entity.HashRevisionCode([]byte(newCode)) {
+			t.Fatalf("expected code hash %s, got %s", entity.HashRevisionCode([]byte(newCode)), newRevision.CodeHash)
+		}
+	})
+
+	t.Run("ClientRevIgnored", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, &entity.User{Name: "test-make-revision"})
+
+		revision := &entity.Revision{
+			Rev:          10,
+			CodeHash:     "client-hash",
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code-new"),
+		}
+
+		if err := cs.MakeRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 3 {
+			t.Fatalf("expected revision rev to be 3, got %d", revision.Rev)
+		} else if revision.CodeHash != entity.HashRevisionCode(revision.CompiledCode) {
+			t.Fatalf("expected code hash %s, got %s", entity.HashRevisionCode(revision.CompiledCode), revision.CodeHash)
+		}
+	})
+
+	t.Run("DuplicateCode", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, &entity.User{Name: "test-make-revision"})
+
+		// the last revision made by MustCreateRevisions.
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code-1"),
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ECONFLICT {
+			t.Fatalf("expected %s, got %s", apperr.ECONFLICT, code)
+		}
+
+		// the code of an older revision is a legit roll forward.
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ContractNotTouched", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision"})
+
+		createdAt := contract.CreatedAt
+		db.Now = func() time.Time { return createdAt.Add(time.Hour) }
+
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			CompiledCode: []byte("test-code"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := cs.FindContractByID(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		} else if !found.UpdatedAt.Equal(contract.UpdatedAt) {
+			t.Fatalf("expected updated at %v, got %v", contract.UpdatedAt, found.UpdatedAt)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision"})
+
+		const n = 5
+
+		revs := make(chan int, n)
+		errs := make(chan error, n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				revision := &entity.Revision{
+					Version:      entity.AnchorageVersion,
+					ContractID:   contract.ID,
+					CompiledCode: []byte(fmt.Sprintf("test-code-%d", i)),
+				}
+				if err := cs.MakeRevision(ctx, revision); err != nil {
+					errs <- err
+					return
+				}
+				revs <- int(revision.Rev)
+			}(i)
+		}
+
+		seen := make(map[int]bool)
+		for i := 0; i < n; i++ {
+			select {
+			case err := <-errs:
+				t.Fatal(err)
+			case rev := <-revs:
+				if seen[rev] {
+					t.Fatalf("expected unique revision numbers, got %d twice", rev)
+				}
+				seen[rev] = true
+			}
 		}
 	})
 
@@ -1065,6 +1572,60 @@ func TestContract_MakeRevision(t *testing.T) {
 	})
 }
 
+func TestContract_FindRevisions(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := postgres.NewContractService(db)
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test-find-revisions",
+				MaxFuel:    entity.FuelInstantActionAmount,
+				Visibility: entity.VisibilityPublic,
+			},
+			User: &entity.User{Name: "test-find-revisions"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		for i := 0; i < 2; i++ {
+			if err := cs.MakeRevision(ctx, &entity.Revision{
+				ContractID:   revision.ContractID,
+				CompiledCode: []byte(fmt.Sprintf("test-code-%d", i+2)),
+				Version:      entity.CurrentRevisionVersion,
+				Notes:        fmt.Sprintf("revision %d", i+2),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		revisions, n, err := cs.FindRevisions(ctx, service.RevisionFilter{ContractID: revision.ContractID, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 3 {
+			t.Fatalf("expected 3 revisions, got %d", n)
+		} else if len(revisions) != 2 {
+			t.Fatalf("expected 2 revisions, got %d", len(revisions))
+		} else if revisions[0].Rev != 3 || revisions[1].Rev != 2 {
+			t.Fatalf("expected revisions 3 and 2, got %d and %d", revisions[0].Rev, revisions[1].Rev)
+		}
+
+		if revisions, _, err := cs.FindRevisions(ctx, service.RevisionFilter{ContractID: revision.ContractID, Limit: 2, Offset: 2}); err != nil {
+			t.Fatal(err)
+		} else if len(revisions) != 1 || revisions[0].ID != revision.ID {
+			t.Fatalf("expected the first revision, got %v", revisions)
+		}
+	})
+}
+
 func TestContract_FindRevisionByContractAndRev(t *testing.T) {
 
 	t.Run("OK", func(t *testing.T) {
@@ -1269,6 +1830,33 @@ func MustCreateRevision(tb testing.TB, ctx context.Context, db *postgres.DB, dat
 	return data.Revision, ctx
 }
 
+// MustCreateRevisions creates a contract owned by the given user with n revisions.
+func MustCreateRevisions(tb testing.TB, ctx context.Context, db *postgres.DB, n int, user *entity.User) (int64, context.Context) {
+	tb.Helper()
+	revision, ctx := MustCreateRevision(tb, ctx, db, DataToMakeRevision{
+		Contract: &entity.Contract{
+			Name:       "test-contract",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		},
+		Revision: &entity.Revision{
+			CompiledCode: []byte("test-code"),
+			Version:      entity.CurrentRevisionVersion,
+		},
+		User: user,
+	})
+	for i := 1; i < n; i++ {
+		if err := postgres.NewContractService(db).MakeRevision(ctx, &entity.Revision{
+			ContractID:   revision.ContractID,
+			CompiledCode: []byte(fmt.Sprintf("test-code-%d", i)),
+			Version:      entity.CurrentRevisionVersion,
+		}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return revision.ContractID, ctx
+}
+
 func TruncateTablesForContractTests(tb testing.TB, db *postgres.DB) {
 	tb.Helper()
 
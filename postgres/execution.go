@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/postgres/query"
+)
+
+var _ service.ExecutionService = (*ExecutionService)(nil)
+var _ service.ExecutionSnapshotPurgeService = (*ExecutionService)(nil)
+
+// ExecutionService is the postgres implementation of the execution service.
+type ExecutionService struct {
+	db *DB
+}
+
+// NewExecutionService creates a new execution service.
+func NewExecutionService(db *DB) *ExecutionService {
+	return &ExecutionService{db: db}
+}
+
+// CreateExecution stores a new execution record, together with its recorded call if any.
+// Return EINVALID if the execution is invalid.
+func (es *ExecutionService) CreateExecution(ctx context.Context, execution *entity.Execution) error {
+
+	tx, err := es.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createExecution(ctx, tx, execution); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// PurgeExecutionSnapshots deletes the recorded calls of the executions started before the given time, the executions are kept.
+// Returns the number of purged snapshots.
+func (es *ExecutionService) PurgeExecutionSnapshots(ctx context.Context, startedBefore time.Time) (int, error) {
+
+	tx, err := es.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := purgeExecutionSnapshots(ctx, tx, startedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return n, nil
+}
+
+// FindExecutionByID returns the execution with the given id, together with its recorded call.
+// Return ENOTFOUND if the execution does not exist.
+func (es *ExecutionService) FindExecutionByID(ctx context.Context, id int64) (*entity.Execution, error) {
+
+	tx, err := es.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findExecutionByID(ctx, tx, id)
+}
+
+// FindExecutions returns a list of executions filtered by the given options.
+// Also returns the total count of executions.
+func (es *ExecutionService) FindExecutions(ctx context.Context, filter service.ExecutionFilter) (entity.Executions, int, error) {
+
+	tx, err := es.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findExecutions(ctx, tx, filter)
+}
+
+// createExecution stores a new execution record.
+func createExecution(ctx context.Context, tx *Tx, execution *entity.Execution) error {
+
+	if err := execution.Validate(); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, query.InsertExecutionQuery(),
+		execution.ContractID,
+		execution.RevisionID,
+		execution.Rev,
+		execution.Alias,
+		execution.UserID,
+		execution.StartedAt,
+		execution.EndedAt,
+		execution.FuelReserved,
+		execution.FuelCharged,
+		execution.Outcome,
+		execution.ErrorCode,
+		execution.ResultSize,
+		execution.Seed,
+		execution.FrozenAt,
+	).Scan(&execution.ID); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to insert execution: %v", err)
+	}
+
+	if execution.Snapshot != nil {
+		if err := createExecutionSnapshot(ctx, tx, execution.ID, execution.Snapshot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createExecutionSnapshot stores the recorded call of the execution.
+func createExecutionSnapshot(ctx context.Context, tx *Tx, executionID int64, snapshot *entity.ExecutionSnapshot) error {
+
+	input, err := json.Marshal(snapshot.Input)
+	if err != nil {
+		return apperr.Errorf(apperr.EINVALID, "failed to encode execution input: %v", err)
+	}
+
+	result, err := json.Marshal(snapshot.Result)
+	if err != nil {
+		return apperr.Errorf(apperr.EINVALID, "failed to encode execution result: %v", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query.InsertExecutionSnapshotQuery(),
+		executionID,
+		input,
+		snapshot.StateBefore,
+		result,
+		snapshot.StateAfter,
+	); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to insert execution snapshot: %v", err)
+	}
+
+	return nil
+}
+
+// findExecutionByID returns the execution with the given id.
+// Return ENOTFOUND if the execution does not exist.
+func findExecutionByID(ctx context.Context, tx *Tx, id int64) (*entity.Execution, error) {
+
+	executions, _, err := findExecutions(ctx, tx, service.ExecutionFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(executions) == 0 {
+		return nil, apperr.Errorf(apperr.ENOTFOUND, "execution not found")
+	}
+
+	execution := executions[0]
+
+	if execution.Snapshot, err = findExecutionSnapshot(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	return execution, nil
+}
+
+// purgeExecutionSnapshots deletes the recorded calls of the executions started before the given time.
+func purgeExecutionSnapshots(ctx context.Context, tx *Tx, startedBefore time.Time) (int, error) {
+
+	res, err := tx.ExecContext(ctx, query.PurgeExecutionSnapshotsQuery(), startedBefore)
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to purge execution snapshots: %v", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to count purged execution snapshots: %v", err)
+	}
+
+	return int(n), nil
+}
+
+// findExecutionSnapshot returns the recorded call of the execution.
+// Return nil if the call is not recorded.
+func findExecutionSnapshot(ctx context.Context, tx *Tx, executionID int64) (*entity.ExecutionSnapshot, error) {
+
+	var snapshot entity.ExecutionSnapshot
+	var input, result []byte
+
+	if err := tx.QueryRowContext(ctx, query.SelectExecutionSnapshotQuery(), executionID).Scan(
+		&input,
+		&snapshot.StateBefore,
+		&result,
+		&snapshot.StateAfter,
+	); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to scan execution snapshot: %v", err)
+	}
+
+	if err := json.Unmarshal(input, &snapshot.Input); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to decode execution input: %v", err)
+	}
+
+	if err := json.Unmarshal(result, &snapshot.Result); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to decode execution result: %v", err)
+	}
+
+	return &snapshot, nil
+}
+
+// findExecutions returns a list of executions filtered by the given options, most recent first.
+// Also returns the total count of executions.
+func findExecutions(ctx context.Context, tx *Tx, filter service.ExecutionFilter) (_ entity.Executions, n int, err error) {
+
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	counterParameter := 1
+
+	if v := filter.ID; v != nil {
+		where = append(where, fmt.Sprintf("id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.ContractID; v != nil {
+		where = append(where, fmt.Sprintf("contract_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.RevisionID; v != nil {
+		where = append(where, fmt.Sprintf("revision_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.UserID; v != nil {
+		where = append(where, fmt.Sprintf("user_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.Outcome; v != nil {
+		where = append(where, fmt.Sprintf("outcome = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.StartedAfter; v != nil {
+		where = append(where, fmt.Sprintf("started_at >= $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.StartedBefore; v != nil {
+		where = append(where, fmt.Sprintf("started_at < $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+
+	rows, err := tx.QueryContext(ctx, query.SelectExecutionsQuery(where, filter.Limit, filter.Offset), args...)
+	if err != nil {
+		return nil, 0, apperr.Errorf(apperr.EINTERNAL, "failed to query executions: %v", err)
+	}
+	defer rows.Close()
+
+	executions := make(entity.Executions, 0)
+
+	for rows.Next() {
+
+		var execution entity.Execution
+
+		if err := rows.Scan(
+			&execution.ID,
+			&execution.ContractID,
+			&execution.RevisionID,
+			&execution.Rev,
+			&execution.Alias,
+			&execution.UserID,
+			&execution.StartedAt,
+			&execution.EndedAt,
+			&execution.FuelReserved,
+			&execution.FuelCharged,
+			&execution.Outcome,
+			&execution.ErrorCode,
+			&execution.ResultSize,
+			&execution.Seed,
+			&execution.FrozenAt,
+			&n,
+		); err != nil {
+			return nil, 0, apperr.Errorf(apperr.EINTERNAL, "failed to scan execution: %v", err)
+		}
+
+		executions = append(executions, &execution)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, apperr.Errorf(apperr.EINTERNAL, "failed to iterate over executions: %v", err)
+	}
+
+	return executions, n, nil
+}
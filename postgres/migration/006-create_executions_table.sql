@@ -0,0 +1,16 @@
+CREATE TABLE executions
+(
+    id BIGSERIAL PRIMARY KEY,
+    contract_id BIGINT NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    revision_id BIGINT NOT NULL REFERENCES revisions(id) ON DELETE CASCADE,
+    user_id BIGINT NULL REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ NOT NULL,
+    fuel_reserved INT NOT NULL DEFAULT 0,
+    fuel_charged INT NOT NULL DEFAULT 0,
+    outcome VARCHAR(255) NOT NULL,
+    error_code VARCHAR(255) NOT NULL DEFAULT '',
+    result_size INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IDX_EXECUTIONS_CONTRACT_STARTED_AT on executions(contract_id, started_at DESC);
@@ -0,0 +1,17 @@
+CREATE TABLE fuel_ledger
+(
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(255) NOT NULL,
+    operation VARCHAR(255) NOT NULL DEFAULT '',
+    user_id BIGINT NULL,
+    contract_id BIGINT NULL,
+    revision_id BIGINT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- no foreign keys, the ledger must survive the deletion of users and contracts for billing.
+
+CREATE INDEX IDX_FUEL_LEDGER_CREATED_AT on fuel_ledger(created_at);
+CREATE INDEX IDX_FUEL_LEDGER_USER_ID on fuel_ledger(user_id, created_at);
+CREATE INDEX IDX_FUEL_LEDGER_CONTRACT_ID on fuel_ledger(contract_id, created_at);
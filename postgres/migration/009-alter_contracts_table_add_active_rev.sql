@@ -0,0 +1,2 @@
+ALTER TABLE contracts ADD active_rev INT NOT NULL DEFAULT 0;
+ALTER TABLE contracts ADD previous_active_rev INT NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+CREATE TABLE contract_aliases
+(
+    id BIGSERIAL PRIMARY KEY,
+    contract_id BIGINT NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    rev INT NOT NULL,
+    split_rev INT NOT NULL DEFAULT 0,
+    split_weight INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(contract_id, name)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE revisions ADD code_hash VARCHAR(64) NOT NULL DEFAULT '';
+UPDATE revisions SET code_hash = encode(sha256(compiled_code), 'hex');
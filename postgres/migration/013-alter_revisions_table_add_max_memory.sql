@@ -0,0 +1 @@
+ALTER TABLE revisions ADD max_memory BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE executions ADD seed BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE executions ADD frozen_at TIMESTAMPTZ NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE execution_snapshots
+(
+    execution_id BIGINT PRIMARY KEY REFERENCES executions(id) ON DELETE CASCADE,
+    input JSONB NOT NULL,
+    state_before JSONB NOT NULL,
+    result JSONB NOT NULL,
+    state_after JSONB NOT NULL
+);
@@ -4,7 +4,10 @@ import "strings"
 
 func DeleteContractQuery() string {
 	return `
-		DELETE FROM contracts WHERE id = $1
+		UPDATE contracts SET
+			deleted_at = $1,
+			updated_at = $1
+		WHERE id = $2
 	`
 }
 
@@ -32,13 +35,17 @@ func InsertRevisionQuery() string {
 			contract_id,
 			notes,
 			compiled_code,
+			code_hash,
 			max_fuel,
+			max_memory,
 			created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id
 	`
 }
 
-func SelectContractsQuery(whereCondtions []string, limit, offset int) string {
+// SelectContractsQuery returns the contracts query sorted by the given order.
+// orderBy must never contain user input.
+func SelectContractsQuery(whereCondtions []string, orderBy string, limit, offset int) string {
 	return `		
 		SELECT
 			id,
@@ -48,15 +55,51 @@ func SelectContractsQuery(whereCondtions []string, limit, offset int) string {
 			visibility,
 			max_fuel,
 			stateful,
+			active_rev,
+			previous_active_rev,
 			created_at,
 			updated_at,
 			COUNT(*) OVER() as count
 		FROM contracts
 		WHERE ` + strings.Join(whereCondtions, " AND ") + `
-		ORDER BY id ASC
+		ORDER BY ` + orderBy + `
 		` + FormatLimitOffset(limit, offset)
 }
 
+func PurgeContractsQuery() string {
+	return `
+		DELETE FROM contracts WHERE deleted_at < $1
+	`
+}
+
+func RestoreContractQuery() string {
+	return `
+		UPDATE contracts SET
+			deleted_at = NULL,
+			updated_at = $1
+		WHERE id = $2
+	`
+}
+
+// LockContractQuery locks the contract row until the end of the transaction, without changing it.
+func LockContractQuery() string {
+	return `
+		SELECT id FROM contracts
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+}
+
+func UpdateActiveRevisionQuery() string {
+	return `
+		UPDATE contracts SET
+			active_rev = $1,
+			previous_active_rev = $2,
+			updated_at = $3
+		WHERE id = $4
+	`
+}
+
 func SelectRevisionsQuery(whereCondtions []string, limit, offset int) string {
 	return `
 		SELECT
@@ -66,7 +109,9 @@ func SelectRevisionsQuery(whereCondtions []string, limit, offset int) string {
 			contract_id,
 			notes,
 			compiled_code,
+			code_hash,
 			max_fuel,
+			max_memory,
 			created_at,
 			COUNT(*) OVER() as count
 		FROM revisions
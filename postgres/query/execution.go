@@ -0,0 +1,80 @@
+package query
+
+import "strings"
+
+func InsertExecutionQuery() string {
+	return `
+		INSERT INTO executions (
+			contract_id,
+			revision_id,
+			rev,
+			alias,
+			user_id,
+			started_at,
+			ended_at,
+			fuel_reserved,
+			fuel_charged,
+			outcome,
+			error_code,
+			result_size,
+			seed,
+			frozen_at
+		) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14 ) RETURNING id
+	`
+}
+
+func SelectExecutionsQuery(whereConditions []string, limit, offset int) string {
+	return `
+		SELECT
+			id,
+			contract_id,
+			revision_id,
+			rev,
+			alias,
+			user_id,
+			started_at,
+			ended_at,
+			fuel_reserved,
+			fuel_charged,
+			outcome,
+			error_code,
+			result_size,
+			seed,
+			frozen_at,
+			COUNT(*) OVER()
+		FROM executions
+		WHERE ` + strings.Join(whereConditions, " AND ") + `
+		ORDER BY started_at DESC, id DESC
+		` + FormatLimitOffset(limit, offset)
+}
+
+func InsertExecutionSnapshotQuery() string {
+	return `
+		INSERT INTO execution_snapshots (
+			execution_id,
+			input,
+			state_before,
+			result,
+			state_after
+		) VALUES ( $1, $2, $3, $4, $5 )
+	`
+}
+
+func PurgeExecutionSnapshotsQuery() string {
+	return `
+		DELETE FROM execution_snapshots
+		WHERE execution_id IN (SELECT id FROM executions WHERE started_at < $1)
+	`
+}
+
+func SelectExecutionSnapshotQuery() string {
+	return `
+		SELECT
+			input,
+			state_before,
+			result,
+			state_after
+		FROM execution_snapshots
+		WHERE execution_id = $1
+	`
+}
@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+const (
+	ContractLogsKeyTemplate = "contract-%d-logs"
+)
+
+// ContractLogsMaxEntries is the maximum number of log entries kept for each contract.
+var ContractLogsMaxEntries = 500
+
+// ContractLogsRetention is the period after the last write before the logs of a contract expire.
+var ContractLogsRetention = 24 * time.Hour
+
+var _ service.ContractLogService = (*ContractLogService)(nil)
+
+// ContractLogService implements the ContractLogService for Redis.
+// Logs are stored in a capped list for each contract.
+type ContractLogService struct {
+	db *DB
+}
+
+// NewContractLogService creates a new ContractLogService.
+func NewContractLogService(db *DB) *ContractLogService {
+	return &ContractLogService{db: db}
+}
+
+// AppendLogs stores the given logs for the contract.
+func (s *ContractLogService) AppendLogs(ctx context.Context, contractID int64, logs entity.ContractLogs) error {
+	return appendLogs(ctx, s.db, contractID, logs)
+}
+
+// FindLogsByContractID returns the most recent logs of the contract, oldest first.
+func (s *ContractLogService) FindLogsByContractID(ctx context.Context, contractID int64) (entity.ContractLogs, error) {
+	return findLogsByContractID(ctx, s.db, contractID)
+}
+
+// appendLogs pushes the logs at the tail of the contract list, then trims the list to the max entries.
+func appendLogs(ctx context.Context, db *DB, contractID int64, logs entity.ContractLogs) error {
+
+	if contractID == 0 {
+		return apperr.Errorf(apperr.EINVALID, "contractID is 0")
+	}
+
+	if len(logs) == 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf(ContractLogsKeyTemplate, contractID)
+
+	values := make([]interface{}, 0, len(logs))
+	for _, l := range logs {
+		rawVal, err := json.Marshal(l)
+		if err != nil {
+			return apperr.Errorf(apperr.EINTERNAL, "failed to marshal contract log: %v", err)
+		}
+		values = append(values, string(rawVal))
+	}
+
+	pipe := db.client.TxPipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.LTrim(ctx, key, int64(-ContractLogsMaxEntries), -1)
+	pipe.Expire(ctx, key, ContractLogsRetention)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to append contract logs to redis: %v", err)
+	}
+
+	return nil
+}
+
+// findLogsByContractID returns the logs stored for the contract.
+// If no logs are found, an empty list is returned.
+func findLogsByContractID(ctx context.Context, db *DB, contractID int64) (entity.ContractLogs, error) {
+
+	if contractID == 0 {
+		return nil, apperr.Errorf(apperr.EINVALID, "contractID is 0")
+	}
+
+	key := fmt.Sprintf(ContractLogsKeyTemplate, contractID)
+
+	rawVals, err := db.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to get contract logs from redis: %v", err)
+	}
+
+	logs := make(entity.ContractLogs, 0, len(rawVals))
+
+	for _, rawVal := range rawVals {
+		var l entity.ContractLog
+		if err := json.Unmarshal([]byte(rawVal), &l); err != nil {
+			return nil, apperr.Errorf(apperr.EINTERNAL, "failed to unmarshal contract log: %v", err)
+		}
+		logs = append(logs, &l)
+	}
+
+	return logs, nil
+}
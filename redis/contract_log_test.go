@@ -0,0 +1,108 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/redis"
+)
+
+func TestContractLog_AppendLogs(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		logService := redis.NewContractLogService(db)
+
+		if err := logService.AppendLogs(ctx, 1, entity.ContractLogs{
+			{ContractID: 1, Level: entity.LogLevelInfo, Message: "first"},
+			{ContractID: 1, Level: entity.LogLevelError, Message: "second"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		logs, err := logService.FindLogsByContractID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(logs) != 2 {
+			t.Fatalf("got %d logs, want %d", len(logs), 2)
+		} else if logs[0].Message != "first" || logs[1].Message != "second" {
+			t.Errorf("unexpected logs order: %s, %s", logs[0].Message, logs[1].Message)
+		}
+	})
+
+	t.Run("Capped", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		logService := redis.NewContractLogService(db)
+
+		logs := make(entity.ContractLogs, 0)
+		for i := 0; i < redis.ContractLogsMaxEntries+10; i++ {
+			logs = append(logs, &entity.ContractLog{ContractID: 1, Level: entity.LogLevelInfo, Message: "log"})
+		}
+
+		if err := logService.AppendLogs(ctx, 1, logs); err != nil {
+			t.Fatal(err)
+		}
+
+		if logs, err := logService.FindLogsByContractID(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if len(logs) != redis.ContractLogsMaxEntries {
+			t.Errorf("got %d logs, want %d", len(logs), redis.ContractLogsMaxEntries)
+		}
+	})
+
+	t.Run("InvalidContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		logService := redis.NewContractLogService(db)
+
+		if err := logService.AppendLogs(context.Background(), 0, entity.ContractLogs{{Message: "log"}}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestContractLog_FindLogsByContractID(t *testing.T) {
+
+	t.Run("Empty", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		logService := redis.NewContractLogService(db)
+
+		if logs, err := logService.FindLogsByContractID(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if len(logs) != 0 {
+			t.Errorf("got %d logs, want 0", len(logs))
+		}
+	})
+}
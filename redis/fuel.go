@@ -42,7 +42,8 @@ func (ft *FuelTankService) Fuel(ctx context.Context) (entity.Fuel, error) {
 }
 
 // Refuel refills the fuel tank by the specified amount.
-func (ft *FuelTankService) Refuel(ctx context.Context, fuelToRefill entity.Fuel) error {
+// It returns the fuel actually refilled.
+func (ft *FuelTankService) Refuel(ctx context.Context, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 	return refuel(ctx, ft.db, fuelToRefill)
 }
 
@@ -51,27 +52,50 @@ func (ft *FuelTankService) Stats(ctx context.Context) (*entity.FuelStat, error)
 	return stats(ctx, ft)
 }
 
+// burnScript checks the capacity and burns the fuel in a single atomic step.
+// KEYS[1] is the fuel used, ARGV[1] the fuel to burn and ARGV[2] the fuel tank capacity.
+// It returns -1 if the fuel used already exceeds the capacity, otherwise the new fuel used.
+var burnScript = redis.NewScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+if used > tonumber(ARGV[2]) then
+	return -1
+end
+return redis.call('INCRBY', KEYS[1], ARGV[1])
+`)
+
+// refuelScript clamps the refill to the fuel used and refuels the tank in a single atomic step.
+// KEYS are the fuel used, the last refill amount and the last refill time, ARGV[1] the fuel to refill and ARGV[2] the refill unix time.
+// It returns the fuel actually refilled.
+var refuelScript = redis.NewScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local refill = tonumber(ARGV[1])
+if refill > used then
+	refill = used
+end
+redis.call('SET', KEYS[1], used - refill)
+redis.call('SET', KEYS[2], refill)
+redis.call('SET', KEYS[3], ARGV[2])
+return refill
+`)
+
 // burn consumes the specified amount of fuel.
-// It is not thread-safe, use it with some lock service.
+// It is atomic, the capacity check and the burn are executed by a single lua script.
+// Return ErrFuelTankNotEnough if the fuel used is already greater than the fuel tank capacity.
 func burn(ctx context.Context, db *DB, fuelUsed entity.Fuel) error {
 
-	currentFuelUsed, err := fuel(ctx, db)
+	res, err := burnScript.Run(ctx, db.client, []string{FuelUsedRedisKey}, uint64(fuelUsed), uint64(entity.FuelTankCapacity)).Int64()
 	if err != nil {
-		return err
+		return apperr.Errorf(apperr.EINTERNAL, "failed to burn fuel in redis: %v", err)
 	}
 
-	// add the fuel used to the current fuel used
-	newFuelUsed := currentFuelUsed + fuelUsed
-
-	if err := db.client.Set(ctx, FuelUsedRedisKey, newFuelUsed, 0).Err(); err != nil {
-		return err
+	if res < 0 {
+		return service.ErrFuelTankNotEnough
 	}
 
 	return nil
 }
 
 // fuel returns the current amount of fuel used.
-// It is not thread-safe, use it with some lock service.
 func fuel(ctx context.Context, db *DB) (entity.Fuel, error) {
 
 	rawVal, err := db.client.Get(ctx, FuelUsedRedisKey).Result()
@@ -91,38 +115,21 @@ func fuel(ctx context.Context, db *DB) (entity.Fuel, error) {
 }
 
 // refuel refills the fuel tank by the specified amount.
-// It is not thread-safe, use it with some lock service.
-func refuel(ctx context.Context, db *DB, fuelToRefill entity.Fuel) error {
-
-	currentFuelUsed, err := fuel(ctx, db)
-	if err != nil {
-		return err
-	}
-
-	// check if the fuel to refill is greater than the current fuel used, if so, set refuel to current fuel used
-	if fuelToRefill > currentFuelUsed {
-		fuelToRefill = currentFuelUsed
-	}
-
-	// remove the fuel refilled from the current fuel used
+// It is atomic, if the passed fuel to refill is greater than actual fuel used, the fuel used is set to 0.
+// It returns the fuel actually refilled.
+func refuel(ctx context.Context, db *DB, fuelToRefill entity.Fuel) (entity.Fuel, error) {
 
-	newFuelUsed := currentFuelUsed - fuelToRefill
+	keys := []string{FuelUsedRedisKey, FuelLastRefillAmount, FuelLastRefillTime}
 
-	if err := db.client.Set(ctx, FuelUsedRedisKey, newFuelUsed, 0).Err(); err != nil {
-		return apperr.Errorf(apperr.EINTERNAL, "failed to refill fuel tank in redis: %v", err)
-	}
-	if err := db.client.Set(ctx, FuelLastRefillAmount, fuelToRefill, 0).Err(); err != nil {
-		return apperr.Errorf(apperr.EINTERNAL, "failed to set last fuel refill amount in redis: %v", err)
-	}
-	if err := db.client.Set(ctx, FuelLastRefillTime, time.Now().Unix(), 0).Err(); err != nil {
-		return apperr.Errorf(apperr.EINTERNAL, "failed to set last fuel refill time in redis: %v", err)
+	refilled, err := refuelScript.Run(ctx, db.client, keys, uint64(fuelToRefill), time.Now().Unix()).Int64()
+	if err != nil {
+		return 0, apperr.Errorf(apperr.EINTERNAL, "failed to refill fuel tank in redis: %v", err)
 	}
 
-	return nil
+	return entity.Fuel(refilled), nil
 }
 
 // stats returns the current fuel tank stats.
-// It is not thread-safe, use it with some lock service.
 func stats(ctx context.Context, ft *FuelTankService) (*entity.FuelStat, error) {
 
 	var lastRefillAmount entity.Fuel
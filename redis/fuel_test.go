@@ -6,6 +6,7 @@ import (
 
 	"github.com/music-gang/music-gang-api/app/apperr"
 	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/mgvm"
 	"github.com/music-gang/music-gang-api/redis"
 )
 
@@ -73,6 +74,37 @@ func TestFuel_Burn(t *testing.T) {
 		}
 	})
 
+	t.Run("ErrNotEnough", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		fuelTankService := redis.NewFuelTankService(db)
+
+		// it's legal to exceed the capacity with a single burn, the next one must fail.
+		if err := fuelTankService.Burn(ctx, entity.FuelTankCapacity+1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fuelTankService.Burn(ctx, entity.Fuel(100)); err == nil {
+			t.Fatal("got nil, want error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_LOWFUEL {
+			t.Errorf("got %v, want %v", errCode, apperr.EMGVM_LOWFUEL)
+		}
+
+		if fuel, err := fuelTankService.Fuel(ctx); err != nil {
+			t.Fatal(err)
+		} else if fuel != entity.FuelTankCapacity+1 {
+			t.Errorf("got %d, want %d", fuel, entity.FuelTankCapacity+1)
+		}
+	})
+
 	t.Run("ContextCancel", func(t *testing.T) {
 
 		db := MustOpenDB(t)
@@ -213,8 +245,10 @@ func TestFuel_Refuel(t *testing.T) {
 			t.Errorf("got %d, want %d", fuel, entity.Fuel(100))
 		}
 
-		if err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err != nil {
+		if refilled, err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err != nil {
 			t.Fatal(err)
+		} else if refilled != entity.Fuel(50) {
+			t.Errorf("got %d, want %d", refilled, entity.Fuel(50))
 		}
 
 		fuel, err = fuelTankService.Fuel(ctx)
@@ -226,8 +260,10 @@ func TestFuel_Refuel(t *testing.T) {
 			t.Errorf("got %d, want %d", fuel, entity.Fuel(50))
 		}
 
-		if err := fuelTankService.Refuel(ctx, entity.Fuel(100)); err != nil {
+		if refilled, err := fuelTankService.Refuel(ctx, entity.Fuel(100)); err != nil {
 			t.Fatal(err)
+		} else if refilled != entity.Fuel(50) {
+			t.Errorf("got %d, want %d", refilled, entity.Fuel(50))
 		}
 
 		fuel, err = fuelTankService.Fuel(ctx)
@@ -277,7 +313,7 @@ func TestFuel_Refuel(t *testing.T) {
 
 		cancel()
 
-		if err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err == nil {
+		if _, err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err == nil {
 			t.Fatal("got nil, want error")
 		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINTERNAL {
 			t.Errorf("got %v, want %v", errCode, apperr.EINTERNAL)
@@ -304,7 +340,7 @@ func TestFuel_Stats(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err != nil {
+		if _, err := fuelTankService.Refuel(ctx, entity.Fuel(50)); err != nil {
 			t.Fatal(err)
 		}
 
@@ -371,3 +407,55 @@ func TestFuel_Stats(t *testing.T) {
 		}
 	})
 }
+
+// BenchmarkFuelTank_BurnRefuel compares the lua scripts with the previous path,
+// where every burn and refuel was serialized by the fuel-tank-lock distributed mutex.
+func BenchmarkFuelTank_BurnRefuel(b *testing.B) {
+
+	bench := func(b *testing.B, fuelTank *mgvm.FuelTank) {
+
+		ctx := context.Background()
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			if err := fuelTank.Burn(ctx, entity.FuelInstantActionAmount); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := fuelTank.Refuel(ctx, entity.FuelInstantActionAmount); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("Lock", func(b *testing.B) {
+
+		db := MustOpenDB(b)
+		defer db.Close()
+
+		if err := db.FlushAll(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+
+		fuelTank := mgvm.NewFuelTank()
+		fuelTank.FuelTankService = redis.NewFuelTankService(db)
+		fuelTank.LockService = redis.NewLockService(db, "fuel-tank-lock")
+
+		bench(b, fuelTank)
+	})
+
+	b.Run("Lua", func(b *testing.B) {
+
+		db := MustOpenDB(b)
+		defer db.Close()
+
+		if err := db.FlushAll(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+
+		fuelTank := mgvm.NewFuelTank()
+		fuelTank.FuelTankService = redis.NewFuelTankService(db)
+
+		bench(b, fuelTank)
+	})
+}
@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+const (
+	FuelWalletKeyTemplate = "mgvm_fuel_wallet_%d"
+
+	fuelWalletUsedField         = "fuel_used"
+	fuelWalletLastRefuelAtField = "last_refuel_at"
+)
+
+// FuelWalletMaxRetries is the number of attempts to update a wallet modified concurrently by another instance.
+var FuelWalletMaxRetries = 10
+
+var _ service.FuelWalletService = (*FuelWalletService)(nil)
+
+// FuelWalletService implements the FuelWalletService interface.
+// Every wallet is stored in a hash and updated with an optimistic transaction, so no lock service is required.
+type FuelWalletService struct {
+	db *DB
+}
+
+// NewFuelWalletService creates a new FuelWalletService.
+func NewFuelWalletService(db *DB) *FuelWalletService {
+	return &FuelWalletService{db: db}
+}
+
+// Burn consumes the specified amount of fuel from the user wallet.
+func (s *FuelWalletService) Burn(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	_, err := updateFuelWallet(ctx, s.db, userID, func(w *entity.FuelWallet) error {
+		return w.Burn(fuel)
+	})
+	return err
+}
+
+// FuelWallet returns the fuel wallet of the given user.
+func (s *FuelWalletService) FuelWallet(ctx context.Context, userID int64) (*entity.FuelWallet, error) {
+	return updateFuelWallet(ctx, s.db, userID, func(w *entity.FuelWallet) error {
+		return nil
+	})
+}
+
+// Refuel gives back the specified amount of fuel to the user wallet.
+func (s *FuelWalletService) Refuel(ctx context.Context, userID int64, fuel entity.Fuel) error {
+	_, err := updateFuelWallet(ctx, s.db, userID, func(w *entity.FuelWallet) error {
+		w.Refuel(fuel)
+		return nil
+	})
+	return err
+}
+
+// updateFuelWallet loads the wallet, applies the elapsed refills and the given update, then stores it.
+// The update is retried if the wallet is modified concurrently.
+func updateFuelWallet(ctx context.Context, db *DB, userID int64, fn func(w *entity.FuelWallet) error) (*entity.FuelWallet, error) {
+
+	if userID == 0 {
+		return nil, apperr.Errorf(apperr.EINVALID, "userID is 0")
+	}
+
+	key := fmt.Sprintf(FuelWalletKeyTemplate, userID)
+
+	var wallet *entity.FuelWallet
+
+	txf := func(tx *redis.Tx) error {
+
+		w, err := findFuelWallet(ctx, tx, key, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(w); err != nil {
+			return err
+		}
+
+		if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key,
+				fuelWalletUsedField, uint64(w.FuelUsed),
+				fuelWalletLastRefuelAtField, w.LastRefuelAt.UnixNano(),
+			)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		wallet = w
+
+		return nil
+	}
+
+	for i := 0; i < FuelWalletMaxRetries; i++ {
+		err := db.client.Watch(ctx, txf, key)
+		if err == nil {
+			return wallet, nil
+		} else if err == redis.TxFailedErr {
+			continue
+		}
+
+		var appErr *apperr.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to update fuel wallet in redis: %v", err)
+	}
+
+	return nil, apperr.Errorf(apperr.EINTERNAL, "failed to update fuel wallet in redis: too many concurrent updates")
+}
+
+// findFuelWallet reads the wallet of the user and applies the elapsed refills.
+// If the wallet does not exist yet, an empty one is returned.
+func findFuelWallet(ctx context.Context, tx *redis.Tx, key string, userID int64) (*entity.FuelWallet, error) {
+
+	now := time.Now().UTC()
+
+	rawVals, err := tx.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to get fuel wallet from redis: %v", err)
+	}
+
+	wallet := entity.NewFuelWallet(userID, now)
+
+	if len(rawVals) == 0 {
+		return wallet, nil
+	}
+
+	fuelUsed, err := strconv.ParseUint(rawVals[fuelWalletUsedField], 10, 64)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to parse fuel wallet used from redis: %v", err)
+	}
+
+	lastRefuelAt, err := strconv.ParseInt(rawVals[fuelWalletLastRefuelAtField], 10, 64)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to parse fuel wallet last refuel from redis: %v", err)
+	}
+
+	wallet.FuelUsed = entity.Fuel(fuelUsed)
+	wallet.LastRefuelAt = time.Unix(0, lastRefuelAt).UTC()
+
+	wallet.Refill(now)
+
+	return wallet, nil
+}
@@ -0,0 +1,148 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/redis"
+)
+
+func TestFuelWallet_Burn(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		fuelWalletService := redis.NewFuelWalletService(db)
+
+		if err := fuelWalletService.Burn(ctx, 1, entity.Fuel(100)); err != nil {
+			t.Fatal(err)
+		}
+
+		wallet, err := fuelWalletService.FuelWallet(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet.FuelUsed != entity.Fuel(100) {
+			t.Errorf("got %d, want %d", wallet.FuelUsed, entity.Fuel(100))
+		}
+
+		if wallet.FuelCapacity != entity.UserFuelCapacity {
+			t.Errorf("got %d, want %d", wallet.FuelCapacity, entity.UserFuelCapacity)
+		}
+
+		if other, err := fuelWalletService.FuelWallet(ctx, 2); err != nil {
+			t.Fatal(err)
+		} else if other.FuelUsed != 0 {
+			t.Errorf("expected wallets to be isolated, got %d", other.FuelUsed)
+		}
+	})
+
+	t.Run("ErrNotEnough", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		fuelWalletService := redis.NewFuelWalletService(db)
+
+		if err := fuelWalletService.Burn(ctx, 1, entity.UserFuelCapacity+1); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EMGVM_USER_LOWFUEL {
+			t.Errorf("got %s, want %s", errCode, apperr.EMGVM_USER_LOWFUEL)
+		}
+	})
+
+	t.Run("ErrInvalidUser", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		fuelWalletService := redis.NewFuelWalletService(db)
+
+		if err := fuelWalletService.Burn(context.Background(), 0, entity.Fuel(100)); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("got %s, want %s", errCode, apperr.EINVALID)
+		}
+	})
+}
+
+func TestFuelWallet_Refuel(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		fuelWalletService := redis.NewFuelWalletService(db)
+
+		if err := fuelWalletService.Burn(ctx, 1, entity.Fuel(100)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := fuelWalletService.Refuel(ctx, 1, entity.Fuel(150)); err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet, err := fuelWalletService.FuelWallet(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if wallet.FuelUsed != 0 {
+			t.Errorf("got %d, want 0", wallet.FuelUsed)
+		}
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		defaultRate := entity.UserFuelRefillRate
+		entity.UserFuelRefillRate = 100 * time.Millisecond
+		defer func() {
+			entity.UserFuelRefillRate = defaultRate
+		}()
+
+		fuelWalletService := redis.NewFuelWalletService(db)
+
+		if err := fuelWalletService.Burn(ctx, 1, entity.UserFuelCapacity); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(150 * time.Millisecond)
+
+		if wallet, err := fuelWalletService.FuelWallet(ctx, 1); err != nil {
+			t.Fatal(err)
+		} else if wallet.FuelUsed != entity.UserFuelCapacity-entity.UserFuelRefillAmount {
+			t.Errorf("got %d, want %d", wallet.FuelUsed, entity.UserFuelCapacity-entity.UserFuelRefillAmount)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/service"
+)
+
+var _ service.LeaderService = (*LeaderService)(nil)
+
+// LeaderKeyTemplate is the key used to store the current leader of an election.
+const LeaderKeyTemplate = "mgvm_leader_%s"
+
+// electionScript acquires the leadership if nobody holds it, or renews it if the caller already holds it.
+// KEYS[1] is the election key, ARGV[1] the candidate id and ARGV[2] the lease in milliseconds.
+// It returns 1 if the candidate is the leader, otherwise 0.
+var electionScript = redis.NewScript(`
+local leader = redis.call('GET', KEYS[1])
+if not leader then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+if leader == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// LeaderService implements a leader election based on a lease stored in redis.
+// The leader must call IsLeader more often than the lease, otherwise another instance takes over.
+type LeaderService struct {
+	db    *DB
+	name  string
+	id    string
+	lease time.Duration
+}
+
+// NewLeaderService creates a new LeaderService for the given election.
+// Every LeaderService is a different candidate, even if created by the same instance.
+func NewLeaderService(db *DB, name string, lease time.Duration) *LeaderService {
+	return &LeaderService{
+		db:    db,
+		name:  name,
+		id:    uuid.NewString(),
+		lease: lease,
+	}
+}
+
+// IsLeader acquires or renews the leadership and reports if the current candidate is the leader.
+func (l *LeaderService) IsLeader(ctx context.Context) (bool, error) {
+
+	res, err := electionScript.Run(ctx, l.db.client, []string{fmt.Sprintf(LeaderKeyTemplate, l.name)}, l.id, l.lease.Milliseconds()).Int()
+	if err != nil {
+		return false, apperr.Errorf(apperr.EINTERNAL, "failed to run leader election: %v", err)
+	}
+
+	return res == 1, nil
+}
+
+// Name returns the name of the election.
+func (l *LeaderService) Name() string {
+	return l.name
+}
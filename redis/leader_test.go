@@ -0,0 +1,81 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/redis"
+)
+
+func TestLeaderService_IsLeader(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		first := redis.NewLeaderService(db, "test", time.Second)
+		second := redis.NewLeaderService(db, "test", time.Second)
+
+		if isLeader, err := first.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if !isLeader {
+			t.Error("expected first candidate to be the leader")
+		}
+
+		if isLeader, err := second.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if isLeader {
+			t.Error("expected second candidate not to be the leader")
+		}
+
+		// the leader renews its lease
+		if isLeader, err := first.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if !isLeader {
+			t.Error("expected first candidate to keep the leadership")
+		}
+	})
+
+	t.Run("LeaseExpired", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		ctx := context.Background()
+
+		if err := db.FlushAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		first := redis.NewLeaderService(db, "test", 100*time.Millisecond)
+		second := redis.NewLeaderService(db, "test", 100*time.Millisecond)
+
+		if isLeader, err := first.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if !isLeader {
+			t.Error("expected first candidate to be the leader")
+		}
+
+		time.Sleep(150 * time.Millisecond)
+
+		if isLeader, err := second.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if !isLeader {
+			t.Error("expected second candidate to take over")
+		}
+
+		if isLeader, err := first.IsLeader(ctx); err != nil {
+			t.Fatal(err)
+		} else if isLeader {
+			t.Error("expected first candidate to lose the leadership")
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/music-gang/music-gang-api/app"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/sqlite/query"
+)
+
+var _ service.ContractAliasService = (*ContractAliasService)(nil)
+
+// ContractAliasService is the sqlite implementation of the contract alias service.
+type ContractAliasService struct {
+	db *DB
+}
+
+// NewContractAliasService creates a new contract alias service.
+func NewContractAliasService(db *DB) *ContractAliasService {
+	return &ContractAliasService{db: db}
+}
+
+// DeleteContractAlias deletes the alias of the contract with the given name.
+// Return ENOTFOUND if the alias does not exist.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func (s *ContractAliasService) DeleteContractAlias(ctx context.Context, contractID int64, name string) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteContractAlias(ctx, tx, contractID, name); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// FindContractAliasByName returns the alias of the contract with the given name.
+// Return ENOTFOUND if the alias does not exist.
+func (s *ContractAliasService) FindContractAliasByName(ctx context.Context, contractID int64, name string) (*entity.ContractAlias, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findContractAliasByName(ctx, tx, contractID, name)
+}
+
+// FindContractAliases returns all the aliases of the contract, sorted by name.
+func (s *ContractAliasService) FindContractAliases(ctx context.Context, contractID int64) (entity.ContractAliases, error) {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findContractAliases(ctx, tx, contractID, nil)
+}
+
+// SetContractAlias creates the alias or moves it to the given revisions if it already exists.
+// Return EINVALID if the alias is invalid.
+// Return ENOTFOUND if the contract or one of the revisions does not exist.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func (s *ContractAliasService) SetContractAlias(ctx context.Context, alias *entity.ContractAlias) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setContractAlias(ctx, tx, alias); err != nil {
+		return err
+	} else if err := tx.Commit(); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// checkContractOwner checks that the contract exists and it is owned by the authenticated user.
+// Return ENOTFOUND if the contract does not exist.
+// Return EUNAUTHORIZED if the contract is not owned by the authenticated user.
+func checkContractOwner(ctx context.Context, tx *Tx, contractID int64) error {
+	if contract, err := findContractByID(ctx, tx, contractID); err != nil {
+		return err
+	} else if contract.UserID != app.UserIDFromContext(ctx) {
+		return apperr.Errorf(apperr.EUNAUTHORIZED, "contract is not owned by the authenticated user")
+	}
+	return nil
+}
+
+// deleteContractAlias deletes the alias of the contract with the given name.
+func deleteContractAlias(ctx context.Context, tx *Tx, contractID int64, name string) error {
+
+	if err := checkContractOwner(ctx, tx, contractID); err != nil {
+		return err
+	} else if _, err := findContractAliasByName(ctx, tx, contractID, name); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, query.DeleteContractAliasQuery(), contractID, name); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to delete contract alias: %v", err)
+	}
+
+	return nil
+}
+
+// findContractAliasByName returns the alias of the contract with the given name.
+// Return ENOTFOUND if the alias does not exist.
+func findContractAliasByName(ctx context.Context, tx *Tx, contractID int64, name string) (*entity.ContractAlias, error) {
+
+	aliases, err := findContractAliases(ctx, tx, contractID, &name)
+	if err != nil {
+		return nil, err
+	} else if len(aliases) == 0 {
+		return nil, apperr.Errorf(apperr.ENOTFOUND, "contract alias not found")
+	}
+
+	return aliases[0], nil
+}
+
+// findContractAliases returns the aliases of the contract, filtered by name if it is not nil.
+func findContractAliases(ctx context.Context, tx *Tx, contractID int64, name *string) (_ entity.ContractAliases, err error) {
+
+	where, args := []string{"contract_id = $1"}, []interface{}{contractID}
+
+	if name != nil {
+		where = append(where, fmt.Sprintf("name = $%d", len(args)+1))
+		args = append(args, *name)
+	}
+
+	rows, err := tx.QueryContext(ctx, query.SelectContractAliasesQuery(where), args...)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to query contract aliases: %v", err)
+	}
+	defer rows.Close()
+
+	aliases := make(entity.ContractAliases, 0)
+
+	for rows.Next() {
+
+		var alias entity.ContractAlias
+
+		if err := rows.Scan(
+			&alias.ID,
+			&alias.ContractID,
+			&alias.Name,
+			&alias.Rev,
+			&alias.SplitRev,
+			&alias.SplitWeight,
+			&alias.CreatedAt,
+			&alias.UpdatedAt,
+		); err != nil {
+			return nil, apperr.Errorf(apperr.EINTERNAL, "failed to scan contract alias: %v", err)
+		}
+
+		aliases = append(aliases, &alias)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to iterate over contract aliases: %v", err)
+	}
+
+	return aliases, nil
+}
+
+// setContractAlias validates the alias, checks that its revisions exist and stores it.
+func setContractAlias(ctx context.Context, tx *Tx, alias *entity.ContractAlias) error {
+
+	alias.CreatedAt = tx.now
+	alias.UpdatedAt = alias.CreatedAt
+
+	if err := alias.Validate(); err != nil {
+		return err
+	} else if err := checkContractOwner(ctx, tx, alias.ContractID); err != nil {
+		return err
+	}
+
+	for _, rev := range []entity.RevisionNumber{alias.Rev, alias.SplitRev} {
+		if rev == 0 {
+			continue
+		}
+		if _, err := findRevisionByContractAndRev(ctx, tx, alias.ContractID, rev); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.QueryRowContext(ctx, query.UpsertContractAliasQuery(),
+		alias.ContractID,
+		alias.Name,
+		alias.Rev,
+		alias.SplitRev,
+		alias.SplitWeight,
+		alias.CreatedAt,
+		alias.UpdatedAt).Scan(&alias.ID, &alias.CreatedAt); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to upsert contract alias: %v", err)
+	}
+
+	return nil
+}
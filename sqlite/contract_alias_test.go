@@ -0,0 +1,184 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/sqlite"
+)
+
+func TestContractAlias_SetContractAlias(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-contract-alias"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		as := sqlite.NewContractAliasService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, userForContract)
+
+		alias := &entity.ContractAlias{ContractID: contractID, Name: "canary", Rev: 1}
+		if err := as.SetContractAlias(ctx, alias); err != nil {
+			t.Fatal(err)
+		} else if alias.ID == 0 {
+			t.Fatal("expected alias id")
+		}
+
+		// the alias is moved, not duplicated.
+		moved := &entity.ContractAlias{ContractID: contractID, Name: "canary", Rev: 1, SplitRev: 2, SplitWeight: 10}
+		if err := as.SetContractAlias(ctx, moved); err != nil {
+			t.Fatal(err)
+		} else if moved.ID != alias.ID {
+			t.Fatalf("expected alias id %d, got %d", alias.ID, moved.ID)
+		}
+
+		if a, err := as.FindContractAliasByName(ctx, contractID, "canary"); err != nil {
+			t.Fatal(err)
+		} else if a.Rev != 1 || a.SplitRev != 2 || a.SplitWeight != 10 {
+			t.Fatalf("unexpected alias %+v", a)
+		}
+	})
+
+	t.Run("RevisionNotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if err := sqlite.NewContractAliasService(db).SetContractAlias(ctx, &entity.ContractAlias{
+			ContractID:  contractID,
+			Name:        "canary",
+			Rev:         1,
+			SplitRev:    2,
+			SplitWeight: 50,
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if err := sqlite.NewContractAliasService(db).SetContractAlias(ctx, &entity.ContractAlias{
+			ContractID: contractID,
+			Name:       "@Canary",
+			Rev:        1,
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+			t.Fatalf("expected %s, got %s", apperr.EINVALID, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, _ := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-alias-2"})
+
+		if err := sqlite.NewContractAliasService(db).SetContractAlias(ctx1, &entity.ContractAlias{
+			ContractID: contractID,
+			Name:       "canary",
+			Rev:        1,
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+}
+
+func TestContractAlias_FindContractAliases(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		as := sqlite.NewContractAliasService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, &entity.User{Name: "test-contract-alias"})
+
+		for _, name := range []string{"stable", "beta", "canary"} {
+			if err := as.SetContractAlias(ctx, &entity.ContractAlias{ContractID: contractID, Name: name, Rev: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if aliases, err := as.FindContractAliases(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if len(aliases) != 3 {
+			t.Fatalf("expected 3 aliases, got %d", len(aliases))
+		} else if aliases[0].Name != "beta" || aliases[1].Name != "canary" || aliases[2].Name != "stable" {
+			t.Fatalf("expected aliases sorted by name, got %s, %s, %s", aliases[0].Name, aliases[1].Name, aliases[2].Name)
+		}
+	})
+}
+
+func TestContractAlias_DeleteContractAlias(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		as := sqlite.NewContractAliasService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, &entity.User{Name: "test-contract-alias"})
+
+		if err := as.SetContractAlias(ctx, &entity.ContractAlias{ContractID: contractID, Name: "canary", Rev: 1}); err != nil {
+			t.Fatal(err)
+		} else if err := as.DeleteContractAlias(ctx, contractID, "canary"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := as.FindContractAliasByName(ctx, contractID, "canary"); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, &entity.User{Name: "test-contract-alias"})
+
+		if err := sqlite.NewContractAliasService(db).DeleteContractAlias(ctx, contractID, "canary"); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+}
@@ -0,0 +1,1907 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/sqlite"
+)
+
+func TestContract_CreateContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+		testContractName := "test-contract"
+		testContractDescription := "test-contract-description"
+
+		contract := &entity.Contract{
+			Name:        testContractName,
+			Description: testContractDescription,
+			UserID:      user.ID,
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		if err := cs.CreateContract(ctx, contract); err != nil {
+			t.Fatal(err)
+		} else if contract.ID == 0 {
+			t.Fatal("contract ID is zero")
+		} else if contract.CreatedAt.IsZero() {
+			t.Fatal("contract created at is zero")
+		} else if contract.UpdatedAt.IsZero() {
+			t.Fatal("contract updated at is zero")
+		} else if contract.User == nil {
+			t.Fatal("contract user is nil")
+		} else if contract.User.ID != user.ID {
+			t.Fatalf("contract user ID is %d, expected %d", contract.User.ID, user.ID)
+		}
+
+		if other, err := cs.FindContractByID(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(contract, other) {
+			t.Fatal("contracts are not equal")
+		}
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+		testContractName := "test-contract"
+		testContractDescription := "test-contract-description"
+
+		contract := &entity.Contract{
+			Name:        testContractName,
+			Description: testContractDescription,
+			UserID:      user.ID,
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+
+		cancel()
+
+		if err := cs.CreateContract(ctx, contract); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("ErrValidate", func(t *testing.T) {
+
+		t.Run("MissingName", func(t *testing.T) {
+			db := MustOpenDB(t)
+			defer MustCloseDB(t, db)
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+			testContractDescription := "test-contract-description"
+
+			contract := &entity.Contract{
+				Description: testContractDescription,
+				UserID:      user.ID,
+				Visibility:  entity.VisibilityPublic,
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			if err := cs.CreateContract(ctx, contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+		})
+
+		t.Run("MissingUserID", func(t *testing.T) {
+			db := MustOpenDB(t)
+			defer MustCloseDB(t, db)
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			testContractName := "test-contract"
+			testContractDescription := "test-contract-description"
+
+			contract := &entity.Contract{
+				Name:        testContractName,
+				Description: testContractDescription,
+				Visibility:  entity.VisibilityPublic,
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			if err := cs.CreateContract(context.Background(), contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+		})
+
+		t.Run("MissingOrWrongVisibility", func(t *testing.T) {
+
+			db := MustOpenDB(t)
+			defer MustCloseDB(t, db)
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+			testContractName := "test-contract"
+			testContractDescription := "test-contract-description"
+
+			contract := &entity.Contract{
+				Name:        testContractName,
+				Description: testContractDescription,
+				UserID:      user.ID,
+				Visibility:  "wrong",
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			if err := cs.CreateContract(ctx, contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+
+			contract = &entity.Contract{
+				Name:        testContractName,
+				Description: testContractDescription,
+				UserID:      user.ID,
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			if err := cs.CreateContract(ctx, contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+		})
+
+		t.Run("InvalidFuel", func(t *testing.T) {
+
+			db := MustOpenDB(t)
+			defer MustCloseDB(t, db)
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+			testContractName := "test-contract"
+			testContractDescription := "test-contract-description"
+
+			contract := &entity.Contract{
+				Name:        testContractName,
+				Description: testContractDescription,
+				UserID:      user.ID,
+				Visibility:  entity.VisibilityPublic,
+			}
+
+			if err := cs.CreateContract(ctx, contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+
+			contract = &entity.Contract{
+				Name:        testContractName,
+				Description: testContractDescription,
+				UserID:      user.ID,
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			if err := cs.CreateContract(ctx, contract); err == nil {
+				t.Fatal("expected error")
+			} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+				t.Fatalf("expected EINVALID, got %s", code)
+			}
+		})
+	})
+
+	t.Run("UserNotAuthenticated", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		user, _ := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+		testContractName := "test-contract"
+		testContractDescription := "test-contract-description"
+
+		contract := &entity.Contract{
+			Name:        testContractName,
+			Description: testContractDescription,
+			UserID:      user.ID,
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		if err := cs.CreateContract(context.Background(), contract); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+
+	t.Run("UserNotContractOwner", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		user0, _ := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-2"})
+
+		testContractName := "test-contract"
+		testContractDescription := "test-contract-description"
+
+		contract := &entity.Contract{
+			Name:        testContractName,
+			Description: testContractDescription,
+			UserID:      user0.ID,
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		if err := cs.CreateContract(ctx1, contract); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+
+	t.Run("UserDeletedBeforeCreateContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		user, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract"})
+
+		testContractName := "test-contract"
+		testContractDescription := "test-contract-description"
+
+		contract := &entity.Contract{
+			Name:        testContractName,
+			Description: testContractDescription,
+			UserID:      user.ID,
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		if err := sqlite.NewUserService(db).DeleteUser(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		} else if err := cs.CreateContract(ctx, contract); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EINTERNAL {
+			t.Fatalf("expected %s, got %s", apperr.EINTERNAL, code)
+		}
+	})
+}
+
+func TestContract_DeleteContract(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-contract"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		cs := sqlite.NewContractService(db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, userForContract)
+
+		if err := cs.DeleteContract(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := cs.FindContractByID(ctx, contract.ID); err == nil {
+			t.Fatalf("expected error, got %v", err)
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		cs := sqlite.NewContractService(db)
+
+		if err := cs.DeleteContract(context.Background(), contract.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		cs := sqlite.NewContractService(db)
+
+		contract0, _ := MustCreateContract(t, context.Background(), db, contract, userForContract)
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-2"})
+
+		if err := cs.DeleteContract(ctx1, contract0.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+
+	t.Run("UserDeletedBeforeDeleteContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		cs := sqlite.NewContractService(db)
+
+		contract0, ctx := MustCreateContract(t, context.Background(), db, contract, userForContract)
+
+		if err := sqlite.NewUserService(db).DeleteUser(ctx, contract.UserID); err != nil {
+			t.Fatal(err)
+		} else if err := cs.DeleteContract(ctx, contract0.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+}
+
+func TestContract_RestoreContract(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-contract"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test-contract",
+				Visibility: entity.VisibilityPublic,
+				MaxFuel:    entity.FuelExtremeActionAmount,
+			},
+			Revision: &entity.Revision{
+				Version:      entity.AnchorageVersion,
+				CompiledCode: []byte("var result = 1"),
+			},
+			User: userForContract,
+		})
+
+		cs := sqlite.NewContractService(db)
+
+		if err := cs.DeleteContract(ctx, revision.ContractID); err != nil {
+			t.Fatal(err)
+		}
+
+		if contracts, n, err := cs.FindContracts(ctx, service.ContractFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(contracts) != 0 {
+			t.Fatalf("expected no contracts, got %d", n)
+		}
+
+		if _, err := cs.FindRevisionByContractAndRev(ctx, revision.ContractID, revision.Rev); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+
+		contract, err := cs.RestoreContract(ctx, revision.ContractID)
+		if err != nil {
+			t.Fatal(err)
+		} else if contract.LastRevision == nil || contract.LastRevision.ID != revision.ID {
+			t.Fatalf("expected the revisions to be kept, got %v", contract.LastRevision)
+		}
+
+		if _, err := cs.FindContractByID(ctx, revision.ContractID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, userForContract)
+
+		if _, err := sqlite.NewContractService(db).RestoreContract(ctx, contract.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, userForContract)
+
+		cs := sqlite.NewContractService(db)
+
+		if err := cs.DeleteContract(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-2"})
+
+		if _, err := cs.RestoreContract(ctx1, contract.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+}
+
+func TestContract_PurgeContracts(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		deleted, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "test-contract-deleted",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract"})
+
+		live := &entity.Contract{
+			Name:       "test-contract-live",
+			UserID:     deleted.UserID,
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}
+		if err := cs.CreateContract(ctx, live); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cs.DeleteContract(ctx, deleted.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// the contract is deleted after the retention period, nothing to purge.
+		if n, err := cs.PurgeContracts(ctx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("expected 0 purged contracts, got %d", n)
+		}
+
+		if n, err := cs.PurgeContracts(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("expected 1 purged contract, got %d", n)
+		}
+
+		if _, err := cs.RestoreContract(ctx, deleted.ID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+
+		if _, err := cs.FindContractByID(ctx, live.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestContract_SetActiveRevision(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-active-revision"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 3, userForContract)
+
+		if contract, err := cs.SetActiveRevision(ctx, contractID, 1); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 1 || contract.PreviousActiveRev != 3 {
+			t.Fatalf("expected active revision 1 and previous 3, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		}
+
+		if contract, err := cs.FindContractByID(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if revision, err := contract.UnwrapActiveRevision(); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 1 {
+			t.Fatalf("expected active revision %d, got %d", 1, revision.Rev)
+		} else if contract.LastRevision.Rev != 3 {
+			t.Fatalf("expected last revision %d, got %d", 3, contract.LastRevision.Rev)
+		}
+
+		// unpinned, the calls run again the last revision.
+		if contract, err := cs.SetActiveRevision(ctx, contractID, 0); err != nil {
+			t.Fatal(err)
+		} else if revision, err := contract.UnwrapActiveRevision(); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 3 {
+			t.Fatalf("expected active revision %d, got %d", 3, revision.Rev)
+		}
+	})
+
+	t.Run("RevisionNotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if _, err := sqlite.NewContractService(db).SetActiveRevision(ctx, contractID, 2); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, _ := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		_, ctx1 := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-active-revision-2"})
+
+		if _, err := sqlite.NewContractService(db).SetActiveRevision(ctx1, contractID, 1); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+}
+
+func TestContract_RollbackActiveRevision(t *testing.T) {
+
+	userForContract := &entity.User{Name: "test-active-revision"}
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 3, userForContract)
+
+		// no change recorded, the revision preceding the last one is pinned.
+		if contract, err := cs.RollbackActiveRevision(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 2 || contract.PreviousActiveRev != 3 {
+			t.Fatalf("expected active revision 2 and previous 3, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		} else if contract.ActiveRevision == nil || contract.ActiveRevision.Rev != 2 {
+			t.Fatalf("expected active revision 2 attached, got %v", contract.ActiveRevision)
+		}
+
+		// a second rollback restores the revision active before the first one.
+		if contract, err := cs.RollbackActiveRevision(ctx, contractID); err != nil {
+			t.Fatal(err)
+		} else if contract.ActiveRev != 3 || contract.PreviousActiveRev != 2 {
+			t.Fatalf("expected active revision 3 and previous 2, got %d and %d", contract.ActiveRev, contract.PreviousActiveRev)
+		}
+	})
+
+	t.Run("NothingToRollback", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 1, userForContract)
+
+		if _, err := sqlite.NewContractService(db).RollbackActiveRevision(ctx, contractID); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ECONFLICT {
+			t.Fatalf("expected %s, got %s", apperr.ECONFLICT, code)
+		}
+	})
+}
+
+func TestContract_UpdateContract(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-contract"})
+
+		oldUpdatedAt := contract.UpdatedAt
+		newContractName := "new-test-contract"
+		newContractDescription := "new-test-contract-description"
+		newMaxFuel := entity.FuelLongActionAmount
+
+		contractUpdate := service.ContractUpdate{
+			Name:        &newContractName,
+			Description: &newContractDescription,
+			MaxFuel:     &newMaxFuel,
+		}
+
+		// sleep to make sure updated_at is different
+		time.Sleep(time.Second)
+
+		if c, err := cs.UpdateContract(ctx, contract.ID, contractUpdate); err != nil {
+			t.Fatal(err)
+		} else if cc, err := cs.FindContractByID(ctx, c.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(c, cc) {
+			t.Fatal("expected contract to be equal")
+		} else if c.UpdatedAt.Equal(oldUpdatedAt) {
+			t.Fatalf("expected updatedAt to be updated")
+		} else if reloadedContract, err := cs.FindContractByID(ctx, c.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(reloadedContract, c) {
+			t.Fatal("expected contract to be equal")
+		} else if reloadedContract.UpdatedAt.Equal(oldUpdatedAt) {
+			t.Fatalf("expected updatedAt to be updated")
+		} else if !reloadedContract.UpdatedAt.Equal(c.UpdatedAt) {
+			t.Fatalf("expected updatedAt to be equal")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-contract"})
+
+		if err := cs.DeleteContract(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		newContractName := "new-test-contract"
+		newContractDescription := "new-test-contract-description"
+
+		contractUpdate := service.ContractUpdate{
+			Name:        &newContractName,
+			Description: &newContractDescription,
+		}
+
+		if _, err := cs.UpdateContract(ctx, contract.ID, contractUpdate); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, _ = MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-contract"})
+
+		newContractName := "new-test-contract"
+		newContractDescription := "new-test-contract-description"
+
+		contractUpdate := service.ContractUpdate{
+			Name:        &newContractName,
+			Description: &newContractDescription,
+		}
+
+		_, ctx := MustCreateUser(t, context.Background(), db, &entity.User{Name: "test-contract-another-user"})
+
+		if _, err := cs.UpdateContract(ctx, contract.ID, contractUpdate); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected %s, got %s", apperr.EUNAUTHORIZED, code)
+		}
+	})
+
+	t.Run("NotValid", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-contract"})
+
+		newContractName := ""
+		newContractDescription := "new-test-contract-description"
+
+		contractUpdate := service.ContractUpdate{
+			Name:        &newContractName,
+			Description: &newContractDescription,
+		}
+
+		if _, err := cs.UpdateContract(ctx, contract.ID, contractUpdate); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.EINVALID {
+			t.Fatalf("expected %s, got %s", apperr.EINVALID, code)
+		}
+	})
+
+	t.Run("UserDeletedBeforeUpdateContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-contract"})
+
+		newContractName := "new-test-contract"
+		newContractDescription := "new-test-contract-description"
+
+		contractUpdate := service.ContractUpdate{
+			Name:        &newContractName,
+			Description: &newContractDescription,
+		}
+
+		if err := sqlite.NewUserService(db).DeleteUser(ctx, contract.UserID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := cs.UpdateContract(ctx, contract.ID, contractUpdate); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+}
+
+func TestContract_FindContractByID(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		userContract := &entity.User{Name: "test-contract"}
+
+		contract := &entity.Contract{
+			Name:        "test-contract",
+			Description: "test-contract-description",
+			Visibility:  entity.VisibilityPublic,
+			MaxFuel:     entity.FuelExtremeActionAmount,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, userContract)
+
+		if contract.ID == 0 {
+			t.Fatal("expected non-zero contract id")
+		}
+
+		if c, err := cs.FindContractByID(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(contract, c) {
+			t.Fatal("expected contract to be equal")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		if _, err := cs.FindContractByID(context.Background(), 1); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, code)
+		}
+	})
+}
+
+func TestContract_FindContracts(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		for i := 0; i < 10; i++ {
+
+			tempUserContract := &entity.User{Name: "test-contract-" + strconv.Itoa(i)}
+
+			contract := &entity.Contract{
+				Name:        "test-contract",
+				Description: "test-contract-description",
+				Visibility:  entity.VisibilityPublic,
+				MaxFuel:     entity.FuelExtremeActionAmount,
+			}
+
+			MustCreateContract(t, context.Background(), db, contract, tempUserContract)
+		}
+
+		filterName := "test-contract"
+		filterDescription := "test-contract-description"
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{
+			Name:        &filterName,
+			Description: &filterDescription,
+		}); err != nil {
+			t.Fatal(err)
+		} else if n != 10 {
+			t.Fatalf("expected 10, got %d", n)
+		} else if len(contracts) != n {
+			t.Fatalf("expected %d, got %d", n, len(contracts))
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 10 {
+			t.Fatalf("expected 10, got %d", n)
+		} else if len(contracts) == n {
+			t.Fatal("Total number of contracts should be greater than the limit")
+		} else if len(contracts) > 2 {
+			t.Fatal("Expected only 2 contracts")
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{Offset: 2, Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 10 {
+			t.Fatalf("expected 10, got %d", n)
+		} else if len(contracts) == n {
+			t.Fatal("Total number of contracts should be greater than the limit")
+		} else if len(contracts) > 2 {
+			t.Fatal("Expected only 2 contracts")
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{Offset: 10, Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("expected 10, got %d", n)
+		} else if len(contracts) != 0 {
+			t.Fatal("Expected no contracts")
+		}
+	})
+
+	t.Run("Visibility", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		publicContract, _ := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "public-contract",
+			Visibility: entity.VisibilityPublic,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract-public"})
+
+		privateContract, _ := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "private-contract",
+			Visibility: entity.VisibilityPrivate,
+			MaxFuel:    entity.FuelExtremeActionAmount,
+		}, &entity.User{Name: "test-contract-private"})
+
+		anonymousID := int64(0)
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{VisibleTo: &anonymousID}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || contracts[0].ID != publicContract.ID {
+			t.Fatalf("expected only the public contract, got %d contracts", n)
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{VisibleTo: &privateContract.UserID}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(contracts) != 2 {
+			t.Fatalf("expected the owner to see both contracts, got %d", n)
+		}
+
+		if contracts, n, err := cs.FindContracts(context.Background(), service.ContractFilter{UserID: &privateContract.UserID, VisibleTo: &publicContract.UserID}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 || len(contracts) != 0 {
+			t.Fatalf("expected private contracts of other users to be hidden, got %d", n)
+		}
+	})
+
+	t.Run("OrderBy", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		for i := 0; i < 3; i++ {
+			MustCreateContract(t, context.Background(), db, &entity.Contract{
+				Name:       "test-contract-" + strconv.Itoa(i),
+				Visibility: entity.VisibilityPublic,
+				MaxFuel:    entity.FuelExtremeActionAmount,
+			}, &entity.User{Name: "test-contract-" + strconv.Itoa(i)})
+		}
+
+		if contracts, _, err := cs.FindContracts(context.Background(), service.ContractFilter{
+			OrderBy:   entity.ContractOrderByCreatedAt,
+			OrderDesc: true,
+		}); err != nil {
+			t.Fatal(err)
+		} else if len(contracts) != 3 {
+			t.Fatalf("expected 3, got %d", len(contracts))
+		} else if contracts[0].Name != "test-contract-2" || contracts[2].Name != "test-contract-0" {
+			t.Fatalf("expected contracts sorted by creation desc, got %s first", contracts[0].Name)
+		}
+
+		if _, _, err := cs.FindContracts(context.Background(), service.ContractFilter{
+			OrderBy: "name; DROP TABLE contracts; --",
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
+
+func TestContract_MakeRevision(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		if err := cs.MakeRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		} else if r, err := cs.FindRevisionByContractAndRev(ctx, contract.ID, revision.Rev); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(revision, r) {
+			t.Fatal("expected revision to be equal")
+		}
+	})
+
+	t.Run("MaxFuelDefaultFromContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+		}
+
+		if err := cs.MakeRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		} else if revision.MaxFuel != contract.MaxFuel {
+			t.Fatalf("expected revision max fuel to be %d, got %d", contract.MaxFuel, revision.MaxFuel)
+		}
+	})
+
+	t.Run("NewRevision", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		if err := cs.MakeRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 1 {
+			t.Fatalf("expected revision rev to be 1, got %d", revision.Rev)
+		}
+
+		newCode := "test-code-2"
+		newNotes := "test-notes"
+
+		newRevision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        newNotes,
+			CompiledCode: []byte(newCode),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		if err := cs.MakeRevision(ctx, newRevision); err != nil {
+			t.Fatal(err)
+		} else if newRevision.Rev != 2 {
+			t.Fatalf("expected revision rev to be 2, got %d", newRevision.Rev)
+		} else if newRevision.CodeHash != entity.HashRevisionCode([]byte(newCode)) {
+			t.Fatalf("expected code hash %s, got %s", entity.HashRevisionCode([]byte(newCode)), newRevision.CodeHash)
+		}
+	})
+
+	t.Run("ClientRevIgnored", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, &entity.User{Name: "test-make-revision"})
+
+		revision := &entity.Revision{
+			Rev:          10,
+			CodeHash:     "client-hash",
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code-new"),
+		}
+
+		if err := cs.MakeRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		} else if revision.Rev != 3 {
+			t.Fatalf("expected revision rev to be 3, got %d", revision.Rev)
+		} else if revision.CodeHash != entity.HashRevisionCode(revision.CompiledCode) {
+			t.Fatalf("expected code hash %s, got %s", entity.HashRevisionCode(revision.CompiledCode), revision.CodeHash)
+		}
+	})
+
+	t.Run("DuplicateCode", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, &entity.User{Name: "test-make-revision"})
+
+		// the last revision made by MustCreateRevisions.
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code-1"),
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ECONFLICT {
+			t.Fatalf("expected %s, got %s", apperr.ECONFLICT, code)
+		}
+
+		// the code of an older revision is a legit roll forward.
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ContractNotTouched", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision"})
+
+		createdAt := contract.CreatedAt
+		db.Now = func() time.Time { return createdAt.Add(time.Hour) }
+
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			CompiledCode: []byte("test-code"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := cs.FindContractByID(ctx, contract.ID); err != nil {
+			t.Fatal(err)
+		} else if !found.UpdatedAt.Equal(contract.UpdatedAt) {
+			t.Fatalf("expected updated at %v, got %v", contract.UpdatedAt, found.UpdatedAt)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision"})
+
+		const n = 5
+
+		revs := make(chan int, n)
+		errs := make(chan error, n)
+
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				revision := &entity.Revision{
+					Version:      entity.AnchorageVersion,
+					ContractID:   contract.ID,
+					CompiledCode: []byte(fmt.Sprintf("test-code-%d", i)),
+				}
+				if err := cs.MakeRevision(ctx, revision); err != nil {
+					errs <- err
+					return
+				}
+				revs <- int(revision.Rev)
+			}(i)
+		}
+
+		seen := make(map[int]bool)
+		for i := 0; i < n; i++ {
+			select {
+			case err := <-errs:
+				t.Fatal(err)
+			case rev := <-revs:
+				if seen[rev] {
+					t.Fatalf("expected unique revision numbers, got %d twice", rev)
+				}
+				seen[rev] = true
+			}
+		}
+	})
+
+	t.Run("DuplicateCodeBeforeCodeHash", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		contractID, ctx := MustCreateRevisions(t, context.Background(), db, 2, &entity.User{Name: "test-make-revision"})
+
+		// the revisions created before the code_hash column have an empty hash until the backfill runs again.
+		MustExec(t, db, "UPDATE revisions SET code_hash = ''")
+		MustExec(t, db, "DELETE FROM migrations WHERE name = $1", "migration/011-update_revisions_table_backfill_code_hash")
+
+		MustCloseDB(t, db)
+
+		db = sqlite.NewDB(db.DSN)
+		if err := db.Open(); err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		var codeHash string
+		if err := sqlite.GetConn(db).QueryRow("SELECT code_hash FROM revisions WHERE contract_id = $1 AND rev = 2", contractID).Scan(&codeHash); err != nil {
+			t.Fatal(err)
+		} else if codeHash != entity.HashRevisionCode([]byte("test-code-1")) {
+			t.Fatalf("expected code hash %s, got %s", entity.HashRevisionCode([]byte("test-code-1")), codeHash)
+		}
+
+		cs := sqlite.NewContractService(db)
+
+		if err := cs.MakeRevision(ctx, &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contractID,
+			CompiledCode: []byte("test-code-1"),
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if code := apperr.ErrorCode(err); code != apperr.ECONFLICT {
+			t.Fatalf("expected %s, got %s", apperr.ECONFLICT, code)
+		}
+	})
+
+	t.Run("ContractNotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   1,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		if err := cs.MakeRevision(context.Background(), revision); err == nil {
+			t.Fatal(err)
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ENOTFOUND {
+			t.Fatalf("expected error code to be %s, got %s", apperr.ENOTFOUND, errCode)
+		}
+	})
+
+	t.Run("ContractNotOwned", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract, _ := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision"})
+
+		_, ctx1 := MustCreateContract(t, context.Background(), db, &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}, &entity.User{Name: "test-make-revision-1"})
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		if err := cs.MakeRevision(ctx1, revision); err == nil {
+			t.Fatal(err)
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EUNAUTHORIZED {
+			t.Fatalf("expected error code to be %s, got %s", apperr.EUNAUTHORIZED, errCode)
+		}
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+		code := "test-code"
+		notes := "test-notes"
+
+		revision := &entity.Revision{
+			Version:      entity.AnchorageVersion,
+			ContractID:   contract.ID,
+			Notes:        notes,
+			CompiledCode: []byte(code),
+			MaxFuel:      entity.FuelInstantActionAmount,
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+
+		cancel()
+
+		if err := cs.MakeRevision(ctx, revision); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINTERNAL {
+			t.Fatalf("expected %s, got %s", apperr.EINTERNAL, errCode)
+		}
+	})
+
+	t.Run("ErrValidate", func(t *testing.T) {
+
+		contract := &entity.Contract{
+			Name:       "make-revision",
+			MaxFuel:    entity.FuelExtremeActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		t.Run("MissingVersion", func(t *testing.T) {
+
+			db := MustOpenDB(t)
+			defer db.Close()
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+			code := "test-code"
+			notes := "test-notes"
+
+			revision := &entity.Revision{
+				ContractID:   contract.ID,
+				Notes:        notes,
+				CompiledCode: []byte(code),
+				MaxFuel:      entity.FuelInstantActionAmount,
+			}
+
+			if err := cs.MakeRevision(ctx, revision); err == nil {
+				t.Fatal("expected error")
+			} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+				t.Fatalf("expected %s, got %s", apperr.EINVALID, errCode)
+			}
+		})
+
+		t.Run("MissingContractID", func(t *testing.T) {
+
+			db := MustOpenDB(t)
+			defer db.Close()
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			code := "test-code"
+			notes := "test-notes"
+
+			revision := &entity.Revision{
+				Version:      entity.AnchorageVersion,
+				Notes:        notes,
+				CompiledCode: []byte(code),
+				MaxFuel:      entity.FuelInstantActionAmount,
+			}
+
+			if err := cs.MakeRevision(context.Background(), revision); err == nil {
+				t.Fatal("expected error")
+			} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+				t.Fatalf("expected %s, got %s", apperr.EINVALID, errCode)
+			}
+		})
+
+		t.Run("MissingCompiledCode", func(t *testing.T) {
+
+			db := MustOpenDB(t)
+			defer db.Close()
+
+			TruncateTablesForContractTests(t, db)
+
+			cs := sqlite.NewContractService(db)
+
+			contract, ctx := MustCreateContract(t, context.Background(), db, contract, &entity.User{Name: "test-make-revision"})
+
+			notes := "test-notes"
+
+			revision := &entity.Revision{
+				Version:    entity.AnchorageVersion,
+				ContractID: contract.ID,
+				Notes:      notes,
+				MaxFuel:    entity.FuelInstantActionAmount,
+			}
+
+			if err := cs.MakeRevision(ctx, revision); err == nil {
+				t.Fatal("expected error")
+			} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+				t.Fatalf("expected %s, got %s", apperr.EINVALID, errCode)
+			}
+		})
+	})
+}
+
+func TestContract_FindRevisions(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test-find-revisions",
+				MaxFuel:    entity.FuelInstantActionAmount,
+				Visibility: entity.VisibilityPublic,
+			},
+			User: &entity.User{Name: "test-find-revisions"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		for i := 0; i < 2; i++ {
+			if err := cs.MakeRevision(ctx, &entity.Revision{
+				ContractID:   revision.ContractID,
+				CompiledCode: []byte(fmt.Sprintf("test-code-%d", i+2)),
+				Version:      entity.CurrentRevisionVersion,
+				Notes:        fmt.Sprintf("revision %d", i+2),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		revisions, n, err := cs.FindRevisions(ctx, service.RevisionFilter{ContractID: revision.ContractID, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 3 {
+			t.Fatalf("expected 3 revisions, got %d", n)
+		} else if len(revisions) != 2 {
+			t.Fatalf("expected 2 revisions, got %d", len(revisions))
+		} else if revisions[0].Rev != 3 || revisions[1].Rev != 2 {
+			t.Fatalf("expected revisions 3 and 2, got %d and %d", revisions[0].Rev, revisions[1].Rev)
+		}
+
+		if revisions, _, err := cs.FindRevisions(ctx, service.RevisionFilter{ContractID: revision.ContractID, Limit: 2, Offset: 2}); err != nil {
+			t.Fatal(err)
+		} else if len(revisions) != 1 || revisions[0].ID != revision.ID {
+			t.Fatalf("expected the first revision, got %v", revisions)
+		}
+	})
+}
+
+func TestContract_FindRevisionByContractAndRev(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "test-find-revision-by-contract-and-rev",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: contract,
+			User:     &entity.User{Name: "test-find-revision-by-contract-and-rev"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		if r, err := cs.FindRevisionByContractAndRev(ctx, revision.ContractID, revision.Rev); err != nil {
+			t.Fatal(err)
+		} else if r == nil {
+			t.Fatal("expected revision")
+		} else if !reflect.DeepEqual(r, revision) {
+			t.Fatalf("expected revision %v, got %v", revision, r)
+		}
+	})
+
+	t.Run("ContextCancelled", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "test-find-revision-by-contract-and-rev",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		_, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: contract,
+			User:     &entity.User{Name: "test-find-revision-by-contract-and-rev"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		ctx, cancel := context.WithCancel(ctx)
+
+		cancel()
+
+		if _, err := cs.FindRevisionByContractAndRev(ctx, contract.ID, 0); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINTERNAL {
+			t.Fatalf("expected %s, got %s", apperr.EINTERNAL, errCode)
+		}
+	})
+
+	t.Run("ContractNotExists", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		if _, err := cs.FindRevisionByContractAndRev(context.Background(), 1, 0); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, errCode)
+		}
+	})
+
+	t.Run("RevNumberNotExists", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "test-find-revision-by-contract-and-rev",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: contract,
+			User:     &entity.User{Name: "test-find-revision-by-contract-and-rev"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		// now exists rev n°1, try to find rev n°2
+
+		if _, err := cs.FindRevisionByContractAndRev(context.Background(), contract.ID, 2); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ENOTFOUND {
+			t.Fatalf("expected %s, got %s", apperr.ENOTFOUND, errCode)
+		}
+	})
+
+	t.Run("FindLastRevision", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer db.Close()
+
+		TruncateTablesForContractTests(t, db)
+
+		cs := sqlite.NewContractService(db)
+
+		contract := &entity.Contract{
+			Name:       "test-find-revision-by-contract-and-rev",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		}
+
+		revision, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: contract,
+			User:     &entity.User{Name: "test-find-revision-by-contract-and-rev"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		// create random number between 2 and 100
+		rand.Seed(time.Now().UnixNano())
+		num := rand.Intn(98) + 2
+
+		for i := 0; i < num; i++ {
+			revisionCode := fmt.Sprintf("test-code-%d", i)
+			revision := &entity.Revision{
+				Version:      entity.AnchorageVersion,
+				ContractID:   contract.ID,
+				CompiledCode: []byte(revisionCode),
+				MaxFuel:      entity.FuelInstantActionAmount,
+			}
+			if err := cs.MakeRevision(ctx, revision); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		lastRevision, err := cs.FindRevisionByContractAndRev(ctx, revision.ContractID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lastCalculatedRevision, err := cs.FindRevisionByContractAndRev(ctx, revision.ContractID, entity.RevisionNumber(num+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lastRevision.Rev != lastCalculatedRevision.Rev {
+			t.Fatalf("expected last revision %d, got %d", lastCalculatedRevision.Rev, lastRevision.Rev)
+		} else if !reflect.DeepEqual(lastRevision, lastCalculatedRevision) {
+			t.Fatalf("expected last revision %v, got %v", lastCalculatedRevision, lastRevision)
+		}
+	})
+}
+
+func MustCreateContract(tb testing.TB, ctx context.Context, db *sqlite.DB, contract *entity.Contract, user *entity.User) (*entity.Contract, context.Context) {
+	tb.Helper()
+	user, ctx = MustCreateUser(tb, ctx, db, user)
+	contract.UserID = user.ID
+	if err := sqlite.NewContractService(db).CreateContract(ctx, contract); err != nil {
+		tb.Fatal(err)
+	}
+	return contract, ctx
+}
+
+type DataToMakeRevision struct {
+	Contract *entity.Contract
+	Revision *entity.Revision
+	User     *entity.User
+}
+
+func MustCreateRevision(tb testing.TB, ctx context.Context, db *sqlite.DB, data DataToMakeRevision) (*entity.Revision, context.Context) {
+	tb.Helper()
+	contract, ctx := MustCreateContract(tb, ctx, db, data.Contract, data.User)
+	data.Revision.ContractID = contract.ID
+	if err := sqlite.NewContractService(db).MakeRevision(ctx, data.Revision); err != nil {
+		tb.Fatal(err)
+	}
+	return data.Revision, ctx
+}
+
+// MustCreateRevisions creates a contract owned by the given user with n revisions.
+func MustCreateRevisions(tb testing.TB, ctx context.Context, db *sqlite.DB, n int, user *entity.User) (int64, context.Context) {
+	tb.Helper()
+	revision, ctx := MustCreateRevision(tb, ctx, db, DataToMakeRevision{
+		Contract: &entity.Contract{
+			Name:       "test-contract",
+			MaxFuel:    entity.FuelInstantActionAmount,
+			Visibility: entity.VisibilityPublic,
+		},
+		Revision: &entity.Revision{
+			CompiledCode: []byte("test-code"),
+			Version:      entity.CurrentRevisionVersion,
+		},
+		User: user,
+	})
+	for i := 1; i < n; i++ {
+		if err := sqlite.NewContractService(db).MakeRevision(ctx, &entity.Revision{
+			ContractID:   revision.ContractID,
+			CompiledCode: []byte(fmt.Sprintf("test-code-%d", i)),
+			Version:      entity.CurrentRevisionVersion,
+		}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return revision.ContractID, ctx
+}
+
+func TruncateTablesForContractTests(tb testing.TB, db *sqlite.DB) {
+	tb.Helper()
+
+	MustTruncateTable(tb, db, "users")
+	MustTruncateTable(tb, db, "auths")
+	MustTruncateTable(tb, db, "contracts")
+}
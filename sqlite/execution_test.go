@@ -0,0 +1,271 @@
+package sqlite_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/sqlite"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestExecutionService_CreateExecution(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTableForExecutionTests(t, db)
+
+		rev, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test",
+				MaxFuel:    entity.FuelInstantActionAmount,
+				Visibility: entity.VisibilityPublic,
+			},
+			User: &entity.User{Name: "test-create-execution"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		s := sqlite.NewExecutionService(db)
+
+		now := time.Now().UTC()
+
+		execution := &entity.Execution{
+			ContractID:   rev.ContractID,
+			RevisionID:   rev.ID,
+			Rev:          rev.Rev,
+			Alias:        "canary",
+			UserID:       null.IntFrom(app.UserIDFromContext(ctx)),
+			StartedAt:    now,
+			EndedAt:      now.Add(time.Millisecond),
+			FuelReserved: entity.FuelInstantActionAmount,
+			FuelCharged:  entity.FuelInstantActionAmount / 2,
+			Outcome:      entity.ExecutionOutcomeOK,
+			ResultSize:   1,
+			Seed:         42,
+			FrozenAt:     null.TimeFrom(now.Truncate(time.Millisecond)),
+			Snapshot: &entity.ExecutionSnapshot{
+				Input:       map[string]any{"n": 1},
+				StateBefore: entity.StateValue{"count": 1},
+				Result:      "ok",
+				StateAfter:  entity.StateValue{"count": 2},
+			},
+		}
+
+		if err := s.CreateExecution(ctx, execution); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if execution.ID == 0 {
+			t.Fatal("execution ID is 0")
+		}
+
+		if found, err := s.FindExecutionByID(ctx, execution.ID); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if found.Outcome != entity.ExecutionOutcomeOK {
+			t.Errorf("expected outcome %s, got %s", entity.ExecutionOutcomeOK, found.Outcome)
+		} else if found.FuelCharged != execution.FuelCharged {
+			t.Errorf("expected fuel charged %d, got %d", execution.FuelCharged, found.FuelCharged)
+		} else if found.Rev != rev.Rev || found.Alias != "canary" {
+			t.Errorf("expected rev %d and alias %s, got %d and %s", rev.Rev, "canary", found.Rev, found.Alias)
+		} else if found.Seed != execution.Seed || !found.FrozenAt.Time.Equal(execution.FrozenAt.Time) {
+			t.Errorf("expected seed %d and frozen time %v, got %d and %v", execution.Seed, execution.FrozenAt.Time, found.Seed, found.FrozenAt.Time)
+		} else if found.Snapshot == nil {
+			t.Error("expected the snapshot to be found")
+		} else if !reflect.DeepEqual(found.Snapshot, &entity.ExecutionSnapshot{
+			Input:       map[string]any{"n": float64(1)},
+			StateBefore: entity.StateValue{"count": float64(1)},
+			Result:      "ok",
+			StateAfter:  entity.StateValue{"count": float64(2)},
+		}) {
+			t.Errorf("unexpected snapshot %+v", found.Snapshot)
+		}
+	})
+
+	t.Run("ErrInvalid", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTableForExecutionTests(t, db)
+
+		s := sqlite.NewExecutionService(db)
+
+		if err := s.CreateExecution(context.Background(), &entity.Execution{}); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
+
+func TestExecutionService_FindExecutions(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTableForExecutionTests(t, db)
+
+		rev, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test",
+				MaxFuel:    entity.FuelInstantActionAmount,
+				Visibility: entity.VisibilityPublic,
+			},
+			User: &entity.User{Name: "test-find-executions"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		s := sqlite.NewExecutionService(db)
+
+		now := time.Now().UTC()
+
+		outcomes := []entity.ExecutionOutcome{
+			entity.ExecutionOutcomeOK,
+			entity.ExecutionOutcomeError,
+			entity.ExecutionOutcomeOK,
+		}
+
+		for i, outcome := range outcomes {
+			if err := s.CreateExecution(ctx, &entity.Execution{
+				ContractID:   rev.ContractID,
+				RevisionID:   rev.ID,
+				StartedAt:    now.Add(time.Duration(i) * time.Second),
+				EndedAt:      now.Add(time.Duration(i) * time.Second),
+				FuelReserved: entity.FuelInstantActionAmount,
+				FuelCharged:  entity.FuelInstantActionAmount,
+				Outcome:      outcome,
+			}); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+		}
+
+		contractID := rev.ContractID
+		outcome := entity.ExecutionOutcome(entity.ExecutionOutcomeOK)
+
+		executions, n, err := s.FindExecutions(ctx, service.ExecutionFilter{
+			ContractID: &contractID,
+			Outcome:    &outcome,
+			Limit:      1,
+		})
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if n != 2 {
+			t.Errorf("expected 2 executions, got %d", n)
+		} else if len(executions) != 1 {
+			t.Fatalf("expected 1 execution in page, got %d", len(executions))
+		} else if executions[0].ID != 3 {
+			t.Errorf("expected most recent execution first, got %d", executions[0].ID)
+		}
+	})
+
+	t.Run("ErrNotFound", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTableForExecutionTests(t, db)
+
+		s := sqlite.NewExecutionService(db)
+
+		if _, err := s.FindExecutionByID(context.Background(), 1); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.ENOTFOUND {
+			t.Errorf("expected error code %s, got %s", apperr.ENOTFOUND, errCode)
+		}
+	})
+}
+
+func TestExecutionService_PurgeExecutionSnapshots(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTableForExecutionTests(t, db)
+
+		rev, ctx := MustCreateRevision(t, context.Background(), db, DataToMakeRevision{
+			Contract: &entity.Contract{
+				Name:       "test",
+				MaxFuel:    entity.FuelInstantActionAmount,
+				Visibility: entity.VisibilityPublic,
+			},
+			User: &entity.User{Name: "test-purge-execution-snapshots"},
+			Revision: &entity.Revision{
+				CompiledCode: []byte("test-code"),
+				Version:      entity.CurrentRevisionVersion,
+			},
+		})
+
+		s := sqlite.NewExecutionService(db)
+
+		now := time.Now().UTC()
+
+		executions := entity.Executions{}
+
+		for _, startedAt := range []time.Time{now.Add(-2 * time.Hour), now} {
+			execution := &entity.Execution{
+				ContractID:   rev.ContractID,
+				RevisionID:   rev.ID,
+				StartedAt:    startedAt,
+				EndedAt:      startedAt.Add(time.Millisecond),
+				FuelReserved: entity.FuelInstantActionAmount,
+				FuelCharged:  entity.FuelInstantActionAmount,
+				Outcome:      entity.ExecutionOutcomeOK,
+				Snapshot: &entity.ExecutionSnapshot{
+					Input:       map[string]any{"n": 1},
+					StateBefore: entity.StateValue{},
+					Result:      "ok",
+					StateAfter:  entity.StateValue{},
+				},
+			}
+			if err := s.CreateExecution(ctx, execution); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			executions = append(executions, execution)
+		}
+
+		if n, err := s.PurgeExecutionSnapshots(ctx, now.Add(-time.Hour)); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if n != 1 {
+			t.Fatalf("expected 1 purged snapshot, got %d", n)
+		}
+
+		// the old execution is kept without its recorded call.
+		if found, err := s.FindExecutionByID(ctx, executions[0].ID); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if found.Snapshot != nil {
+			t.Errorf("expected the snapshot to be purged, got %+v", found.Snapshot)
+		}
+
+		if found, err := s.FindExecutionByID(ctx, executions[1].ID); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if found.Snapshot == nil {
+			t.Error("expected the snapshot to be kept")
+		}
+	})
+}
+
+func MustTruncateTableForExecutionTests(tb testing.TB, db *sqlite.DB) {
+	tb.Helper()
+
+	MustTruncateTable(tb, db, "users")
+	MustTruncateTable(tb, db, "contracts")
+	MustTruncateTable(tb, db, "revisions")
+	MustTruncateTable(tb, db, "execution_snapshots")
+	MustTruncateTable(tb, db, "executions")
+}
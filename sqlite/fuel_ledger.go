@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/sqlite/query"
+	"gopkg.in/guregu/null.v4"
+)
+
+var _ service.FuelLedgerService = (*FuelLedgerService)(nil)
+
+// fuelUsageBucketLayout is the layout of the time buckets returned by fuelUsageBucketExprs.
+const fuelUsageBucketLayout = "2006-01-02 15:04:05"
+
+// fuelUsageBucketExprs maps the time buckets to the sqlite expressions truncating created_at.
+// sqlite has no date_trunc, weeks start on monday as in postgres.
+var fuelUsageBucketExprs = map[entity.FuelUsageBucket]string{
+	entity.FuelUsageBucketMinute: "strftime('%Y-%m-%d %H:%M:00', created_at)",
+	entity.FuelUsageBucketHour:   "strftime('%Y-%m-%d %H:00:00', created_at)",
+	entity.FuelUsageBucketDay:    "strftime('%Y-%m-%d 00:00:00', created_at)",
+	entity.FuelUsageBucketWeek:   "strftime('%Y-%m-%d 00:00:00', created_at, 'weekday 0', '-6 days')",
+	entity.FuelUsageBucketMonth:  "strftime('%Y-%m-01 00:00:00', created_at)",
+}
+
+// FuelLedgerService is the sqlite implementation of the fuel ledger service.
+type FuelLedgerService struct {
+	db *DB
+}
+
+// NewFuelLedgerService creates a new fuel ledger service.
+func NewFuelLedgerService(db *DB) *FuelLedgerService {
+	return &FuelLedgerService{db: db}
+}
+
+// FindFuelUsages returns the fuel ledger aggregated as specified by the filter.
+func (fs *FuelLedgerService) FindFuelUsages(ctx context.Context, filter service.FuelUsageFilter) (entity.FuelUsages, error) {
+
+	tx, err := fs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findFuelUsages(ctx, tx, filter)
+}
+
+// RecordFuelEntries appends the given entries to the ledger in a single transaction.
+func (fs *FuelLedgerService) RecordFuelEntries(ctx context.Context, entries entity.FuelLedgerEntries) error {
+
+	tx, err := fs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if err := createFuelLedgerEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// createFuelLedgerEntry appends a new entry to the ledger.
+// If the entry has no creation time, the transaction time is used.
+func createFuelLedgerEntry(ctx context.Context, tx *Tx, entry *entity.FuelLedgerEntry) error {
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = tx.now
+	}
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, query.InsertFuelLedgerEntryQuery(),
+		entry.Kind,
+		entry.Operation,
+		entry.UserID,
+		entry.ContractID,
+		entry.RevisionID,
+		entry.Amount,
+		entry.CreatedAt,
+	).Scan(&entry.ID); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to insert fuel ledger entry: %v", err)
+	}
+
+	return nil
+}
+
+// findFuelUsages aggregates the fuel ledger by user, contract or time bucket.
+func findFuelUsages(ctx context.Context, tx *Tx, filter service.FuelUsageFilter) (_ entity.FuelUsages, err error) {
+
+	if err := filter.GroupBy.Validate(); err != nil {
+		return nil, err
+	}
+
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	counterParameter := 1
+
+	var groupExpr string
+
+	switch filter.GroupBy {
+	case entity.FuelUsageGroupByUser:
+		groupExpr = "user_id"
+		where = append(where, "user_id IS NOT NULL")
+	case entity.FuelUsageGroupByContract:
+		groupExpr = "contract_id"
+		where = append(where, "contract_id IS NOT NULL")
+	case entity.FuelUsageGroupByBucket:
+		if err := filter.Bucket.Validate(); err != nil {
+			return nil, err
+		}
+		groupExpr = fuelUsageBucketExprs[filter.Bucket]
+	}
+
+	if v := filter.UserID; v != nil {
+		where = append(where, fmt.Sprintf("user_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	if v := filter.ContractID; v != nil {
+		where = append(where, fmt.Sprintf("contract_id = $%d", counterParameter))
+		args = append(args, *v)
+		counterParameter++
+	}
+	// times are stored as UTC text, so the bounds must be in UTC to be compared.
+	if v := filter.From; v != nil {
+		where = append(where, fmt.Sprintf("created_at >= $%d", counterParameter))
+		args = append(args, v.UTC())
+		counterParameter++
+	}
+	if v := filter.To; v != nil {
+		where = append(where, fmt.Sprintf("created_at < $%d", counterParameter))
+		args = append(args, v.UTC())
+		counterParameter++
+	}
+
+	rows, err := tx.QueryContext(ctx, query.SelectFuelUsagesQuery(groupExpr, where, filter.Limit, filter.Offset), args...)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to query fuel usages: %v", err)
+	}
+	defer rows.Close()
+
+	usages := make(entity.FuelUsages, 0)
+
+	for rows.Next() {
+
+		var usage entity.FuelUsage
+
+		// the buckets are returned as text by strftime.
+		var bucket string
+
+		var groupKey interface{}
+		switch filter.GroupBy {
+		case entity.FuelUsageGroupByUser:
+			groupKey = &usage.UserID
+		case entity.FuelUsageGroupByContract:
+			groupKey = &usage.ContractID
+		case entity.FuelUsageGroupByBucket:
+			groupKey = &bucket
+		}
+
+		if err := rows.Scan(
+			groupKey,
+			&usage.Burned,
+			&usage.Refunded,
+			&usage.Refilled,
+		); err != nil {
+			return nil, apperr.Errorf(apperr.EINTERNAL, "failed to scan fuel usage: %v", err)
+		}
+
+		if bucket != "" {
+			t, err := time.ParseInLocation(fuelUsageBucketLayout, bucket, time.UTC)
+			if err != nil {
+				return nil, apperr.Errorf(apperr.EINTERNAL, "failed to parse fuel usage bucket: %v", err)
+			}
+			usage.Bucket = null.TimeFrom(t)
+		}
+
+		if usage.Burned > usage.Refunded {
+			usage.Consumed = usage.Burned - usage.Refunded
+		}
+
+		usages = append(usages, &usage)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to iterate over fuel usages: %v", err)
+	}
+
+	return usages, nil
+}
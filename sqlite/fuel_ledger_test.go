@@ -0,0 +1,195 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+	"github.com/music-gang/music-gang-api/app/service"
+	"github.com/music-gang/music-gang-api/sqlite"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestFuelLedgerService_RecordFuelEntries(t *testing.T) {
+
+	t.Run("OK", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTable(t, db, "fuel_ledger")
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		entries := entity.FuelLedgerEntries{
+			{Kind: entity.FuelLedgerKindBurn, Operation: entity.VmOperationExecuteContract, UserID: null.IntFrom(1), ContractID: null.IntFrom(1), RevisionID: null.IntFrom(1), Amount: 100},
+			{Kind: entity.FuelLedgerKindRefund, Operation: entity.VmOperationExecuteContract, UserID: null.IntFrom(1), ContractID: null.IntFrom(1), RevisionID: null.IntFrom(1), Amount: 50},
+		}
+
+		if err := s.RecordFuelEntries(context.Background(), entries); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+
+		for _, entry := range entries {
+			if entry.ID == 0 {
+				t.Error("entry ID is 0")
+			}
+		}
+	})
+
+	t.Run("ErrInvalid", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTable(t, db, "fuel_ledger")
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		entries := entity.FuelLedgerEntries{
+			{Kind: entity.FuelLedgerKindBurn, Amount: 100},
+			{Kind: "unknown", Amount: 100},
+		}
+
+		if err := s.RecordFuelEntries(context.Background(), entries); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+
+		if usages, err := s.FindFuelUsages(context.Background(), service.FuelUsageFilter{
+			GroupBy: entity.FuelUsageGroupByBucket,
+			Bucket:  entity.FuelUsageBucketDay,
+		}); err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if len(usages) != 0 {
+			t.Errorf("expected no entry recorded, got %d usages", len(usages))
+		}
+	})
+}
+
+func TestFuelLedgerService_FindFuelUsages(t *testing.T) {
+
+	mustRecordEntries := func(t *testing.T, s *sqlite.FuelLedgerService, now time.Time) {
+		t.Helper()
+
+		entries := entity.FuelLedgerEntries{
+			{Kind: entity.FuelLedgerKindBurn, UserID: null.IntFrom(1), ContractID: null.IntFrom(1), Amount: 100, CreatedAt: now},
+			{Kind: entity.FuelLedgerKindRefund, UserID: null.IntFrom(1), ContractID: null.IntFrom(1), Amount: 40, CreatedAt: now},
+			{Kind: entity.FuelLedgerKindBurn, UserID: null.IntFrom(2), ContractID: null.IntFrom(1), Amount: 200, CreatedAt: now},
+			{Kind: entity.FuelLedgerKindBurn, UserID: null.IntFrom(2), ContractID: null.IntFrom(2), Amount: 300, CreatedAt: now.Add(-time.Hour)},
+			{Kind: entity.FuelLedgerKindRefill, Amount: 1000, CreatedAt: now},
+		}
+
+		if err := s.RecordFuelEntries(context.Background(), entries); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	t.Run("GroupByUser", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTable(t, db, "fuel_ledger")
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		mustRecordEntries(t, s, time.Now().UTC())
+
+		usages, err := s.FindFuelUsages(context.Background(), service.FuelUsageFilter{
+			GroupBy: entity.FuelUsageGroupByUser,
+		})
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if len(usages) != 2 {
+			t.Fatalf("expected 2 usages, got %d", len(usages))
+		}
+
+		if usages[0].UserID.Int64 != 1 || usages[0].Consumed != 60 {
+			t.Errorf("unexpected usage for user 1: %+v", usages[0])
+		}
+
+		if usages[1].UserID.Int64 != 2 || usages[1].Consumed != 500 {
+			t.Errorf("unexpected usage for user 2: %+v", usages[1])
+		}
+	})
+
+	t.Run("GroupByContract", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTable(t, db, "fuel_ledger")
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		mustRecordEntries(t, s, time.Now().UTC())
+
+		userID := int64(2)
+
+		usages, err := s.FindFuelUsages(context.Background(), service.FuelUsageFilter{
+			GroupBy: entity.FuelUsageGroupByContract,
+			UserID:  &userID,
+		})
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if len(usages) != 2 {
+			t.Fatalf("expected 2 usages, got %d", len(usages))
+		}
+
+		if usages[0].ContractID.Int64 != 1 || usages[0].Burned != 200 {
+			t.Errorf("unexpected usage for contract 1: %+v", usages[0])
+		}
+	})
+
+	t.Run("GroupByBucket", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		MustTruncateTable(t, db, "fuel_ledger")
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		now := time.Now().UTC()
+
+		mustRecordEntries(t, s, now)
+
+		from := now.Add(-time.Minute)
+
+		usages, err := s.FindFuelUsages(context.Background(), service.FuelUsageFilter{
+			GroupBy: entity.FuelUsageGroupByBucket,
+			Bucket:  entity.FuelUsageBucketHour,
+			From:    &from,
+		})
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		} else if len(usages) != 1 {
+			t.Fatalf("expected 1 usage, got %d", len(usages))
+		}
+
+		if usages[0].Burned != 300 || usages[0].Refilled != 1000 {
+			t.Errorf("unexpected usage: %+v", usages[0])
+		}
+	})
+
+	t.Run("ErrInvalidBucket", func(t *testing.T) {
+
+		db := MustOpenDB(t)
+		defer MustCloseDB(t, db)
+
+		s := sqlite.NewFuelLedgerService(db)
+
+		if _, err := s.FindFuelUsages(context.Background(), service.FuelUsageFilter{
+			GroupBy: entity.FuelUsageGroupByBucket,
+			Bucket:  "year'; DROP TABLE fuel_ledger; --",
+		}); err == nil {
+			t.Fatal("expected error")
+		} else if errCode := apperr.ErrorCode(err); errCode != apperr.EINVALID {
+			t.Errorf("expected error code %s, got %s", apperr.EINVALID, errCode)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+CREATE TABLE users
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) UNIQUE NULL,
+	password VARCHAR(255) NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE auths
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	source VARCHAR(255) NOT NULL,
+	source_id VARCHAR(255) NULL,
+	access_token VARCHAR(255) NULL,
+	refresh_token VARCHAR(255) NULL,
+	expiry DATETIME NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	UNIQUE(user_id, source),
+	-- one source per user
+
+	UNIQUE(source, source_id)
+	-- one auth per source user
+);
@@ -0,0 +1,11 @@
+CREATE TABLE contracts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	visibility VARCHAR(255) NOT NULL DEFAULT 'public',
+	max_fuel INTEGER NOT NULL DEFAULT 0,
+	stateful BOOLEAN NOT NULL DEFAULT false,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
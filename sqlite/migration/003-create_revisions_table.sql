@@ -0,0 +1,13 @@
+CREATE TABLE revisions
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rev INTEGER NOT NULL,
+	version VARCHAR(255) NOT NULL,
+	contract_id INTEGER NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+	notes TEXT NOT NULL DEFAULT '',
+	compiled_code BLOB NOT NULL,
+	max_fuel INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX UQ_CONTRACT_REVISION on revisions(rev, contract_id);
@@ -0,0 +1,10 @@
+CREATE TABLE states(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	revision_id INTEGER NOT NULL REFERENCES revisions(id) ON DELETE CASCADE,
+	value BLOB NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	UNIQUE(revision_id, user_id)
+);
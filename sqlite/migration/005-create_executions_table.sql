@@ -0,0 +1,16 @@
+CREATE TABLE executions
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	contract_id INTEGER NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+	revision_id INTEGER NOT NULL REFERENCES revisions(id) ON DELETE CASCADE,
+	user_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME NOT NULL,
+	fuel_reserved INTEGER NOT NULL DEFAULT 0,
+	fuel_charged INTEGER NOT NULL DEFAULT 0,
+	outcome VARCHAR(255) NOT NULL,
+	error_code VARCHAR(255) NOT NULL DEFAULT '',
+	result_size INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IDX_EXECUTIONS_CONTRACT_STARTED_AT on executions(contract_id, started_at DESC);
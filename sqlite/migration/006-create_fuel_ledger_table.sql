@@ -0,0 +1,17 @@
+CREATE TABLE fuel_ledger
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind VARCHAR(255) NOT NULL,
+	operation VARCHAR(255) NOT NULL DEFAULT '',
+	user_id INTEGER NULL,
+	contract_id INTEGER NULL,
+	revision_id INTEGER NULL,
+	amount INTEGER NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- no foreign keys, the ledger must survive the deletion of users and contracts for billing.
+
+CREATE INDEX IDX_FUEL_LEDGER_CREATED_AT on fuel_ledger(created_at);
+CREATE INDEX IDX_FUEL_LEDGER_USER_ID on fuel_ledger(user_id, created_at);
+CREATE INDEX IDX_FUEL_LEDGER_CONTRACT_ID on fuel_ledger(contract_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE contracts ADD deleted_at DATETIME NULL;
+
+CREATE INDEX IDX_CONTRACTS_DELETED_AT on contracts(deleted_at);
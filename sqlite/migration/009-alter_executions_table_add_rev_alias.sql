@@ -0,0 +1,2 @@
+ALTER TABLE executions ADD rev INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE executions ADD alias VARCHAR(255) NOT NULL DEFAULT '';
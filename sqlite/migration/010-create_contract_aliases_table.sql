@@ -0,0 +1,13 @@
+CREATE TABLE contract_aliases
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	contract_id INTEGER NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	rev INTEGER NOT NULL,
+	split_rev INTEGER NOT NULL DEFAULT 0,
+	split_weight INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	UNIQUE(contract_id, name)
+);
@@ -0,0 +1 @@
+ALTER TABLE revisions ADD code_hash VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+CREATE TABLE execution_snapshots
+(
+	execution_id INTEGER PRIMARY KEY REFERENCES executions(id) ON DELETE CASCADE,
+	input BLOB NOT NULL,
+	state_before BLOB NOT NULL,
+	result BLOB NOT NULL,
+	state_after BLOB NOT NULL
+);
@@ -0,0 +1,133 @@
+package query
+
+import "strings"
+
+func DeleteContractQuery() string {
+	return `
+		UPDATE contracts SET
+			deleted_at = $1,
+			updated_at = $1
+		WHERE id = $2
+	`
+}
+
+func InsertContractQuery() string {
+	return `
+		INSERT INTO contracts (
+			name,
+			description,
+			user_id,
+			visibility,
+			max_fuel,
+			stateful,
+			created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+}
+
+func InsertRevisionQuery() string {
+	return `
+		INSERT INTO revisions (
+			rev,
+			version,
+			contract_id,
+			notes,
+			compiled_code,
+			code_hash,
+			max_fuel,
+			max_memory,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id
+	`
+}
+
+// SelectContractsQuery returns the contracts query sorted by the given order.
+// orderBy must never contain user input.
+func SelectContractsQuery(whereCondtions []string, orderBy string, limit, offset int) string {
+	return `		
+		SELECT
+			id,
+			name,
+			description,
+			user_id,
+			visibility,
+			max_fuel,
+			stateful,
+			active_rev,
+			previous_active_rev,
+			created_at,
+			updated_at,
+			COUNT(*) OVER() as count
+		FROM contracts
+		WHERE ` + strings.Join(whereCondtions, " AND ") + `
+		ORDER BY ` + orderBy + `
+		` + FormatLimitOffset(limit, offset)
+}
+
+func PurgeContractsQuery() string {
+	return `
+		DELETE FROM contracts WHERE deleted_at < $1
+	`
+}
+
+func RestoreContractQuery() string {
+	return `
+		UPDATE contracts SET
+			deleted_at = NULL,
+			updated_at = $1
+		WHERE id = $2
+	`
+}
+
+// LockContractQuery takes the write lock of the database until the end of the transaction, without changing the contract.
+// sqlite has no row locks, any update statement starts the write transaction even if it changes nothing.
+func LockContractQuery() string {
+	return `
+		UPDATE contracts SET
+			id = id
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+}
+
+func UpdateActiveRevisionQuery() string {
+	return `
+		UPDATE contracts SET
+			active_rev = $1,
+			previous_active_rev = $2,
+			updated_at = $3
+		WHERE id = $4
+	`
+}
+
+func SelectRevisionsQuery(whereCondtions []string, limit, offset int) string {
+	return `
+		SELECT
+			id,
+			rev,
+			version,
+			contract_id,
+			notes,
+			compiled_code,
+			code_hash,
+			max_fuel,
+			max_memory,
+			created_at,
+			COUNT(*) OVER() as count
+		FROM revisions
+		WHERE ` + strings.Join(whereCondtions, " AND ") + `
+		ORDER BY rev DESC
+		` + FormatLimitOffset(limit, offset)
+}
+
+func UpdateContractQuery() string {
+	return `
+		UPDATE contracts SET
+			name = $1,
+			description = $2,
+			max_fuel = $3,
+			updated_at = $4
+		WHERE id = $5
+	`
+}
@@ -0,0 +1,47 @@
+package query
+
+import "strings"
+
+func DeleteContractAliasQuery() string {
+	return `
+		DELETE FROM contract_aliases WHERE contract_id = $1 AND name = $2
+	`
+}
+
+// UpsertContractAliasQuery inserts the alias or moves the existing one, the creation time of an existing alias is kept.
+func UpsertContractAliasQuery() string {
+	return `
+		INSERT INTO contract_aliases (
+			contract_id,
+			name,
+			rev,
+			split_rev,
+			split_weight,
+			created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (contract_id, name) DO UPDATE SET
+			rev = excluded.rev,
+			split_rev = excluded.split_rev,
+			split_weight = excluded.split_weight,
+			updated_at = excluded.updated_at
+		RETURNING id, created_at
+	`
+}
+
+func SelectContractAliasesQuery(whereConditions []string) string {
+	return `
+		SELECT
+			id,
+			contract_id,
+			name,
+			rev,
+			split_rev,
+			split_weight,
+			created_at,
+			updated_at
+		FROM contract_aliases
+		WHERE ` + strings.Join(whereConditions, " AND ") + `
+		ORDER BY name
+	`
+}
@@ -0,0 +1,33 @@
+package query
+
+import "strings"
+
+func InsertFuelLedgerEntryQuery() string {
+	return `
+		INSERT INTO fuel_ledger (
+			kind,
+			operation,
+			user_id,
+			contract_id,
+			revision_id,
+			amount,
+			created_at
+		) VALUES ( $1, $2, $3, $4, $5, $6, $7 ) RETURNING id
+	`
+}
+
+// SelectFuelUsagesQuery aggregates the fuel ledger by the given group expression.
+// groupExpr must never contain user input.
+func SelectFuelUsagesQuery(groupExpr string, whereConditions []string, limit, offset int) string {
+	return `
+		SELECT
+			` + groupExpr + ` AS group_key,
+			COALESCE(SUM(CASE WHEN kind = 'burn' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN kind = 'refund' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN kind = 'refill' THEN amount ELSE 0 END), 0)
+		FROM fuel_ledger
+		WHERE ` + strings.Join(whereConditions, " AND ") + `
+		GROUP BY group_key
+		ORDER BY group_key ASC
+		` + FormatLimitOffset(limit, offset)
+}
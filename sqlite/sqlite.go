@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/music-gang/music-gang-api/app/apperr"
+	"github.com/music-gang/music-gang-api/app/entity"
+)
+
+//go:embed migration/*.sql
+var migrationsFS embed.FS
+
+// migrationFuncs are the migrations that cannot be written in SQL, like the ones hashing the stored data.
+// They are executed in order with the migration files and tracked in the same way.
+var migrationFuncs = map[string]func(tx *sqlx.Tx) error{
+	"migration/011-update_revisions_table_backfill_code_hash": backfillRevisionsCodeHash,
+}
+
+// Tx wraps the SQL Tx object to provide a timestamp at the start of the transaction.
+type Tx struct {
+	*sqlx.Tx
+	db  *DB
+	now time.Time
+}
+
+// DB represents the database connection.
+type DB struct {
+	conn   *sqlx.DB
+	ctx    context.Context
+	cancel func()
+
+	DSN string
+
+	Now func() time.Time
+}
+
+// NewDB returns a new instance of DB with the given DSN.
+// The DSN is the path of the database file, optionally followed by the driver options,
+// see config.BuildDSNFromDatabaseConfigForSQLite.
+func NewDB(dsn string) *DB {
+	db := &DB{
+		DSN: dsn,
+		Now: time.Now,
+	}
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+	return db
+}
+
+// createMigrationsTable creates the migrations table if it doesn't exist.
+func (db *DB) createMigrationsTable() error {
+	if _, err := db.conn.Exec("CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY);"); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to create migrations table: %s", err)
+	}
+	return nil
+}
+
+// migrate sets up migration tracking and executes pending migration files.
+//
+// Migration files are embedded in the sqlite/migration folder and are executed
+// in lexigraphical order.
+//
+// Once a migration is run, its name is stored in the 'migrations' table so it
+// is not re-executed. Migrations run in a transaction to prevent partial
+// migrations
+func (db *DB) migrate() error {
+
+	if err := db.createMigrationsTable(); err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrationsFS, "migration/*.sql")
+	if err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to glob migrations: %s", err)
+	}
+
+	for name := range migrationFuncs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	tx, err := db.conn.Beginx()
+	if err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to begin transaction: %s", err)
+	}
+
+	for _, name := range names {
+		if err := db.migrateFile(tx, name); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// migrate runs a single migration file within a transaction. On success, the
+// migration file name is saved to the "migrations" table to prevent re-running.
+func (db *DB) migrateFile(tx *sqlx.Tx, name string) error {
+
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM migrations WHERE name = $1", name).Scan(&n); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed to query migrations: %s", err)
+	} else if n != 0 {
+		return nil
+	}
+
+	if fn, ok := migrationFuncs[name]; ok {
+
+		if err := fn(tx); err != nil {
+			return apperr.Errorf(apperr.EINTERNAL, "failed exec migration %s: %s", name, err)
+		}
+
+	} else if buf, err := fs.ReadFile(migrationsFS, name); err != nil {
+
+		return apperr.Errorf(apperr.EINTERNAL, "failed to read migration file: %s", err)
+
+	} else if _, err := tx.Exec(string(buf)); err != nil {
+
+		return apperr.Errorf(apperr.EINTERNAL, "failed exec migration %s: %s", name, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO migrations (name) VALUES ($1)", name); err != nil {
+		return apperr.Errorf(apperr.EINTERNAL, "failed insert migration: %s", err)
+	}
+
+	return nil
+}
+
+// backfillRevisionsCodeHash sets the code hash of the revisions created before the code_hash column,
+// sqlite has no sha256 function so the hash is computed here, as postgres does in its migration.
+func backfillRevisionsCodeHash(tx *sqlx.Tx) error {
+
+	rows, err := tx.Query("SELECT id, compiled_code FROM revisions WHERE code_hash = ''")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int64]string)
+
+	for rows.Next() {
+
+		var id int64
+		var code []byte
+
+		if err := rows.Scan(&id, &code); err != nil {
+			return err
+		}
+
+		hashes[id] = entity.HashRevisionCode(code)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, hash := range hashes {
+		if _, err := tx.Exec("UPDATE revisions SET code_hash = $1 WHERE id = $2", hash, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BeginTx starts a transaction and returns a wrapper Tx type. This type
+// provides a reference to the database and a fixed timestamp at the start of
+// the transaction. The timestamp allows us to mock time during tests as well.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.conn.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.EINTERNAL, "failed to begin transaction: %v", err)
+	}
+
+	// Return wrapper Tx that includes the transaction start time.
+	return &Tx{
+		Tx:  tx,
+		db:  db,
+		now: db.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// Close closes the database connection.
+func (db *DB) Close() error {
+	db.cancel()
+	if db.conn != nil {
+		return db.conn.Close()
+	}
+	return nil
+}
+
+// MustOpen opens the database and panics on error.
+func (db *DB) MustOpen() {
+	if err := db.Open(); err != nil {
+		panic(err)
+	}
+}
+
+// Open database connection
+func (db *DB) Open() error {
+
+	if db.DSN == "" {
+		return apperr.Errorf(apperr.EINVALID, "no DSN provided")
+	}
+
+	var err error
+
+	if db.conn, err = sqlx.Open("sqlite3", db.DSN); err != nil {
+		return err
+	}
+
+	if err := db.migrate(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/music-gang/music-gang-api/config"
+	"github.com/music-gang/music-gang-api/sqlite"
+)
+
+func TestDB(t *testing.T) {
+	db := MustOpenDB(t)
+	MustCloseDB(t, db)
+}
+
+// MustOpenDB opens a new database in a temporary directory, so every test starts from an empty schema.
+func MustOpenDB(tb testing.TB) *sqlite.DB {
+
+	tb.Helper()
+
+	dsn := config.BuildDSNFromDatabaseConfigForSQLite(config.SQLiteConfig{
+		Path: filepath.Join(tb.TempDir(), "musicgang.db"),
+	})
+
+	db := sqlite.NewDB(dsn)
+	if err := db.Open(); err != nil {
+		tb.Fatal(err)
+	}
+
+	return db
+}
+
+func MustCloseDB(tb testing.TB, db *sqlite.DB) {
+
+	tb.Helper()
+
+	if err := db.Close(); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func MustExec(tb testing.TB, db *sqlite.DB, sql string, args ...interface{}) {
+
+	tb.Helper()
+
+	if _, err := sqlite.GetConn(db).Exec(sql, args...); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func MustTruncateTable(tb testing.TB, db *sqlite.DB, table string) {
+
+	tb.Helper()
+
+	if _, err := sqlite.GetConn(db).Exec("DELETE FROM " + table); err != nil {
+		tb.Fatal(err)
+	} else if _, err := sqlite.GetConn(db).Exec("DELETE FROM sqlite_sequence WHERE name = $1", table); err != nil {
+		tb.Fatal(err)
+	}
+}
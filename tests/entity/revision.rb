@@ -4,7 +4,7 @@
 class Revision
   include Jsonizable
 
-  attr_accessor :id, :created_at, :rev, :version, :contract_id, :notes, :max_fuel
+  attr_accessor :id, :created_at, :rev, :version, :contract_id, :notes, :max_fuel, :code_hash, :max_memory
 
   def initialize(id: nil,
                  created_at: nil,
@@ -12,7 +12,9 @@ class Revision
                  version: nil,
                  contract_id: nil,
                  notes: nil,
-                 max_fuel: nil)
+                 max_fuel: nil,
+                 code_hash: nil,
+                 max_memory: nil)
     @id = id
     @created_at = created_at
     @rev = rev
@@ -20,6 +22,8 @@ class Revision
     @contract_id = contract_id
     @notes = notes
     @max_fuel = max_fuel
+    @code_hash = code_hash
+    @max_memory = max_memory
   end
 
   def to_hash
@@ -30,7 +34,9 @@ class Revision
       version: @version,
       contract_id: @contract_id,
       notes: @notes,
-      max_fuel: @max_fuel
+      max_fuel: @max_fuel,
+      code_hash: @code_hash,
+      max_memory: @max_memory
     }
   end
 
@@ -40,7 +46,7 @@ class Revision
     # @return [Revision]
     def from_hash(hash)
       validate_hash hash
-      Revision.new id: hash[:id], created_at: Time.parse(hash[:created_at]), rev: hash[:rev], version: hash[:version], contract_id: hash[:contract_id], notes: hash[:notes], max_fuel: hash[:max_fuel]
+      Revision.new id: hash[:id], created_at: Time.parse(hash[:created_at]), rev: hash[:rev], version: hash[:version], contract_id: hash[:contract_id], notes: hash[:notes], max_fuel: hash[:max_fuel], code_hash: hash[:code_hash], max_memory: hash[:max_memory]
     end
 
     # Validate hash.
@@ -53,6 +59,8 @@ class Revision
       raise 'missing contract id' unless hash.key? :contract_id
       raise 'missing notes' unless hash.key? :notes
       raise 'missing max fuel' unless hash.key? :max_fuel
+      raise 'missing code hash' unless hash.key? :code_hash
+      raise 'missing max memory' unless hash.key? :max_memory
     end
   end
 end
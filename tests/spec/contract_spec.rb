@@ -158,11 +158,25 @@ describe 'Flow Contract:' do
         contract = success_create_contract user
         revision = Revision.new version: 'Anchorage', notes: 'New revision!', max_fuel: 3000, contract_id: contract.id
 
-        rev1 = container.contract_service.make_revision access_token: user.token_pairs.access_token, contract: contract, revision: revision
-        rev2 = container.contract_service.make_revision access_token: user.token_pairs.access_token, contract: contract, revision: revision
+        rev = container.contract_service.make_revision access_token: user.token_pairs.access_token, contract: contract, revision: revision
 
-        expect(rev1.version).to eq rev2.version
-        expect(rev1.rev).not_to eq rev2.rev
+        expect(rev.version).to eq 'Anchorage'
+        expect(rev.rev).to eq 1
+        expect(rev.code_hash).not_to be_empty
+      end
+    end
+
+    context 'given the same code of the last revision' do
+      it 'returns an error' do
+        contract = success_create_contract user
+        revision = Revision.new version: 'Anchorage', notes: 'New revision!', max_fuel: 3000, contract_id: contract.id
+
+        container.contract_service.make_revision access_token: user.token_pairs.access_token, contract: contract, revision: revision
+        container.contract_service.make_revision access_token: user.token_pairs.access_token, contract: contract, revision: revision
+      rescue ServiceError
+        'error correctley raised'
+      else
+        raise 'error not raised'
       end
     end
 